@@ -0,0 +1,98 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/manifest"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+)
+
+type applyCmd struct {
+	config    string
+	file      string
+	workspace string
+	dryRun    bool
+	prune     bool
+}
+
+func (a *applyCmd) validate() error {
+	if a.file == "" {
+		return errors.New("--file is required")
+	}
+	return nil
+}
+
+func (a *applyCmd) run(out io.Writer) error {
+	m, err := manifest.Load(a.file)
+	if err != nil {
+		return err
+	}
+	dbClient, err := server.OpenDatabase()
+	if err != nil {
+		return err
+	}
+	_, err = db.GetWorkspace(dbClient, a.workspace)
+	if err != nil {
+		return err
+	}
+	store, err := server.NewStorage(dbClient)
+	if err != nil {
+		return err
+	}
+	// the manifest only reads and converges the resources of the workspace
+	ctx := db.WithActor(db.WithWorkspace(context.Background(), a.workspace), db.CommandActor("apply"))
+	changes, err := manifest.Plan(ctx, store, m, a.prune)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No changes")
+		return nil
+	}
+	for _, change := range changes {
+		fmt.Fprintln(out, change)
+	}
+	if a.dryRun {
+		fmt.Fprintf(out, "%d changes planned, none applied (dry run)\n", len(changes))
+		return nil
+	}
+	err = manifest.Apply(ctx, store, changes)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d changes applied\n", len(changes))
+	return nil
+}
+
+func NewApplyCmd() *cobra.Command {
+	c := &applyCmd{}
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "converge the checks, notification channels and status pages to a manifest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			viper.SetConfigFile(c.config)
+			err := viper.ReadInConfig()
+			if err != nil {
+				return err
+			}
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(cmd.OutOrStdout())
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.config, "config", "", "statuspage", "Configuration file")
+	persistentFlags.StringVarP(&c.file, "file", "f", "", "YAML or JSON manifest, - reads from the standard input")
+	persistentFlags.StringVarP(&c.workspace, "workspace", "", db.DefaultWorkspaceID, "Workspace of the resources")
+	persistentFlags.BoolVarP(&c.dryRun, "dry-run", "", false, "Print the changes without applying them")
+	persistentFlags.BoolVarP(&c.prune, "prune", "", false, "Delete the resources missing from the manifest")
+
+	cmd.MarkPersistentFlagRequired("config")
+	return cmd
+}
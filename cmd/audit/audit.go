@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"time"
+)
+
+type Format string
+
+const (
+	JSONFormat Format = "json"
+	CSVFormat  Format = "csv"
+)
+
+// NewAuditCmd reads the audit log directly from the database
+func NewAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "export the audit log of the configuration changes",
+	}
+	cmd.AddCommand(newAuditExportCmd())
+	return cmd
+}
+
+type auditExportCmd struct {
+	config       string
+	workspace    string
+	format       string
+	output       string
+	since        time.Duration
+	resourceType string
+	resourceID   string
+	actor        string
+}
+
+func (a *auditExportCmd) validate() error {
+	switch Format(a.format) {
+	case JSONFormat, CSVFormat:
+		return nil
+	default:
+		return errors.Errorf("Invalid format %s, expected json or csv", a.format)
+	}
+}
+
+func (a *auditExportCmd) run(out io.Writer) error {
+	dbClient, err := server.OpenDatabase()
+	if err != nil {
+		return err
+	}
+	_, err = db.GetWorkspace(dbClient, a.workspace)
+	if err != nil {
+		return err
+	}
+	dbClient = dbClient.WithContext(db.WithWorkspace(context.Background(), a.workspace))
+	filter := db.AuditFilter{
+		ResourceType: db.ResourceType(a.resourceType),
+		ResourceID:   a.resourceID,
+		Actor:        a.actor,
+	}
+	if a.since > 0 {
+		from := time.Now().Add(-a.since)
+		filter.From = &from
+	}
+	if a.output != "-" {
+		file, err := os.Create(a.output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	if Format(a.format) == CSVFormat {
+		return exportCSV(dbClient, filter, out)
+	}
+	return exportJSON(dbClient, filter, out)
+}
+
+// auditEntry is the exported entry, before and after are kept as JSON
+type auditEntry struct {
+	ID           string          `json:"id"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	Actor        string          `json:"actor"`
+	ActorID      string          `json:"actorId"`
+	ActorMethod  string          `json:"actorMethod"`
+	Operation    string          `json:"operation"`
+	ResourceType string          `json:"resourceType"`
+	ResourceID   string          `json:"resourceId"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+}
+
+// exportJSON writes a JSON document per line
+func exportJSON(dbClient *gorm.DB, filter db.AuditFilter, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	return db.ExportAuditEntries(dbClient, filter, func(entry db.AuditEntry) error {
+		return encoder.Encode(auditEntry{
+			ID:           entry.ID,
+			CreatedAt:    entry.CreatedAt.UTC(),
+			Actor:        entry.Actor,
+			ActorID:      entry.ActorID,
+			ActorMethod:  entry.ActorMethod,
+			Operation:    string(entry.Operation),
+			ResourceType: string(entry.ResourceType),
+			ResourceID:   entry.ResourceID,
+			Before:       rawJSON(entry.Before),
+			After:        rawJSON(entry.After),
+		})
+	})
+}
+
+func exportCSV(dbClient *gorm.DB, filter db.AuditFilter, out io.Writer) error {
+	w := csv.NewWriter(out)
+	err := w.Write([]string{"id", "created_at", "actor", "actor_id", "actor_method", "operation", "resource_type", "resource_id", "before", "after"})
+	if err != nil {
+		return err
+	}
+	err = db.ExportAuditEntries(dbClient, filter, func(entry db.AuditEntry) error {
+		return w.Write([]string{
+			entry.ID,
+			entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+			entry.Actor,
+			entry.ActorID,
+			entry.ActorMethod,
+			string(entry.Operation),
+			string(entry.ResourceType),
+			entry.ResourceID,
+			string(entry.Before),
+			string(entry.After),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func rawJSON(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(data)
+}
+
+func newAuditExportCmd() *cobra.Command {
+	c := &auditExportCmd{}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the audit log of a workspace in chronological order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			viper.SetConfigFile(c.config)
+			err := viper.ReadInConfig()
+			if err != nil {
+				return err
+			}
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(cmd.OutOrStdout())
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&c.config, "config", "", "statuspage", "Configuration file")
+	flags.StringVarP(&c.workspace, "workspace", "", db.DefaultWorkspaceID, "Workspace of the audit log")
+	flags.StringVarP(&c.format, "format", "", string(JSONFormat), "Format of the export: json, a document per line, or csv")
+	flags.StringVarP(&c.output, "output", "o", "-", "File written, - writes to the standard output")
+	flags.DurationVarP(&c.since, "since", "", 0, "Only export the changes of the given period, such as 720h")
+	flags.StringVarP(&c.resourceType, "resource-type", "", "", "Only export the changes of a type of resource, such as check")
+	flags.StringVarP(&c.resourceID, "resource-id", "", "", "Only export the changes of a resource")
+	flags.StringVarP(&c.actor, "actor", "", "", "Only export the changes of an actor")
+	cmd.MarkFlagRequired("config")
+	return cmd
+}
@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/pkg/backup"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// openStorage connects to the database of the configuration, the statements are restricted to
+// the workspace and the changes are recorded in the audit log as made by the command
+func openStorage(config string, workspace string, command string) (storage.Storage, context.Context, error) {
+	viper.SetConfigFile(config)
+	err := viper.ReadInConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	dbClient, err := server.OpenDatabase()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = db.GetWorkspace(dbClient, workspace)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := db.WithWorkspace(context.Background(), workspace)
+	ctx = db.WithActor(ctx, db.CommandActor(command))
+	store, err := server.NewStorage(dbClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, ctx, nil
+}
+
+type exportCmd struct {
+	config     string
+	workspace  string
+	output     string
+	executions bool
+}
+
+func (e *exportCmd) validate() error {
+	if e.output == "" {
+		return errors.New("--output is required")
+	}
+	return nil
+}
+
+func (e *exportCmd) run(out io.Writer) error {
+	store, ctx, err := openStorage(e.config, e.workspace, "export")
+	if err != nil {
+		return err
+	}
+	archive := out
+	if e.output != "-" {
+		file, err := os.Create(e.output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		archive = file
+	}
+	counts, err := backup.Export(ctx, store, archive, backup.ExportOptions{Executions: e.executions})
+	if err != nil {
+		return err
+	}
+	if e.output == "-" {
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tEXPORTED")
+	for _, kind := range backup.Kinds {
+		if kind == backup.ExecutionKind && !e.executions {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\n", kind, counts[kind])
+	}
+	return w.Flush()
+}
+
+// NewExportCmd dumps the resources of a workspace to an archive restored by `statuspage import`,
+// the archive doesn't depend on the database so that it can be restored in another one
+func NewExportCmd() *cobra.Command {
+	c := &exportCmd{}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the checks, notification channels, status pages, maintenance windows and incidents",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(cmd.OutOrStdout())
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.config, "config", "", "statuspage", "Configuration file")
+	persistentFlags.StringVarP(&c.workspace, "workspace", "", db.DefaultWorkspaceID, "Workspace to export")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "JSON lines archive, - writes to the standard output")
+	persistentFlags.BoolVarP(&c.executions, "executions", "", false, "Include the execution history, compacted executions are not exported")
+
+	cmd.MarkPersistentFlagRequired("config")
+	return cmd
+}
+
+type importCmd struct {
+	config     string
+	workspace  string
+	file       string
+	onConflict string
+	executions bool
+}
+
+func (i *importCmd) validate() error {
+	if i.file == "" {
+		return errors.New("--file is required")
+	}
+	switch backup.ConflictPolicy(i.onConflict) {
+	case backup.FailOnConflict, backup.SkipConflicts, backup.OverwriteConflicts:
+		return nil
+	default:
+		return errors.Errorf("Invalid conflict policy %s, expected fail, skip or overwrite", i.onConflict)
+	}
+}
+
+func (i *importCmd) run(in io.Reader, out io.Writer) error {
+	store, ctx, err := openStorage(i.config, i.workspace, "import")
+	if err != nil {
+		return err
+	}
+	if i.file != "-" {
+		file, err := os.Open(i.file)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	summary, err := backup.Import(ctx, store, in, backup.ImportOptions{
+		OnConflict: backup.ConflictPolicy(i.onConflict),
+		Executions: i.executions,
+	})
+	if summary != nil {
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "KIND\tCREATED\tUPDATED\tSKIPPED")
+		for _, kind := range backup.Kinds {
+			result, ok := summary[kind]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", kind, result.Created, result.Updated, result.Skipped)
+		}
+		w.Flush()
+	}
+	return err
+}
+
+// NewImportCmd restores an archive written by `statuspage export`, the resources get new ids
+// so that an archive can be imported in any workspace
+func NewImportCmd() *cobra.Command {
+	c := &importCmd{}
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "import the resources exported by statuspage export",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.config, "config", "", "statuspage", "Configuration file")
+	persistentFlags.StringVarP(&c.workspace, "workspace", "", db.DefaultWorkspaceID, "Workspace the resources are imported in")
+	persistentFlags.StringVarP(&c.file, "file", "f", "", "JSON lines archive, - reads from the standard input")
+	persistentFlags.StringVarP(&c.onConflict, "on-conflict", "", string(backup.FailOnConflict), "What to do with the existing resources: fail, skip or overwrite")
+	persistentFlags.BoolVarP(&c.executions, "executions", "", true, "Import the execution history of the archive for the checks created by the import")
+
+	cmd.MarkPersistentFlagRequired("config")
+	return cmd
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"github.com/kfsoftware/statuspage/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+func NewCheckCmd() *cobra.Command {
+	o := &clientOptions{}
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "manage the checks of a running server",
+	}
+	o.addFlags(cmd)
+	cmd.AddCommand(
+		newCheckListCmd(o),
+		newCheckGetCmd(o),
+		newCheckCreateCmd(o),
+		newCheckDeleteCmd(o),
+		newCheckPauseCmd(o),
+		newCheckResumeCmd(o),
+	)
+	return cmd
+}
+
+type checkListCmd struct {
+	types    []string
+	statuses []string
+	owner    string
+	labels   []string
+}
+
+func newCheckListCmd(o *clientOptions) *cobra.Command {
+	c := &checkListCmd{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the checks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(); err != nil {
+				return err
+			}
+			filter := client.CheckFilter{Statuses: c.statuses}
+			for _, checkType := range c.types {
+				filter.Types = append(filter.Types, strings.ToUpper(checkType))
+			}
+			if c.owner != "" {
+				filter.Owner = &c.owner
+			}
+			labels, err := parseLabels(c.labels)
+			if err != nil {
+				return err
+			}
+			filter.Labels = labels
+			checks, err := o.client().ListChecks(filter)
+			if err != nil {
+				return err
+			}
+			if checks == nil {
+				checks = []client.Check{}
+			}
+			return o.print(cmd.OutOrStdout(), checks, func(w io.Writer) {
+				printCheckHeader(w)
+				for _, chk := range checks {
+					printCheckRow(w, chk)
+				}
+			})
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringSliceVarP(&c.types, "type", "t", nil, "Only list the checks of these types")
+	flags.StringSliceVarP(&c.statuses, "status", "s", nil, "Only list the checks with these statuses")
+	flags.StringVarP(&c.owner, "owner", "", "", "Only list the checks of this owner")
+	flags.StringArrayVarP(&c.labels, "label", "l", nil, "Only list the checks having this label, as name=value")
+	return cmd
+}
+
+func newCheckGetCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id|identifier>",
+		Short: "show a check",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(); err != nil {
+				return err
+			}
+			chk, err := o.client().GetCheck(args[0])
+			if err != nil {
+				return err
+			}
+			return o.print(cmd.OutOrStdout(), chk, func(w io.Writer) {
+				printRow(w, "ID:", chk.ID)
+				printRow(w, "IDENTIFIER:", chk.Identifier)
+				printRow(w, "TYPE:", checkType(*chk))
+				printRow(w, "TARGET:", chk.Target())
+				printRow(w, "FRECUENCY:", chk.Frecuency)
+				printRow(w, "STATUS:", chk.Status)
+				printRow(w, "LATEST CHECK:", formatTime(chk.LatestCheck))
+				printRow(w, "MESSAGE:", chk.Message)
+				printRow(w, "ERROR:", chk.ErrorMsg)
+				printRow(w, "DESCRIPTION:", chk.Description)
+				printRow(w, "OWNER:", chk.Owner)
+				printRow(w, "LABELS:", formatLabels(chk.Labels))
+			})
+		},
+	}
+}
+
+type checkCreateCmd struct {
+	checkType   string
+	identifier  string
+	frecuency   string
+	url         string
+	address     string
+	rootCAs     string
+	description string
+	owner       string
+	labels      []string
+}
+
+func newCheckCreateCmd(o *clientOptions) *cobra.Command {
+	c := &checkCreateCmd{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a check",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(); err != nil {
+				return err
+			}
+			input, err := c.input()
+			if err != nil {
+				return err
+			}
+			chk, err := o.client().CreateCheck(c.checkType, *input)
+			if err != nil {
+				return err
+			}
+			return o.print(cmd.OutOrStdout(), chk, func(w io.Writer) {
+				printCheckHeader(w)
+				printCheckRow(w, *chk)
+			})
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&c.checkType, "type", "t", "", "Type of the check: http, tcp, tls or icmp")
+	flags.StringVarP(&c.identifier, "id", "", "", "Identifier of the check")
+	flags.StringVarP(&c.frecuency, "frecuency", "", "1m", "Frecuency of the check")
+	flags.StringVarP(&c.url, "url", "", "", "Url probed by http checks")
+	flags.StringVarP(&c.address, "address", "", "", "Address probed by tcp, tls and icmp checks")
+	flags.StringVarP(&c.rootCAs, "root-cas", "", "", "PEM file with the root CAs of tls checks")
+	flags.StringVarP(&c.description, "description", "", "", "Description of the check")
+	flags.StringVarP(&c.owner, "owner", "", "", "Owner of the check")
+	flags.StringArrayVarP(&c.labels, "label", "l", nil, "Label of the check, as name=value")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("id")
+	return cmd
+}
+
+func (c *checkCreateCmd) input() (*client.CreateCheckInput, error) {
+	input := &client.CreateCheckInput{
+		ID:        c.identifier,
+		Frecuency: c.frecuency,
+	}
+	switch c.checkType {
+	case "http":
+		if c.url == "" {
+			return nil, errors.New("--url is required for http checks")
+		}
+		input.Url = c.url
+	case "tcp", "tls", "icmp":
+		if c.address == "" {
+			return nil, errors.Errorf("--address is required for %s checks", c.checkType)
+		}
+		input.Address = c.address
+	default:
+		return nil, errors.Errorf("Invalid check type %s, expected http, tcp, tls or icmp", c.checkType)
+	}
+	if c.rootCAs != "" {
+		if c.checkType != "tls" {
+			return nil, errors.New("--root-cas is only supported by tls checks")
+		}
+		pem, err := ioutil.ReadFile(c.rootCAs)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := string(pem)
+		input.RootCAs = &rootCAs
+	}
+	if c.description != "" {
+		input.Description = &c.description
+	}
+	if c.owner != "" {
+		input.Owner = &c.owner
+	}
+	labels, err := parseLabels(c.labels)
+	if err != nil {
+		return nil, err
+	}
+	input.Labels = labels
+	return input, nil
+}
+
+func newCheckDeleteCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id|identifier>",
+		Short: "delete a check, its history is kept",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(); err != nil {
+				return err
+			}
+			c := o.client()
+			chk, err := c.GetCheck(args[0])
+			if err != nil {
+				return err
+			}
+			err = c.DeleteCheck(chk.ID)
+			if err != nil {
+				return err
+			}
+			return o.print(cmd.OutOrStdout(), map[string]string{"id": chk.ID}, func(w io.Writer) {
+				printRow(w, "Check", chk.Identifier, "deleted")
+			})
+		},
+	}
+}
+
+func newCheckPauseCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause <id|identifier>",
+		Short: "stop executing a check until it is resumed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.updateCheck(cmd.OutOrStdout(), args[0], (*client.Client).PauseCheck)
+		},
+	}
+}
+
+func newCheckResumeCmd(o *clientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume <id|identifier>",
+		Short: "resume a paused check",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.updateCheck(cmd.OutOrStdout(), args[0], (*client.Client).ResumeCheck)
+		},
+	}
+}
+
+func (o *clientOptions) updateCheck(out io.Writer, idOrIdentifier string, mutation func(c *client.Client, id string) (*client.Check, error)) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	c := o.client()
+	chk, err := c.GetCheck(idOrIdentifier)
+	if err != nil {
+		return err
+	}
+	chk, err = mutation(c, chk.ID)
+	if err != nil {
+		return err
+	}
+	return o.print(out, chk, func(w io.Writer) {
+		printCheckHeader(w)
+		printCheckRow(w, *chk)
+	})
+}
+
+func printCheckHeader(w io.Writer) {
+	printRow(w, "ID", "IDENTIFIER", "TYPE", "TARGET", "FRECUENCY", "STATUS", "LATEST CHECK", "OWNER", "LABELS")
+}
+
+func printCheckRow(w io.Writer, chk client.Check) {
+	printRow(w, chk.ID, chk.Identifier, checkType(chk), chk.Target(), chk.Frecuency, chk.Status, formatTime(chk.LatestCheck), valueOrDash(chk.Owner), formatLabels(chk.Labels))
+}
+
+// checkType returns http for HttpCheck
+func checkType(chk client.Check) string {
+	return strings.ToLower(strings.TrimSuffix(chk.Type, "Check"))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func formatLabels(labels []client.Label) string {
+	var formatted []string
+	for _, label := range labels {
+		formatted = append(formatted, label.Name+"="+label.Value)
+	}
+	return valueOrDash(strings.Join(formatted, ","))
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// parseLabels parses labels written as name=value
+func parseLabels(values []string) ([]client.Label, error) {
+	var labels []client.Label
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("Invalid label %s, expected name=value", value)
+		}
+		labels = append(labels, client.Label{Name: parts[0], Value: parts[1]})
+	}
+	return labels, nil
+}
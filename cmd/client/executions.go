@@ -0,0 +1,59 @@
+package client
+
+import (
+	"github.com/kfsoftware/statuspage/pkg/client"
+	"github.com/spf13/cobra"
+	"io"
+	"time"
+)
+
+type executionsCmd struct {
+	since    time.Duration
+	statuses []string
+	limit    int
+}
+
+func NewExecutionsCmd() *cobra.Command {
+	o := &clientOptions{}
+	c := &executionsCmd{}
+	cmd := &cobra.Command{
+		Use:   "executions <id|identifier>",
+		Short: "list the latest executions of a check",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(); err != nil {
+				return err
+			}
+			cl := o.client()
+			chk, err := cl.GetCheck(args[0])
+			if err != nil {
+				return err
+			}
+			filter := client.ExecutionFilter{
+				Statuses: c.statuses,
+				Limit:    c.limit,
+			}
+			if c.since > 0 {
+				from := time.Now().Add(-c.since)
+				filter.From = &from
+			}
+			executions, err := cl.ListExecutions(chk.ID, filter)
+			if err != nil {
+				return err
+			}
+			return o.print(cmd.OutOrStdout(), executions, func(w io.Writer) {
+				printRow(w, "TIME", "STATUS", "LATENCY", "MAINTENANCE", "MESSAGE", "ERROR")
+				for _, execution := range executions {
+					latency := time.Duration(execution.Latency * float64(time.Millisecond)).Round(time.Millisecond)
+					printRow(w, formatTime(&execution.ExecutionTime), execution.Status, latency, execution.Maintenance, valueOrDash(execution.Message), valueOrDash(execution.ErrorMsg))
+				}
+			})
+		},
+	}
+	o.addFlags(cmd)
+	flags := cmd.Flags()
+	flags.DurationVarP(&c.since, "since", "", 0, "Only list the executions newer than this duration, such as 1h")
+	flags.StringSliceVarP(&c.statuses, "status", "s", nil, "Only list the executions with these statuses")
+	flags.IntVarP(&c.limit, "limit", "", 20, "Maximum number of executions")
+	return cmd
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kfsoftware/statuspage/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+)
+
+// clientOptions holds the flags shared by the commands calling a running server
+type clientOptions struct {
+	server string
+	token  string
+	output string
+}
+
+func (o *clientOptions) addFlags(cmd *cobra.Command) {
+	server := os.Getenv("STATUSPAGE_SERVER")
+	if server == "" {
+		server = "http://localhost/graphql"
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&o.server, "server", "", server, "GraphQL endpoint of the server, defaults to $STATUSPAGE_SERVER")
+	persistentFlags.StringVarP(&o.token, "token", "", os.Getenv("STATUSPAGE_TOKEN"), "API token or JWT sent to the server, defaults to $STATUSPAGE_TOKEN")
+	persistentFlags.StringVarP(&o.output, "output", "o", tableOutput, "Output format: table, json or yaml")
+}
+
+func (o *clientOptions) validate() error {
+	switch o.output {
+	case tableOutput, jsonOutput, yamlOutput:
+		return nil
+	default:
+		return errors.Errorf("Invalid output %s, expected table, json or yaml", o.output)
+	}
+}
+
+func (o *clientOptions) client() *client.Client {
+	c := client.New(o.server)
+	c.Token = o.token
+	return c
+}
+
+// print writes v as JSON or YAML, or calls table to write it as a table
+func (o *clientOptions) print(out io.Writer, v interface{}, table func(w io.Writer)) error {
+	switch o.output {
+	case jsonOutput:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case yamlOutput:
+		yamlBytes, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(yamlBytes)
+		return err
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+}
+
+func printRow(w io.Writer, columns ...interface{}) {
+	for i, column := range columns {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, column)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kfsoftware/statuspage/pkg/client"
+	"gopkg.in/yaml.v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const checksResponse = `{"data": {"checks": {
+	"edges": [
+		{"node": {"type": "HttpCheck", "id": "1", "identifier": "api", "frecuency": "@every 1m", "status": "UP", "url": "https://example.com", "owner": "payments", "labels": [{"name": "env", "value": "prod"}]}},
+		{"node": {"type": "TcpCheck", "id": "2", "identifier": "postgres", "frecuency": "@every 5m", "status": "DOWN", "address": "localhost:5432", "labels": []}}
+	],
+	"pageInfo": {"hasNextPage": false}
+}}}`
+
+// listChecks runs check list against a server returning two checks
+func listChecks(t *testing.T, output string) (string, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(checksResponse))
+	}))
+	defer server.Close()
+	cmd := NewCheckCmd()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"list", "--server", server.URL, "--token", "", "-o", output})
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestOutput(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		out, err := listChecks(t, "table")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		want := [][]string{
+			{"ID", "IDENTIFIER", "TYPE", "TARGET", "FRECUENCY", "STATUS", "LATEST", "CHECK", "OWNER", "LABELS"},
+			{"1", "api", "http", "https://example.com", "@every", "1m", "UP", "-", "payments", "env=prod"},
+			{"2", "postgres", "tcp", "localhost:5432", "@every", "5m", "DOWN", "-", "-", "-"},
+		}
+		if len(lines) != len(want) {
+			t.Fatalf("table has %d lines, want %d:\n%s", len(lines), len(want), out)
+		}
+		for i, line := range lines {
+			if strings.Join(strings.Fields(line), " ") != strings.Join(want[i], " ") {
+				t.Errorf("line %d = %q, want %q", i, line, strings.Join(want[i], " "))
+			}
+		}
+		if strings.Index(lines[0], "TYPE") != strings.Index(lines[1], "http") {
+			t.Errorf("columns aren't aligned:\n%s", out)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		out, err := listChecks(t, "json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var checks []client.Check
+		err = json.Unmarshal([]byte(out), &checks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(checks) != 2 || checks[0].Url != "https://example.com" || checks[1].Address != "localhost:5432" {
+			t.Errorf("json = %+v, want both checks", checks)
+		}
+		if !strings.Contains(out, "\n  {") {
+			t.Errorf("json isn't indented:\n%s", out)
+		}
+	})
+	t.Run("yaml", func(t *testing.T) {
+		out, err := listChecks(t, "yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var checks []client.Check
+		err = yaml.Unmarshal([]byte(out), &checks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(checks) != 2 || checks[0].Labels[0].Name != "env" || checks[1].Status != "DOWN" {
+			t.Errorf("yaml = %+v, want both checks", checks)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := listChecks(t, "xml")
+		if err == nil || !strings.Contains(err.Error(), "Invalid output xml") {
+			t.Errorf("check list -o xml = %v, want an invalid output", err)
+		}
+	})
+}
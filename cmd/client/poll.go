@@ -0,0 +1,30 @@
+package client
+
+import (
+	"github.com/spf13/cobra"
+	"io"
+	"time"
+)
+
+func NewPollCmd() *cobra.Command {
+	o := &clientOptions{}
+	cmd := &cobra.Command{
+		Use:   "poll",
+		Short: "execute every check now",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(); err != nil {
+				return err
+			}
+			took, err := o.client().Poll()
+			if err != nil {
+				return err
+			}
+			return o.print(cmd.OutOrStdout(), map[string]int{"took": took}, func(w io.Writer) {
+				printRow(w, "Checks executed in", time.Duration(took)*time.Millisecond)
+			})
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
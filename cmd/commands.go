@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"github.com/kfsoftware/statuspage/cmd/apply"
+	"github.com/kfsoftware/statuspage/cmd/audit"
+	"github.com/kfsoftware/statuspage/cmd/backup"
+	"github.com/kfsoftware/statuspage/cmd/client"
+	"github.com/kfsoftware/statuspage/cmd/migrate"
+	"github.com/kfsoftware/statuspage/cmd/run"
 	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/cmd/token"
+	"github.com/kfsoftware/statuspage/cmd/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +28,17 @@ func NewCmdStatusPage() *cobra.Command {
 		Long:  statusPageDesc,
 	}
 	cmd.AddCommand(server.NewServerCmd())
+	cmd.AddCommand(migrate.NewMigrateCmd())
+	cmd.AddCommand(apply.NewApplyCmd())
+	cmd.AddCommand(client.NewCheckCmd())
+	cmd.AddCommand(client.NewExecutionsCmd())
+	cmd.AddCommand(client.NewPollCmd())
+	cmd.AddCommand(run.NewRunCmd())
+	cmd.AddCommand(token.NewTokenCmd())
+	cmd.AddCommand(workspace.NewWorkspaceCmd())
+	cmd.AddCommand(audit.NewAuditCmd())
+	cmd.AddCommand(backup.NewExportCmd())
+	cmd.AddCommand(backup.NewImportCmd())
 
 	return cmd
 }
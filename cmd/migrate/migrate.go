@@ -0,0 +1,159 @@
+package migrate
+
+import (
+	"fmt"
+	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"text/tabwriter"
+	"time"
+)
+
+// NewMigrateCmd applies the schema migrations embedded in the binary, the server refuses to start
+// until the database is migrated to its version
+func NewMigrateCmd() *cobra.Command {
+	var config string
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate the schema of the database",
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&config, "config", "", "statuspage", "Configuration file")
+	cmd.MarkPersistentFlagRequired("config")
+	connectDatabase := func() (*gorm.DB, error) {
+		viper.SetConfigFile(config)
+		err := viper.ReadInConfig()
+		if err != nil {
+			return nil, err
+		}
+		return server.ConnectDatabase()
+	}
+	cmd.AddCommand(
+		newMigrateUpCmd(connectDatabase),
+		newMigrateDownCmd(connectDatabase),
+		newMigrateStatusCmd(connectDatabase),
+	)
+	return cmd
+}
+
+type migrateUpCmd struct {
+	to int
+}
+
+func (m *migrateUpCmd) validate() error {
+	if m.to < 0 {
+		return errors.New("Version can't be negative")
+	}
+	return nil
+}
+
+func newMigrateUpCmd(connectDatabase func() (*gorm.DB, error)) *cobra.Command {
+	c := &migrateUpCmd{}
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "apply the pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			dbClient, err := connectDatabase()
+			if err != nil {
+				return err
+			}
+			migrations, err := db.MigrateUp(dbClient, c.to)
+			for _, migration := range migrations {
+				fmt.Fprintf(cmd.OutOrStdout(), "Applied %d %s\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(migrations) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No pending migrations")
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.IntVarP(&c.to, "to", "", 0, "Version to migrate to, the latest version by default")
+	return cmd
+}
+
+type migrateDownCmd struct {
+	steps int
+}
+
+func (m *migrateDownCmd) validate() error {
+	if m.steps < 1 {
+		return errors.New("At least one migration has to be reverted")
+	}
+	return nil
+}
+
+func newMigrateDownCmd(connectDatabase func() (*gorm.DB, error)) *cobra.Command {
+	c := &migrateDownCmd{}
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "revert the latest migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			dbClient, err := connectDatabase()
+			if err != nil {
+				return err
+			}
+			migrations, err := db.MigrateDown(dbClient, c.steps)
+			for _, migration := range migrations {
+				fmt.Fprintf(cmd.OutOrStdout(), "Reverted %d %s\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(migrations) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No applied migrations")
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.IntVarP(&c.steps, "steps", "", 1, "Number of migrations to revert")
+	return cmd
+}
+
+func newMigrateStatusCmd(connectDatabase func() (*gorm.DB, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "list the migrations and whether they are applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbClient, err := connectDatabase()
+			if err != nil {
+				return err
+			}
+			statuses, err := db.GetMigrationStatus(dbClient)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+			for _, status := range statuses {
+				state := "pending"
+				appliedAt := "-"
+				if status.AppliedAt != nil {
+					state = "applied"
+					appliedAt = status.AppliedAt.Format(time.RFC3339)
+				}
+				if status.Unknown {
+					state = "unknown, applied by a newer release"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+			}
+			return w.Flush()
+		},
+	}
+}
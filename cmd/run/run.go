@@ -0,0 +1,175 @@
+package run
+
+import (
+	"fmt"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/manifest"
+	"github.com/kfsoftware/statuspage/pkg/runner"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+type runCmd struct {
+	file        string
+	checkType   string
+	identifier  string
+	url         string
+	address     string
+	rootCAs     string
+	assertions  check.Assertions
+	junitReport string
+	jsonReport  string
+}
+
+func (r *runCmd) validate() error {
+	if r.file != "" && r.checkType != "" {
+		return errors.New("--file and --type cannot be used together")
+	}
+	if r.file == "" && r.checkType == "" {
+		return errors.New("either --file or --type is required")
+	}
+	return nil
+}
+
+// checks returns the checks of the manifest, or the check described by the flags,
+// the assertions set by flags apply to every check
+func (r *runCmd) checks() ([]manifest.Check, error) {
+	var checks []manifest.Check
+	if r.file != "" {
+		m, err := manifest.Load(r.file)
+		if err != nil {
+			return nil, err
+		}
+		checks = m.Checks
+	} else {
+		chk := manifest.Check{
+			ID:      r.identifier,
+			Type:    check.Type(r.checkType),
+			Url:     r.url,
+			Address: r.address,
+		}
+		if chk.ID == "" {
+			chk.ID = r.url + r.address
+		}
+		if r.rootCAs != "" {
+			pem, err := ioutil.ReadFile(r.rootCAs)
+			if err != nil {
+				return nil, err
+			}
+			chk.RootCAs = string(pem)
+		}
+		err := chk.Validate()
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, chk)
+	}
+	for i := range checks {
+		assertions := &checks[i].Assertions
+		if r.assertions.StatusCode != 0 {
+			assertions.StatusCode = r.assertions.StatusCode
+		}
+		if r.assertions.MaxLatency != 0 {
+			assertions.MaxLatency = r.assertions.MaxLatency
+		}
+		if r.assertions.MinCertValidity != 0 {
+			assertions.MinCertValidity = r.assertions.MinCertValidity
+		}
+	}
+	return checks, nil
+}
+
+func (r *runCmd) run(out io.Writer) error {
+	checks, err := r.checks()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	results := runner.Run(checks)
+	took := time.Since(start)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESULT\tCHECK\tTYPE\tTARGET\tLATENCY\tMESSAGE")
+	failed := 0
+	for _, result := range results {
+		status := "PASS"
+		message := result.Message
+		if !result.Passed {
+			status = "FAIL"
+			failed++
+			message = result.Failures[0]
+			for _, failure := range result.Failures[1:] {
+				message = fmt.Sprintf("%s; %s", message, failure)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", status, result.Name, result.Type, result.Target, result.Latency.Round(time.Microsecond), message)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d passed, %d failed in %s\n", len(results)-failed, failed, took.Round(time.Millisecond))
+	if r.junitReport != "" {
+		err = writeReport(r.junitReport, results, runner.WriteJUnit)
+		if err != nil {
+			return err
+		}
+	}
+	if r.jsonReport != "" {
+		err = writeReport(r.jsonReport, results, runner.WriteJSON)
+		if err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+func writeReport(file string, results []runner.Result, write func(w io.Writer, results []runner.Result) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = write(f, results)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func NewRunCmd() *cobra.Command {
+	c := &runCmd{}
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "execute checks once without a database, exiting with an error when any of them fails",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			// failed checks are reported by the output, not by the usage
+			cmd.SilenceUsage = true
+			return c.run(cmd.OutOrStdout())
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&c.file, "file", "f", "", "YAML or JSON manifest with the checks to execute, - reads from the standard input")
+	flags.StringVarP(&c.checkType, "type", "t", "", "Type of the check: http, tcp, tls or icmp")
+	flags.StringVarP(&c.identifier, "id", "", "", "Name of the check in the reports, defaults to its target")
+	flags.StringVarP(&c.url, "url", "", "", "Url probed by http checks")
+	flags.StringVarP(&c.address, "address", "", "", "Address probed by tcp, tls and icmp checks")
+	flags.StringVarP(&c.rootCAs, "root-cas", "", "", "PEM file with the root CAs of tls checks")
+	flags.IntVarP(&c.assertions.StatusCode, "expect-status", "", 0, "Status code expected from http checks, 200 when not set")
+	flags.DurationVarP(&c.assertions.MaxLatency, "max-latency", "", 0, "Fail the checks taking longer than this duration")
+	flags.DurationVarP(&c.assertions.MinCertValidity, "min-cert-validity", "", 0, "Fail the tls checks whose certificate expires within this duration")
+	flags.StringVarP(&c.junitReport, "junit-report", "", "", "Write a JUnit report to this file")
+	flags.StringVarP(&c.jsonReport, "json-report", "", "", "Write a JSON report to this file")
+	return cmd
+}
@@ -0,0 +1,43 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunManifestWithoutFrequency(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	file := filepath.Join(t.TempDir(), "checks.yaml")
+	manifest := fmt.Sprintf("checks:\n  - id: api\n    type: tcp\n    address: %s\n", listener.Addr())
+	err = ioutil.WriteFile(file, []byte(manifest), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	r := &runCmd{file: file}
+	err = r.run(out)
+	if err != nil {
+		t.Fatalf("run -f = %v, output:\n%s", err, out)
+	}
+	if !strings.Contains(out.String(), "1 passed, 0 failed") {
+		t.Errorf("run -f output:\n%s\nwant the check passed", out)
+	}
+}
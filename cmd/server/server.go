@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/apollotracing"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
@@ -9,9 +10,17 @@ import (
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kfsoftware/statuspage/pkg/auth"
 	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/events"
+	"github.com/kfsoftware/statuspage/pkg/exporter"
 	"github.com/kfsoftware/statuspage/pkg/graphql/generated"
 	"github.com/kfsoftware/statuspage/pkg/graphql/resolvers"
+	"github.com/kfsoftware/statuspage/pkg/scheduler"
+	"github.com/kfsoftware/statuspage/pkg/sink"
+	"github.com/kfsoftware/statuspage/pkg/statuspage"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/kfsoftware/statuspage/pkg/tracing"
 	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
@@ -34,38 +43,31 @@ func (s *serverCmd) validate() error {
 	return nil
 }
 func (s *serverCmd) run() error {
-	var err error
-	provider := viper.GetString("database.type")
-	var dbClient *gorm.DB
-	switch provider {
-	case string(Database):
-		driverName := viper.GetString("database.driver")
-		dataSource := viper.GetString("database.dataSource")
-		var drName DriverName
-		switch driverName {
-		case PostgresqlDriver:
-			drName = PostgresqlDriver
-		case MySQLDriver:
-			drName = MySQLDriver
-		default:
-			return errors.Errorf("Driver %s not supported", driverName)
-		}
-		dbClient, err = newDbStorage(
-			drName,
-			dataSource,
-		)
+	shutdownTracing, err := tracing.Setup()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := shutdownTracing(context.Background())
 		if err != nil {
-			return err
+			log.Warnf("Failed flushing the traces: %v", err)
 		}
-	default:
-		return errors.Errorf("No valid provider: %s", provider)
+	}()
+	dbClient, err := OpenDatabase()
+	if err != nil {
+		return err
 	}
 
 	r := gin.Default()
 
+	store, err := NewStorage(dbClient)
+	if err != nil {
+		return err
+	}
+	bus := events.NewBus()
 	c := cron.New(cron.WithSeconds())
 	go func() {
-		db.CheckAll(dbClient)
+		scheduler.CheckAll(context.Background(), store, bus)
 	}()
 	spec := viper.GetString("cron")
 	if spec == "" {
@@ -73,7 +75,18 @@ func (s *serverCmd) run() error {
 		log.Warnf("`cron` property not set, defaulting to %s", spec)
 	}
 	_, err = c.AddFunc(spec, func() {
-		db.CheckAll(dbClient)
+		scheduler.CheckAll(context.Background(), store, bus)
+	})
+	if err != nil {
+		return err
+	}
+	compactionSpec := viper.GetString("retention.cron")
+	if compactionSpec == "" {
+		compactionSpec = "@every 1h"
+	}
+	_, err = c.AddFunc(compactionSpec, func() {
+		scheduler.Compact(context.Background(), store)
+		scheduler.PurgeDeletedChecks(context.Background(), store)
 	})
 	if err != nil {
 		return err
@@ -81,14 +94,23 @@ func (s *serverCmd) run() error {
 	c.Start()
 	es := generated.NewExecutableSchema(generated.Config{
 		Resolvers: &resolvers.Resolver{
-			Db: dbClient,
+			Storage: store,
+			Bus:     bus,
+		},
+		Directives: generated.DirectiveRoot{
+			HasRole: resolvers.HasRole,
 		},
 	})
 	h := handler.New(es)
+	authenticators, err := auth.NewAuthenticators(context.Background(), dbClient)
+	if err != nil {
+		return err
+	}
 
 	h.AddTransport(transport.Websocket{
 		KeepAlivePingInterval: 10 * time.Second,
 		Upgrader:              wsupgrader,
+		InitFunc:              auth.WebsocketInit(authenticators),
 	})
 	h.AddTransport(transport.Options{})
 	h.AddTransport(transport.GET{})
@@ -97,24 +119,41 @@ func (s *serverCmd) run() error {
 
 	h.SetQueryCache(lru.New(1000))
 	h.Use(extension.Introspection{})
+	h.Use(auth.Guard{
+		// the status pages are only readable without credentials when enabled
+		Anonymous: viper.GetBool("auth.anonymous"),
+	})
 	h.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New(100),
 	})
 	h.Use(apollotracing.Tracer{})
+	h.Use(tracing.GraphQLTracer{})
 
+	allowedOrigins := viper.GetStringSlice("cors.allowedOrigins")
 	r.Any("/graphql",
 		func(c *gin.Context) {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+			origin := allowedOrigin(allowedOrigins, c.GetHeader("Origin"))
+			if origin != "" {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			}
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Identity")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
-			h.ServeHTTP(c.Writer, c.Request)
+		},
+		auth.Middleware(authenticators),
+		func(c *gin.Context) {
+			h.ServeHTTP(c.Writer, tracing.Extract(c.Request))
 		},
 	)
 	playgroundHandler := playground.Handler("GraphQL", "/graphql")
 	r.GET("/playground", func(c *gin.Context) {
 		playgroundHandler.ServeHTTP(c.Writer, c.Request)
 	})
+	statuspage.Handler{Db: dbClient, Executions: store.Executions()}.Register(r)
+	err = exporter.Register(r, store)
+	if err != nil {
+		return err
+	}
 	listenAddr := s.addr
 	if listenAddr == "" {
 		listenAddr = viper.GetString("address")
@@ -153,11 +192,111 @@ func NewServerCmd() *cobra.Command {
 	return cmd
 }
 
+// OpenDatabase connects to the database set in the configuration, it refuses databases whose
+// schema isn't migrated to the version of this release
+func OpenDatabase() (*gorm.DB, error) {
+	dbClient, err := ConnectDatabase()
+	if err != nil {
+		return nil, err
+	}
+	err = db.CheckSchema(dbClient)
+	if err != nil {
+		return nil, err
+	}
+	return dbClient, nil
+}
+
+// ConnectDatabase connects to the database set in the configuration without verifying its schema
+func ConnectDatabase() (*gorm.DB, error) {
+	provider := viper.GetString("database.type")
+	switch provider {
+	case string(Database):
+		driverName := viper.GetString("database.driver")
+		dataSource := viper.GetString("database.dataSource")
+		var drName DriverName
+		switch driverName {
+		case PostgresqlDriver:
+			drName = PostgresqlDriver
+		case MySQLDriver:
+			drName = MySQLDriver
+		case SQLiteDriver:
+			drName = SQLiteDriver
+		default:
+			return nil, errors.Errorf("Driver %s not supported", driverName)
+		}
+		return newDbStorage(
+			drName,
+			dataSource,
+		)
+	default:
+		return nil, errors.Errorf("No valid provider: %s", provider)
+	}
+}
+
+// NewStorage returns the storage of the database, the executions are written to the sink set in
+// `executions.sink` unless they are kept in the database only
+func NewStorage(dbClient *gorm.DB) (storage.Storage, error) {
+	store := storage.NewGormStorage(dbClient)
+	executionSink, err := newExecutionSink()
+	if err != nil {
+		return nil, err
+	}
+	if executionSink == nil {
+		return store, nil
+	}
+	return storage.WithExecutionSink(store, executionSink, viper.GetBool("executions.keepInDatabase")), nil
+}
+
+type ExecutionSinkType string
+
+const (
+	DatabaseSink ExecutionSinkType = "database"
+	// TimescaleSink stores the executions in a hypertable of the TimescaleDB set in
+	// `executions.timescale.dataSource`
+	TimescaleSink ExecutionSinkType = "timescale"
+	// PrometheusSink sends the executions to `executions.prometheus.remoteWriteUrl` and queries
+	// them from `executions.prometheus.queryUrl`
+	PrometheusSink ExecutionSinkType = "prometheus"
+)
+
+func newExecutionSink() (storage.ExecutionSink, error) {
+	sinkType := viper.GetString("executions.sink")
+	switch ExecutionSinkType(sinkType) {
+	case "", DatabaseSink:
+		return nil, nil
+	case TimescaleSink:
+		dataSource := viper.GetString("executions.timescale.dataSource")
+		if dataSource == "" {
+			return nil, errors.New("executions.timescale.dataSource is required by the timescale sink")
+		}
+		dbClient, err := newDbStorage(PostgresqlDriver, dataSource)
+		if err != nil {
+			return nil, err
+		}
+		timescale, err := sink.NewTimescale(dbClient)
+		if err != nil {
+			return nil, err
+		}
+		return timescale, nil
+	case PrometheusSink:
+		remoteWriteUrl := viper.GetString("executions.prometheus.remoteWriteUrl")
+		queryUrl := viper.GetString("executions.prometheus.queryUrl")
+		if remoteWriteUrl == "" || queryUrl == "" {
+			return nil, errors.New("executions.prometheus.remoteWriteUrl and executions.prometheus.queryUrl are required by the prometheus sink")
+		}
+		return sink.NewPrometheus(remoteWriteUrl, queryUrl), nil
+	default:
+		return nil, errors.Errorf("Execution sink %s not supported", sinkType)
+	}
+}
+
 type DriverName string
 
 const (
 	PostgresqlDriver = "postgres"
 	MySQLDriver      = "mysql"
+	// SQLiteDriver stores the data in the file set as data source, meant for a single instance
+	SQLiteDriver = "sqlite"
 )
 
 func newDbStorage(driverName DriverName, dataSourceName string) (*gorm.DB, error) {
@@ -193,18 +332,22 @@ func newDbStorage(driverName DriverName, dataSourceName string) (*gorm.DB, error
 		if err != nil {
 			return nil, err
 		}
+	case SQLiteDriver:
+		dbClient, err = gorm.Open(db.SQLite(dataSourceName), gormConfig)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.Errorf("Driver %s not supported", string(driverName))
 	}
-	err = dbClient.AutoMigrate(&db.Check{})
+	err = dbClient.Use(tracing.GormPlugin{})
 	if err != nil {
 		return nil, err
 	}
-	err = dbClient.AutoMigrate(&db.CheckExecution{})
+	err = dbClient.Use(db.WorkspacePlugin{})
 	if err != nil {
 		return nil, err
 	}
-
 	return dbClient, nil
 }
 
@@ -214,6 +357,20 @@ const (
 	Database Provider = "sql"
 )
 
+// allowedOrigin returns the Access-Control-Allow-Origin of the origin, any origin is allowed
+// unless `cors.allowedOrigins` is set
+func allowedOrigin(allowedOrigins []string, origin string) string {
+	if len(allowedOrigins) == 0 {
+		return "*"
+	}
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
 var wsupgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
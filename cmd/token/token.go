@@ -0,0 +1,187 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NewTokenCmd manages the API tokens directly in the database, so that the first token
+// can be issued before the API accepts mutations
+func NewTokenCmd() *cobra.Command {
+	var config string
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "manage the API tokens",
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&config, "config", "", "statuspage", "Configuration file")
+	cmd.MarkPersistentFlagRequired("config")
+	openDatabase := func() (*gorm.DB, error) {
+		viper.SetConfigFile(config)
+		err := viper.ReadInConfig()
+		if err != nil {
+			return nil, err
+		}
+		dbClient, err := server.OpenDatabase()
+		if err != nil {
+			return nil, err
+		}
+		return dbClient.WithContext(db.WithActor(context.Background(), db.CommandActor("token"))), nil
+	}
+	cmd.AddCommand(
+		newTokenCreateCmd(openDatabase),
+		newTokenListCmd(openDatabase),
+		newTokenDeleteCmd(openDatabase),
+	)
+	return cmd
+}
+
+type tokenCreateCmd struct {
+	expires   time.Duration
+	role      string
+	teams     []string
+	workspace string
+}
+
+func (t *tokenCreateCmd) validate() error {
+	_, err := auth.ParseRole(t.role)
+	return err
+}
+
+func (t *tokenCreateCmd) run(out io.Writer, dbClient *gorm.DB, name string) error {
+	role, err := auth.ParseRole(t.role)
+	if err != nil {
+		return err
+	}
+	_, err = db.GetWorkspace(dbClient, t.workspace)
+	if err != nil {
+		return err
+	}
+	apiToken := &db.ApiToken{
+		Name:        name,
+		Role:        string(role),
+		WorkspaceID: t.workspace,
+	}
+	err = apiToken.SetTeams(t.teams)
+	if err != nil {
+		return err
+	}
+	if t.expires > 0 {
+		expiresAt := time.Now().Add(t.expires)
+		apiToken.ExpiresAt = &expiresAt
+	}
+	token, err := db.CreateApiToken(dbClient, apiToken)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, token)
+	return nil
+}
+
+func newTokenCreateCmd(openDatabase func() (*gorm.DB, error)) *cobra.Command {
+	c := &tokenCreateCmd{}
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "issue a token and print it, the token can't be retrieved afterwards",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			dbClient, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			return c.run(cmd.OutOrStdout(), dbClient, args[0])
+		},
+	}
+	flags := cmd.Flags()
+	flags.DurationVarP(&c.expires, "expires", "", 0, "Lifetime of the token, tokens don't expire by default")
+	flags.StringVarP(&c.role, "role", "", "viewer", "Role of the token: viewer, editor or admin")
+	flags.StringSliceVarP(&c.teams, "team", "", nil, "Teams whose checks are accessed by viewer and editor tokens")
+	flags.StringVarP(&c.workspace, "workspace", "", db.DefaultWorkspaceID, "Workspace accessed with the token")
+	return cmd
+}
+
+func newTokenListCmd(openDatabase func() (*gorm.DB, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the tokens",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbClient, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			apiTokens, err := db.GetApiTokens(dbClient)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tTOKEN\tWORKSPACE\tROLE\tTEAMS\tCREATED\tEXPIRES\tLAST USED")
+			for _, apiToken := range apiTokens {
+				teams, err := apiToken.GetTeams()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%s...\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					apiToken.Name,
+					apiToken.Hint,
+					apiToken.WorkspaceID,
+					strings.ToLower(apiToken.Role),
+					formatTeams(teams),
+					formatTime(&apiToken.CreatedAt),
+					formatTime(apiToken.ExpiresAt),
+					formatTime(apiToken.LastUsedAt),
+				)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newTokenDeleteCmd(openDatabase func() (*gorm.DB, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "revoke a token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbClient, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			err = db.DeleteApiToken(dbClient, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Token %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+func formatTeams(teams []string) string {
+	if len(teams) == 0 {
+		return "-"
+	}
+	return strings.Join(teams, ",")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,171 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"github.com/kfsoftware/statuspage/cmd/server"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"io"
+	"text/tabwriter"
+)
+
+// NewWorkspaceCmd manages the workspaces directly in the database, tokens are issued for a
+// workspace with `statuspage token create --workspace`
+func NewWorkspaceCmd() *cobra.Command {
+	var config string
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "manage the workspaces and their quotas",
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&config, "config", "", "statuspage", "Configuration file")
+	cmd.MarkPersistentFlagRequired("config")
+	openDatabase := func() (*gorm.DB, error) {
+		viper.SetConfigFile(config)
+		err := viper.ReadInConfig()
+		if err != nil {
+			return nil, err
+		}
+		dbClient, err := server.OpenDatabase()
+		if err != nil {
+			return nil, err
+		}
+		return dbClient.WithContext(db.WithActor(context.Background(), db.CommandActor("workspace"))), nil
+	}
+	cmd.AddCommand(
+		newWorkspaceSaveCmd(openDatabase, false),
+		newWorkspaceSaveCmd(openDatabase, true),
+		newWorkspaceListCmd(openDatabase),
+	)
+	return cmd
+}
+
+type workspaceSaveCmd struct {
+	update                  bool
+	name                    string
+	maxChecks               int
+	maxStatusPages          int
+	maxNotificationChannels int
+}
+
+func (w *workspaceSaveCmd) validate() error {
+	if w.maxChecks < 0 || w.maxStatusPages < 0 || w.maxNotificationChannels < 0 {
+		return errors.New("Quotas can't be negative")
+	}
+	return nil
+}
+
+func (w *workspaceSaveCmd) run(out io.Writer, cmd *cobra.Command, dbClient *gorm.DB, id string) error {
+	workspace := &db.Workspace{ID: id, Name: id}
+	if w.update {
+		var err error
+		workspace, err = db.GetWorkspace(dbClient, id)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := db.GetWorkspace(dbClient, id)
+		if err == nil {
+			return errors.Errorf("Workspace %s already exists", id)
+		}
+	}
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		workspace.Name = w.name
+	}
+	if flags.Changed("max-checks") {
+		workspace.MaxChecks = w.maxChecks
+	}
+	if flags.Changed("max-status-pages") {
+		workspace.MaxStatusPages = w.maxStatusPages
+	}
+	if flags.Changed("max-notification-channels") {
+		workspace.MaxNotificationChannels = w.maxNotificationChannels
+	}
+	err := db.SaveWorkspace(dbClient, workspace)
+	if err != nil {
+		return err
+	}
+	if w.update {
+		fmt.Fprintf(out, "Workspace %s updated\n", id)
+	} else {
+		fmt.Fprintf(out, "Workspace %s created\n", id)
+	}
+	return nil
+}
+
+func newWorkspaceSaveCmd(openDatabase func() (*gorm.DB, error), update bool) *cobra.Command {
+	c := &workspaceSaveCmd{update: update}
+	cmd := &cobra.Command{
+		Use:   "create <id>",
+		Short: "create a workspace",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			dbClient, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			return c.run(cmd.OutOrStdout(), cmd, dbClient, args[0])
+		},
+	}
+	if update {
+		cmd.Use = "update <id>"
+		cmd.Short = "rename a workspace or change its quotas"
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&c.name, "name", "", "", "Name of the workspace, the id by default")
+	flags.IntVarP(&c.maxChecks, "max-checks", "", 0, "Maximum number of checks, 0 is unlimited")
+	flags.IntVarP(&c.maxStatusPages, "max-status-pages", "", 0, "Maximum number of status pages, 0 is unlimited")
+	flags.IntVarP(&c.maxNotificationChannels, "max-notification-channels", "", 0, "Maximum number of notification channels, 0 is unlimited")
+	return cmd
+}
+
+func newWorkspaceListCmd(openDatabase func() (*gorm.DB, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the workspaces along with their usage and quotas",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbClient, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			workspaces, err := db.GetWorkspaces(dbClient)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tCHECKS\tSTATUS PAGES\tNOTIFICATION CHANNELS")
+			for _, workspace := range workspaces {
+				usage, err := db.GetWorkspaceUsage(dbClient, workspace.ID)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\t%s\n",
+					workspace.ID,
+					workspace.Name,
+					formatUsage(usage.Checks, workspace.MaxChecks),
+					formatUsage(usage.StatusPages, workspace.MaxStatusPages),
+					formatUsage(usage.NotificationChannels, workspace.MaxNotificationChannels),
+				)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func formatUsage(used int64, quota int) string {
+	if quota <= 0 {
+		return fmt.Sprintf("%d", used)
+	}
+	return fmt.Sprintf("%d/%d", used, quota)
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type Method string
+
+const (
+	// TokenMethod authenticates with an API token issued by the server
+	TokenMethod Method = "token"
+	// JWTMethod authenticates with a JWT signed by the OIDC issuer
+	JWTMethod Method = "jwt"
+)
+
+// Identity of the caller of the API
+type Identity struct {
+	Subject string
+	Name    string
+	Method  Method
+	Role    Role
+	// Workspace whose resources are accessed
+	Workspace string
+	// Teams owning the checks accessed by viewers and editors
+	Teams []string
+	// Claims of the JWT, empty for API tokens
+	Claims map[string]interface{}
+}
+
+// ErrUnauthenticated is returned when the credentials are not accepted by any authenticator
+var ErrUnauthenticated = errors.New("Invalid credentials")
+
+// Authenticator validates a bearer token, it returns a nil identity when the token is
+// not of its kind so that the next authenticator is tried
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+// Authenticators tries every authenticator in order
+type Authenticators []Authenticator
+
+// Authenticate validates the Authorization header, a nil identity is returned without header
+func (a Authenticators) Authenticate(ctx context.Context, authorization string) (*Identity, error) {
+	if authorization == "" {
+		return nil, nil
+	}
+	scheme, token, ok := cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return nil, errors.New("Invalid Authorization header, expected a bearer token")
+	}
+	for _, authenticator := range a {
+		identity, err := authenticator.Authenticate(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if identity != nil {
+			return identity, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
+
+func cut(s string, sep string) (string, string, bool) {
+	i := strings.Index(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], strings.TrimSpace(s[i+len(sep):]), true
+}
+
+// NewAuthenticators returns the authenticators enabled in the `auth` configuration, API tokens
+// are always accepted
+func NewAuthenticators(ctx context.Context, db *gorm.DB) (Authenticators, error) {
+	authenticators := Authenticators{TokenAuthenticator{Db: db}}
+	issuer := viper.GetString("auth.oidc.issuer")
+	if issuer != "" {
+		jwtAuthenticator, err := NewJWTAuthenticator(ctx, JWTConfig{
+			Issuer:         issuer,
+			JWKSUrl:        viper.GetString("auth.oidc.jwksUrl"),
+			Audience:       viper.GetString("auth.oidc.audience"),
+			UsernameClaim:  viper.GetString("auth.oidc.usernameClaim"),
+			RoleClaim:      viper.GetString("auth.oidc.roleClaim"),
+			TeamsClaim:     viper.GetString("auth.oidc.teamsClaim"),
+			WorkspaceClaim: viper.GetString("auth.oidc.workspaceClaim"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+	return authenticators, nil
+}
+
+// GetWorkspace returns the workspace of the identity, anonymous callers access the default workspace
+func (i *Identity) GetWorkspace() string {
+	if i == nil || i.Workspace == "" {
+		return db.DefaultWorkspaceID
+	}
+	return i.Workspace
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller of the operation, nil for anonymous callers
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
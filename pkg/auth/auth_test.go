@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// issuer is a local OIDC issuer serving its discovery document and key set
+type issuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newIssuer(t *testing.T) *issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &issuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                i.server.URL,
+			"jwks_uri":                              i.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key:       &key.PublicKey,
+			KeyID:     "test",
+			Algorithm: "RS256",
+			Use:       "sig",
+		}}})
+	})
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+	return i
+}
+
+func (i *issuer) sign(t *testing.T, key *rsa.PrivateKey, claims interface{}) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (i *issuer) claims(subject string, audience string, expiry time.Time) jwt.Claims {
+	return jwt.Claims{
+		Issuer:   i.server.URL,
+		Subject:  subject,
+		Audience: jwt.Audience{audience},
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(expiry),
+	}
+}
+
+func newJWTAuthenticators(t *testing.T, i *issuer, jwksUrl string) Authenticators {
+	authenticator, err := NewJWTAuthenticator(context.Background(), JWTConfig{
+		Issuer:        i.server.URL,
+		JWKSUrl:       jwksUrl,
+		Audience:      "statuspage",
+		UsernameClaim: "email",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Authenticators{authenticator}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	i := newIssuer(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := struct {
+		jwt.Claims
+		Email string `json:"email"`
+	}{i.claims("alice", "statuspage", time.Now().Add(time.Hour)), "alice@example.com"}
+	tests := []struct {
+		name    string
+		token   string
+		subject string
+	}{
+		{"valid", i.sign(t, i.key, valid), "alice"},
+		{"expired", i.sign(t, i.key, i.claims("alice", "statuspage", time.Now().Add(-time.Minute))), ""},
+		{"wrong audience", i.sign(t, i.key, i.claims("alice", "other", time.Now().Add(time.Hour))), ""},
+		{"unknown key", i.sign(t, otherKey, i.claims("alice", "statuspage", time.Now().Add(time.Hour))), ""},
+		{"not a jwt", "sp_0123456789", ""},
+	}
+	// with discovery and with the key set configured
+	for _, jwksUrl := range []string{"", i.server.URL + "/keys"} {
+		authenticators := newJWTAuthenticators(t, i, jwksUrl)
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				identity, err := authenticators.Authenticate(context.Background(), "Bearer "+tt.token)
+				if tt.subject == "" {
+					if err == nil {
+						t.Fatalf("expected the token to be rejected, got %+v", identity)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if identity.Subject != tt.subject || identity.Method != JWTMethod {
+					t.Errorf("unexpected identity %+v", identity)
+				}
+				if identity.Name != "alice@example.com" {
+					t.Errorf("expected the name from the email claim, got %s", identity.Name)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthenticateHeader(t *testing.T) {
+	authenticators := Authenticators{}
+	identity, err := authenticators.Authenticate(context.Background(), "")
+	if err != nil || identity != nil {
+		t.Errorf("expected an anonymous caller without header, got %+v %v", identity, err)
+	}
+	_, err = authenticators.Authenticate(context.Background(), "Basic dXNlcjpwYXNz")
+	if err == nil {
+		t.Error("expected basic credentials to be rejected")
+	}
+	_, err = authenticators.Authenticate(context.Background(), "Bearer sp_unknown")
+	if err != ErrUnauthenticated {
+		t.Errorf("expected %v, got %v", ErrUnauthenticated, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	i := newIssuer(t)
+	r := gin.New()
+	r.GET("/", Middleware(newJWTAuthenticators(t, i, "")), func(c *gin.Context) {
+		identity := IdentityFromContext(c.Request.Context())
+		if identity == nil {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		c.String(http.StatusOK, identity.Subject)
+	})
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{"anonymous", "", http.StatusOK, "anonymous"},
+		{"valid", "Bearer " + i.sign(t, i.key, i.claims("bob", "statuspage", time.Now().Add(time.Hour))), http.StatusOK, "bob"},
+		{"invalid", "Bearer sp_unknown", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	authenticated := WithIdentity(context.Background(), &Identity{Subject: "alice", Method: TokenMethod})
+	tests := []struct {
+		name      string
+		guard     Guard
+		ctx       context.Context
+		operation ast.Operation
+		allowed   bool
+	}{
+		{"anonymous query", Guard{Anonymous: true}, context.Background(), ast.Query, true},
+		{"anonymous subscription", Guard{Anonymous: true}, context.Background(), ast.Subscription, true},
+		{"anonymous mutation", Guard{Anonymous: true}, context.Background(), ast.Mutation, false},
+		{"anonymous disabled", Guard{}, context.Background(), ast.Query, false},
+		{"authenticated mutation", Guard{}, authenticated, ast.Mutation, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &graphql.OperationContext{
+				Operation: &ast.OperationDefinition{Operation: tt.operation},
+			}
+			err := tt.guard.MutateOperationContext(tt.ctx, rc)
+			if tt.allowed && err != nil {
+				t.Errorf("expected the operation to be allowed, got %v", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("expected the operation to be rejected")
+			}
+		})
+	}
+}
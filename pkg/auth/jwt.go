@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+type JWTConfig struct {
+	Issuer string
+	// JWKSUrl is discovered from the issuer when empty
+	JWKSUrl  string
+	Audience string
+	// UsernameClaim is the claim used as the name of the identity, the subject by default
+	UsernameClaim string
+	// RoleClaim holds a role or a list of roles, the highest one is granted, `role` by default
+	RoleClaim string
+	// TeamsClaim holds the teams of the identity, `groups` by default
+	TeamsClaim string
+	// WorkspaceClaim holds the workspace of the identity, `workspace` by default, the default
+	// workspace is accessed without the claim
+	WorkspaceClaim string
+}
+
+// JWTAuthenticator accepts the JWTs signed by the keys of the issuer
+type JWTAuthenticator struct {
+	verifier       *oidc.IDTokenVerifier
+	usernameClaim  string
+	roleClaim      string
+	teamsClaim     string
+	workspaceClaim string
+}
+
+func NewJWTAuthenticator(ctx context.Context, config JWTConfig) (*JWTAuthenticator, error) {
+	oidcConfig := &oidc.Config{
+		ClientID:          config.Audience,
+		SkipClientIDCheck: config.Audience == "",
+	}
+	var verifier *oidc.IDTokenVerifier
+	if config.JWKSUrl != "" {
+		// the key set outlives the context of the setup
+		keySet := oidc.NewRemoteKeySet(context.Background(), config.JWKSUrl)
+		verifier = oidc.NewVerifier(config.Issuer, keySet, oidcConfig)
+	} else {
+		provider, err := oidc.NewProvider(ctx, config.Issuer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to discover the OIDC issuer %s", config.Issuer)
+		}
+		verifier = provider.Verifier(oidcConfig)
+	}
+	authenticator := &JWTAuthenticator{
+		verifier:       verifier,
+		usernameClaim:  config.UsernameClaim,
+		roleClaim:      config.RoleClaim,
+		teamsClaim:     config.TeamsClaim,
+		workspaceClaim: config.WorkspaceClaim,
+	}
+	if authenticator.roleClaim == "" {
+		authenticator.roleClaim = "role"
+	}
+	if authenticator.teamsClaim == "" {
+		authenticator.teamsClaim = "groups"
+	}
+	if authenticator.workspaceClaim == "" {
+		authenticator.workspaceClaim = "workspace"
+	}
+	return authenticator, nil
+}
+
+func (j *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if strings.Count(token, ".") != 2 {
+		return nil, nil
+	}
+	idToken, err := j.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid token")
+	}
+	claims := map[string]interface{}{}
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid token")
+	}
+	identity := &Identity{
+		Subject:   idToken.Subject,
+		Name:      idToken.Subject,
+		Method:    JWTMethod,
+		Role:      ViewerRole,
+		Workspace: db.DefaultWorkspaceID,
+		Teams:     claimValues(claims[j.teamsClaim]),
+		Claims:    claims,
+	}
+	if workspace, ok := claims[j.workspaceClaim].(string); ok && workspace != "" {
+		identity.Workspace = workspace
+	}
+	for _, value := range claimValues(claims[j.roleClaim]) {
+		role, err := ParseRole(value)
+		if err == nil && role.Includes(identity.Role) {
+			identity.Role = role
+		}
+	}
+	if j.usernameClaim != "" {
+		if username, ok := claims[j.usernameClaim]; ok {
+			identity.Name = fmt.Sprint(username)
+		}
+	}
+	return identity, nil
+}
+
+// claimValues returns the values of a claim holding either a string or a list of strings
+func claimValues(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
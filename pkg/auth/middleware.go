@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"net/http"
+)
+
+// Middleware authenticates the Authorization header, requests with invalid credentials are
+// rejected while requests without credentials continue anonymously
+func Middleware(authenticators Authenticators) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := authenticators.Authenticate(c.Request.Context(), c.GetHeader("Authorization"))
+		if err != nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"errors": []gin.H{{"message": err.Error()}},
+			})
+			return
+		}
+		if identity != nil {
+			c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), identity))
+		}
+		c.Next()
+	}
+}
+
+// WebsocketInit authenticates the Authorization of the init payload of the websocket
+// connections, browsers can't set headers when opening them
+func WebsocketInit(authenticators Authenticators) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, error) {
+		identity, err := authenticators.Authenticate(ctx, initPayload.Authorization())
+		if err != nil {
+			return nil, err
+		}
+		if identity != nil {
+			ctx = WithIdentity(ctx, identity)
+		}
+		return ctx, nil
+	}
+}
+
+// Guard is a gqlgen extension rejecting the operations of anonymous callers, queries and
+// subscriptions are allowed when Anonymous is set but only the fields without @hasRole, the
+// status pages, resolve without credentials
+type Guard struct {
+	Anonymous bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = Guard{}
+
+func (Guard) ExtensionName() string {
+	return "Authentication"
+}
+
+func (Guard) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (g Guard) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if IdentityFromContext(ctx) != nil {
+		return nil
+	}
+	if g.Anonymous && rc.Operation != nil && rc.Operation.Operation != ast.Mutation {
+		return nil
+	}
+	err := gqlerror.Errorf("Authentication required")
+	err.Extensions = map[string]interface{}{"code": "UNAUTHENTICATED"}
+	return err
+}
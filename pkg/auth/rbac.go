@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Role grants the permissions of the roles below it
+type Role string
+
+const (
+	// ViewerRole reads the checks of its teams
+	ViewerRole Role = "VIEWER"
+	// EditorRole manages the checks of its teams, incidents and maintenance windows
+	EditorRole Role = "EDITOR"
+	// AdminRole manages every check, status pages and notification channels
+	AdminRole Role = "ADMIN"
+)
+
+var roleLevels = map[Role]int{
+	ViewerRole: 1,
+	EditorRole: 2,
+	AdminRole:  3,
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToUpper(s))
+	if _, ok := roleLevels[role]; !ok {
+		return "", errors.Errorf("Invalid role %s, expected viewer, editor or admin", s)
+	}
+	return role, nil
+}
+
+// Includes returns true when the role has the permissions of the required role
+func (r Role) Includes(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// GetRole returns the role of the identity, anonymous callers have no role so that they only
+// read the fields without @hasRole, the status pages
+func (i *Identity) GetRole() Role {
+	if i == nil {
+		return ""
+	}
+	return i.Role
+}
+
+// Scoped returns true when the identity only accesses the checks of its teams, only admins
+// access every check. Anonymous callers are scoped to no team so they don't see any check
+func (i *Identity) Scoped() bool {
+	return i == nil || i.Role != AdminRole
+}
+
+// CanAccess returns true when the identity can access the checks of the owner
+func (i *Identity) CanAccess(owner string) bool {
+	if !i.Scoped() {
+		return true
+	}
+	if i == nil {
+		return false
+	}
+	for _, team := range i.Teams {
+		if team == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole returns an error unless the identity has the permissions of the role
+func (i *Identity) HasRole(role Role) error {
+	if i == nil {
+		return errors.New("Authentication required")
+	}
+	if !i.GetRole().Includes(role) {
+		return errors.Errorf("Forbidden, %s role required", strings.ToLower(string(role)))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIdentityAccess(t *testing.T) {
+	editor := &Identity{Role: EditorRole, Teams: []string{"payments"}}
+	admin := &Identity{Role: AdminRole}
+	var anonymous *Identity
+	tests := []struct {
+		name     string
+		identity *Identity
+		role     Role
+		owner    string
+		hasRole  bool
+		canRead  bool
+	}{
+		{"editor edits", editor, EditorRole, "payments", true, true},
+		{"editor of other team", editor, EditorRole, "search", true, false},
+		{"editor administers", editor, AdminRole, "payments", false, true},
+		{"admin", admin, AdminRole, "search", true, true},
+		{"anonymous reads", anonymous, ViewerRole, "search", false, false},
+		{"anonymous edits", anonymous, EditorRole, "search", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.identity.HasRole(tt.role)
+			if tt.hasRole != (err == nil) {
+				t.Errorf("HasRole(%s) = %v", tt.role, err)
+			}
+			if canRead := tt.identity.CanAccess(tt.owner); canRead != tt.canRead {
+				t.Errorf("CanAccess(%s) = %v", tt.owner, canRead)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole("editor")
+	if err != nil || role != EditorRole {
+		t.Errorf("expected %s, got %s %v", EditorRole, role, err)
+	}
+	_, err = ParseRole("owner")
+	if err == nil {
+		t.Error("expected an invalid role")
+	}
+}
+
+func TestJWTRoleAndTeams(t *testing.T) {
+	i := newIssuer(t)
+	authenticators := newJWTAuthenticators(t, i, "")
+	claims := struct {
+		jwt.Claims
+		Role   []string `json:"role"`
+		Groups []string `json:"groups"`
+	}{
+		i.claims("carol", "statuspage", time.Now().Add(time.Hour)),
+		[]string{"viewer", "editor", "unknown"},
+		[]string{"payments", "search"},
+	}
+	identity, err := authenticators.Authenticate(context.Background(), "Bearer "+i.sign(t, i.key, claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Role != EditorRole {
+		t.Errorf("expected the highest role %s, got %s", EditorRole, identity.Role)
+	}
+	if !reflect.DeepEqual(identity.Teams, []string{"payments", "search"}) {
+		t.Errorf("unexpected teams %v", identity.Teams)
+	}
+	withoutRole, err := authenticators.Authenticate(context.Background(), "Bearer "+i.sign(t, i.key, i.claims("dave", "statuspage", time.Now().Add(time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withoutRole.Role != ViewerRole || !withoutRole.Scoped() {
+		t.Errorf("expected a scoped viewer, got %+v", withoutRole)
+	}
+	if withoutRole.GetWorkspace() != db.DefaultWorkspaceID {
+		t.Errorf("expected the default workspace, got %s", withoutRole.GetWorkspace())
+	}
+}
+
+func TestJWTWorkspace(t *testing.T) {
+	i := newIssuer(t)
+	authenticators := newJWTAuthenticators(t, i, "")
+	claims := struct {
+		jwt.Claims
+		Workspace string `json:"workspace"`
+	}{
+		i.claims("erin", "statuspage", time.Now().Add(time.Hour)),
+		"acme",
+	}
+	identity, err := authenticators.Authenticate(context.Background(), "Bearer "+i.sign(t, i.key, claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.GetWorkspace() != "acme" {
+		t.Errorf("expected the workspace acme, got %s", identity.GetWorkspace())
+	}
+	var anonymous *Identity
+	if anonymous.GetWorkspace() != db.DefaultWorkspaceID {
+		t.Errorf("expected anonymous callers in the default workspace, got %s", anonymous.GetWorkspace())
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"gorm.io/gorm"
+)
+
+// TokenAuthenticator accepts the API tokens stored in the database
+type TokenAuthenticator struct {
+	Db *gorm.DB
+}
+
+func (t TokenAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	apiToken, err := db.FindApiToken(t.Db.WithContext(ctx), token)
+	if err != nil {
+		return nil, err
+	}
+	if apiToken == nil {
+		return nil, nil
+	}
+	role, err := ParseRole(apiToken.Role)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := apiToken.GetTeams()
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Subject:   apiToken.ID,
+		Name:      apiToken.Name,
+		Method:    TokenMethod,
+		Role:      role,
+		Workspace: apiToken.WorkspaceID,
+		Teams:     teams,
+	}, nil
+}
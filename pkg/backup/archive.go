@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"io"
+	"time"
+)
+
+// FormatVersion is the version of the archives written by Export, archives of newer versions
+// are refused by Import
+const FormatVersion = 1
+
+// Kind of the records of an archive, every line of the archive holds a single record
+type Kind string
+
+const (
+	HeaderKind              Kind = "header"
+	CheckKind               Kind = "check"
+	NotificationChannelKind Kind = "notificationChannel"
+	StatusPageKind          Kind = "statusPage"
+	MaintenanceWindowKind   Kind = "maintenanceWindow"
+	IncidentKind            Kind = "incident"
+	ExecutionKind           Kind = "execution"
+)
+
+// Kinds lists the kinds in the order of the archive, records only reference the records before them
+var Kinds = []Kind{
+	CheckKind,
+	NotificationChannelKind,
+	StatusPageKind,
+	MaintenanceWindowKind,
+	IncidentKind,
+	ExecutionKind,
+}
+
+type line struct {
+	Kind   Kind            `json:"kind"`
+	Record json.RawMessage `json:"record"`
+}
+
+// Header is the first record of the archive
+type Header struct {
+	Version    int       `json:"version"`
+	Workspace  string    `json:"workspace"`
+	ExportedAt time.Time `json:"exportedAt"`
+	// Executions is set when the archive holds the execution history
+	Executions bool `json:"executions"`
+}
+
+// Check is the configuration of a check, the results of the probes are not exported
+type Check struct {
+	ID          string          `json:"id"`
+	Identifier  string          `json:"identifier"`
+	Type        check.Type      `json:"type"`
+	Data        json.RawMessage `json:"data"`
+	Frequency   string          `json:"frequency"`
+	Description string          `json:"description,omitempty"`
+	Owner       string          `json:"owner,omitempty"`
+	Labels      db.Labels       `json:"labels,omitempty"`
+	Paused      bool            `json:"paused,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+type NotificationChannel struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Type      db.ChannelType `json:"type"`
+	Url       string         `json:"url"`
+	Labels    db.Labels      `json:"labels,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+type StatusPage struct {
+	ID           string                `json:"id"`
+	Slug         string                `json:"slug"`
+	Title        string                `json:"title"`
+	LogoURL      string                `json:"logoUrl,omitempty"`
+	Domain       string                `json:"domain,omitempty"`
+	GroupByLabel string                `json:"groupByLabel,omitempty"`
+	Components   []StatusPageComponent `json:"components,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+}
+
+type StatusPageComponent struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	GroupName string    `json:"groupName,omitempty"`
+	Position  int       `json:"position"`
+	CheckID   string    `json:"checkId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MaintenanceWindow lists the checks added explicitly, the ones matching its labels are not
+type MaintenanceWindow struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Mode        db.MaintenanceMode `json:"mode"`
+	StartsAt    *time.Time         `json:"startsAt,omitempty"`
+	EndsAt      *time.Time         `json:"endsAt,omitempty"`
+	Schedule    string             `json:"schedule,omitempty"`
+	Duration    string             `json:"duration,omitempty"`
+	Timezone    string             `json:"timezone,omitempty"`
+	Labels      db.Labels          `json:"labels,omitempty"`
+	CheckIDs    []string           `json:"checkIds,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt"`
+}
+
+type Incident struct {
+	ID           string            `json:"id"`
+	Title        string            `json:"title"`
+	Status       db.IncidentStatus `json:"status"`
+	Impact       db.Impact         `json:"impact"`
+	ResolvedAt   *time.Time        `json:"resolvedAt,omitempty"`
+	ComponentIDs []string          `json:"componentIds,omitempty"`
+	Updates      []IncidentUpdate  `json:"updates"`
+	CreatedAt    time.Time         `json:"createdAt"`
+}
+
+type IncidentUpdate struct {
+	Status    db.IncidentStatus `json:"status"`
+	Body      string            `json:"body"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+type Execution struct {
+	CheckID     string          `json:"checkId"`
+	Status      db.Status       `json:"status"`
+	ErrorMsg    string          `json:"errorMsg,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	Latency     time.Duration   `json:"latency"`
+	Maintenance bool            `json:"maintenance,omitempty"`
+	Stats       json.RawMessage `json:"stats,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// writer writes the records of an archive as JSON lines
+type writer struct {
+	encoder *json.Encoder
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{encoder: json.NewEncoder(w)}
+}
+
+func (w *writer) write(kind Kind, record interface{}) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return w.encoder.Encode(line{Kind: kind, Record: recordBytes})
+}
+
+// reader reads the records of an archive, the header is read when the reader is created
+type reader struct {
+	scanner *bufio.Scanner
+	header  Header
+	lineNo  int
+}
+
+func newReader(r io.Reader) (*reader, error) {
+	scanner := bufio.NewScanner(r)
+	// the data of the checks and the stats of the executions may exceed the default line size
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	ar := &reader{scanner: scanner}
+	kind, record, err := ar.next()
+	if err == io.EOF {
+		return nil, errors.New("Empty archive")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kind != HeaderKind {
+		return nil, errors.New("Invalid archive, the header is missing")
+	}
+	err = json.Unmarshal(record, &ar.header)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid archive header")
+	}
+	if ar.header.Version < 1 || ar.header.Version > FormatVersion {
+		return nil, errors.Errorf("Archive version %d not supported, the latest version is %d", ar.header.Version, FormatVersion)
+	}
+	return ar, nil
+}
+
+// next returns the next record, io.EOF at the end of the archive
+func (r *reader) next() (Kind, json.RawMessage, error) {
+	for r.scanner.Scan() {
+		r.lineNo++
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+		l := line{}
+		err := json.Unmarshal(r.scanner.Bytes(), &l)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "Invalid record at line %d", r.lineNo)
+		}
+		return l.Kind, l.Record, nil
+	}
+	err := r.scanner.Err()
+	if err != nil {
+		return "", nil, err
+	}
+	return "", nil, io.EOF
+}
@@ -0,0 +1,281 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/internal/dbtest"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openSQLiteStorage migrates a new SQLite database, the archives are written by the in-memory
+// storage and imported in it to verify they don't depend on the database
+func openSQLiteStorage(t *testing.T) storage.Storage {
+	t.Helper()
+	return storage.NewGormStorage(openSQLiteDatabase(t))
+}
+
+func openSQLiteDatabase(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, db.SQLite, db.Migrate, db.WorkspacePlugin{})
+}
+
+// exportSample exports a workspace holding a resource of every kind
+func exportSample(t *testing.T) []byte {
+	t.Helper()
+	store := storage.NewMemoryStorage()
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	createdAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	var checks []*db.Check
+	for _, identifier := range []string{"api", "web"} {
+		id := uuid.New().String()
+		chk := &db.Check{
+			ID:         id,
+			Identifier: identifier,
+			Type:       "http",
+			Data:       []byte(`{"url":"https://` + identifier + `.example.com"}`),
+			Frequency:  "@every 1m",
+			Status:     db.Up,
+			Labels:     db.NewCheckLabels(id, db.Labels{"team": identifier}),
+			CreatedAt:  createdAt,
+		}
+		err := store.Checks().Create(ctx, chk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checks = append(checks, chk)
+	}
+	channel := &db.NotificationChannel{Name: "ops", Type: db.WebhookChannel, Url: "https://hooks.example.com"}
+	err := channel.SetLabels(db.Labels{"team": "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Channels().Create(ctx, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statusPage := &db.StatusPage{Slug: "public", Title: "Public"}
+	err = store.StatusPages().Create(ctx, statusPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	component := &db.StatusPageComponent{StatusPageID: statusPage.ID, Name: "API", CheckID: checks[0].ID}
+	err = store.StatusPages().AddComponent(ctx, component)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startsAt := createdAt.Add(time.Hour)
+	endsAt := startsAt.Add(time.Hour)
+	window := &db.MaintenanceWindow{
+		Title:     "Upgrade",
+		Mode:      db.PauseMode,
+		StartsAt:  &startsAt,
+		EndsAt:    &endsAt,
+		Checks:    []db.Check{{ID: checks[1].ID}},
+		CreatedAt: createdAt,
+	}
+	err = window.SetLabels(db.Labels{"team": "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.MaintenanceWindows().Create(ctx, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	incident := &db.Incident{
+		Title:     "API outage",
+		Status:    db.Investigating,
+		Impact:    db.MajorImpact,
+		CreatedAt: createdAt,
+		Updates: []db.IncidentUpdate{
+			{Status: db.Investigating, Body: "Looking into it", CreatedAt: createdAt},
+		},
+	}
+	err = store.Incidents().Create(ctx, incident, []string{component.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		err := store.Executions().Create(ctx, &db.CheckExecution{
+			CheckID:   checks[0].ID,
+			Status:    db.Up,
+			Latency:   time.Duration(i+1) * time.Millisecond,
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &bytes.Buffer{}
+	counts, err := Export(ctx, store, out, ExportOptions{Executions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[CheckKind] != 2 || counts[ExecutionKind] != 3 || counts[IncidentKind] != 1 {
+		t.Fatalf("Export() counts = %v, want 2 checks, 1 incident and 3 executions", counts)
+	}
+	return out.Bytes()
+}
+
+func TestExportImport(t *testing.T) {
+	archive := exportSample(t)
+	store := openSQLiteStorage(t)
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	summary, err := Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: FailOnConflict, Executions: true})
+	if err != nil {
+		t.Fatalf("Import() = %v", err)
+	}
+	for _, kind := range []Kind{CheckKind, NotificationChannelKind, StatusPageKind, MaintenanceWindowKind, IncidentKind, ExecutionKind} {
+		if summary[kind].Created == 0 || summary[kind].Skipped != 0 {
+			t.Errorf("Import() %s = %+v, want every record created", kind, summary[kind])
+		}
+	}
+
+	api, err := store.Checks().GetByIdentifier(ctx, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.GetLabels()["team"] != "api" || api.Status != db.Scheduled || !strings.Contains(string(api.Data), "api.example.com") {
+		t.Errorf("imported check = %+v, want its labels and data", api)
+	}
+	statusPages, err := store.StatusPages().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statusPages) != 1 || len(statusPages[0].Components) != 1 || statusPages[0].Components[0].CheckID != api.ID {
+		t.Fatalf("imported status pages = %+v, want a component of check %s", statusPages, api.ID)
+	}
+	windows, err := store.MaintenanceWindows().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 1 || len(windows[0].Checks) != 2 {
+		t.Errorf("imported maintenance windows = %+v, want web listed and api matching the labels", windows)
+	}
+	incidents, err := store.Incidents().List(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incidents) != 1 || len(incidents[0].Updates) != 1 || len(incidents[0].Components) != 1 || incidents[0].Components[0].ID != statusPages[0].Components[0].ID {
+		t.Errorf("imported incidents = %+v, want the update and the imported component", incidents)
+	}
+	page, err := store.Executions().List(ctx, db.ExecutionFilter{CheckID: api.ID}, db.Order{}, db.Pagination{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 3 {
+		t.Errorf("imported executions = %d, want 3", page.TotalCount)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	archive := exportSample(t)
+	store := openSQLiteStorage(t)
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	_, err := Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: FailOnConflict, Executions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: FailOnConflict, Executions: true})
+	if err == nil || !strings.Contains(err.Error(), "check api") {
+		t.Errorf("Import() of existing resources with fail = %v, want the conflicts", err)
+	}
+
+	summary, err := Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: SkipConflicts, Executions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary[CheckKind].Skipped != 2 || summary[IncidentKind].Skipped != 1 || summary[ExecutionKind].Skipped != 3 || summary[ExecutionKind].Created != 0 {
+		t.Errorf("Import() with skip = %+v, want every record skipped", summary)
+	}
+
+	api, err := store.Checks().GetByIdentifier(ctx, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Description = "changed"
+	err = store.Checks().Save(ctx, db.UpdateOperation, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err = Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: OverwriteConflicts, Executions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary[CheckKind].Updated != 2 || summary[StatusPageKind].Updated != 1 || summary[IncidentKind].Skipped != 1 {
+		t.Errorf("Import() with overwrite = %+v, want the resources updated and the incidents kept", summary)
+	}
+	api, err = store.Checks().GetByIdentifier(ctx, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Description != "" {
+		t.Errorf("Description = %q after overwriting, want the one of the archive", api.Description)
+	}
+	incidents, err := store.Incidents().List(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incidents) != 1 {
+		t.Errorf("%d incidents after importing the archive 3 times, want 1", len(incidents))
+	}
+}
+
+func TestImportIsAtomic(t *testing.T) {
+	archive := exportSample(t)
+	dbClient := openSQLiteDatabase(t)
+	store := storage.NewGormStorage(dbClient)
+	_, err := Import(db.WithWorkspace(context.Background(), db.DefaultWorkspaceID), store, bytes.NewReader(archive), ImportOptions{OnConflict: FailOnConflict})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the slugs are unique within a workspace only
+	err = db.SaveWorkspace(dbClient, &db.Workspace{ID: "acme", Name: "Acme", MaxStatusPages: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := db.WithWorkspace(context.Background(), "acme")
+	err = store.StatusPages().Create(ctx, &db.StatusPage{Slug: "acme", Title: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the status page of the archive exceeds the quota after the checks are imported
+	_, err = Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: FailOnConflict})
+	if err == nil || !strings.Contains(err.Error(), "Quota exceeded") {
+		t.Fatalf("Import() over the quota = %v, want the quota error", err)
+	}
+	page, err := store.Checks().List(ctx, db.CheckFilter{}, db.Order{}, db.Pagination{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels, err := store.Channels().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 0 || len(channels) != 0 {
+		t.Errorf("%d checks and %d channels left after a failed import, want none", page.TotalCount, len(channels))
+	}
+
+	err = db.SaveWorkspace(dbClient, &db.Workspace{ID: "acme", Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Import(ctx, store, bytes.NewReader(archive), ImportOptions{OnConflict: FailOnConflict})
+	if err != nil {
+		t.Errorf("Import() of the slug of another workspace = %v", err)
+	}
+}
+
+func TestImportRefusesNewerArchive(t *testing.T) {
+	archive := `{"kind":"header","record":{"version":1000}}` + "\n"
+	_, err := Import(context.Background(), storage.NewMemoryStorage(), strings.NewReader(archive), ImportOptions{OnConflict: FailOnConflict})
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Import() of a newer archive = %v, want an unsupported version error", err)
+	}
+}
@@ -0,0 +1,249 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"gorm.io/datatypes"
+	"io"
+	"time"
+)
+
+// exportPageSize is the number of checks or executions read at once
+const exportPageSize = 500
+
+type ExportOptions struct {
+	// Executions includes the execution history, the rollups of the compacted executions are
+	// not exported
+	Executions bool
+}
+
+// Export writes the configuration of the workspace of the context to the archive and returns the
+// number of records of every kind, deleted resources are not exported
+func Export(ctx context.Context, store storage.Storage, out io.Writer, options ExportOptions) (map[Kind]int, error) {
+	counts := map[Kind]int{}
+	workspace, _ := db.WorkspaceFromContext(ctx)
+	w := newWriter(out)
+	err := w.write(HeaderKind, Header{
+		Version:    FormatVersion,
+		Workspace:  workspace,
+		ExportedAt: time.Now().UTC(),
+		Executions: options.Executions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	checks, err := listChecks(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	for _, chk := range checks {
+		err := w.write(CheckKind, Check{
+			ID:          chk.ID,
+			Identifier:  chk.Identifier,
+			Type:        chk.Type,
+			Data:        rawJSON(chk.Data),
+			Frequency:   chk.Frequency,
+			Description: chk.Description,
+			Owner:       chk.Owner,
+			Labels:      chk.GetLabels(),
+			Paused:      chk.Paused,
+			CreatedAt:   chk.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		counts[CheckKind]++
+	}
+	channels, err := store.Channels().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		labels, err := channel.GetLabels()
+		if err != nil {
+			return nil, err
+		}
+		err = w.write(NotificationChannelKind, NotificationChannel{
+			ID:        channel.ID,
+			Name:      channel.Name,
+			Type:      channel.Type,
+			Url:       channel.Url,
+			Labels:    labels,
+			CreatedAt: channel.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		counts[NotificationChannelKind]++
+	}
+	statusPages, err := store.StatusPages().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, statusPage := range statusPages {
+		record := StatusPage{
+			ID:           statusPage.ID,
+			Slug:         statusPage.Slug,
+			Title:        statusPage.Title,
+			LogoURL:      statusPage.LogoURL,
+			Domain:       statusPage.Domain,
+			GroupByLabel: statusPage.GroupByLabel,
+			CreatedAt:    statusPage.CreatedAt,
+		}
+		for _, component := range statusPage.Components {
+			record.Components = append(record.Components, StatusPageComponent{
+				ID:        component.ID,
+				Name:      component.Name,
+				GroupName: component.GroupName,
+				Position:  component.Position,
+				CheckID:   component.CheckID,
+				CreatedAt: component.CreatedAt,
+			})
+		}
+		err := w.write(StatusPageKind, record)
+		if err != nil {
+			return nil, err
+		}
+		counts[StatusPageKind]++
+	}
+	windows, err := store.MaintenanceWindows().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, window := range windows {
+		labels, err := window.GetLabels()
+		if err != nil {
+			return nil, err
+		}
+		record := MaintenanceWindow{
+			ID:          window.ID,
+			Title:       window.Title,
+			Description: window.Description,
+			Mode:        window.Mode,
+			StartsAt:    window.StartsAt,
+			EndsAt:      window.EndsAt,
+			Schedule:    window.Schedule,
+			Duration:    window.Duration,
+			Timezone:    window.Timezone,
+			Labels:      labels,
+			CreatedAt:   window.CreatedAt,
+		}
+		for _, chk := range window.Checks {
+			// the checks matching the labels are left to the labels so that they are matched
+			// again once imported
+			if len(labels) > 0 && chk.GetLabels().Matches(labels) {
+				continue
+			}
+			record.CheckIDs = append(record.CheckIDs, chk.ID)
+		}
+		err = w.write(MaintenanceWindowKind, record)
+		if err != nil {
+			return nil, err
+		}
+		counts[MaintenanceWindowKind]++
+	}
+	incidents, err := store.Incidents().List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		record := Incident{
+			ID:         incident.ID,
+			Title:      incident.Title,
+			Status:     incident.Status,
+			Impact:     incident.Impact,
+			ResolvedAt: incident.ResolvedAt,
+			CreatedAt:  incident.CreatedAt,
+		}
+		for _, component := range incident.Components {
+			record.ComponentIDs = append(record.ComponentIDs, component.ID)
+		}
+		// updates are exported oldest first so that they are imported in the order they were posted
+		for i := len(incident.Updates) - 1; i >= 0; i-- {
+			update := incident.Updates[i]
+			record.Updates = append(record.Updates, IncidentUpdate{
+				Status:    update.Status,
+				Body:      update.Body,
+				CreatedAt: update.CreatedAt,
+			})
+		}
+		err := w.write(IncidentKind, record)
+		if err != nil {
+			return nil, err
+		}
+		counts[IncidentKind]++
+	}
+	if !options.Executions {
+		return counts, nil
+	}
+	for _, chk := range checks {
+		count, err := exportExecutions(ctx, store, w, chk.ID)
+		if err != nil {
+			return nil, err
+		}
+		counts[ExecutionKind] += count
+	}
+	return counts, nil
+}
+
+// listChecks returns every check of the workspace
+func listChecks(ctx context.Context, store storage.Storage) ([]db.Check, error) {
+	var checks []db.Check
+	first := exportPageSize
+	p := db.Pagination{First: &first}
+	for {
+		page, err := store.Checks().List(ctx, db.CheckFilter{}, db.Order{Column: db.IdentifierColumn, Direction: db.Asc}, p)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, page.Checks...)
+		if !page.PageInfo.HasNextPage || len(page.Cursors) == 0 {
+			return checks, nil
+		}
+		p.After = &page.Cursors[len(page.Cursors)-1]
+	}
+}
+
+// exportExecutions writes the executions of the check oldest first
+func exportExecutions(ctx context.Context, store storage.Storage, w *writer, checkID string) (int, error) {
+	count := 0
+	first := exportPageSize
+	p := db.Pagination{First: &first}
+	order := db.Order{Column: db.CreatedAtColumn, Direction: db.Asc}
+	for {
+		page, err := store.Executions().List(ctx, db.ExecutionFilter{CheckID: checkID}, order, p)
+		if err != nil {
+			return count, err
+		}
+		for _, execution := range page.Executions {
+			err := w.write(ExecutionKind, Execution{
+				CheckID:     execution.CheckID,
+				Status:      execution.Status,
+				ErrorMsg:    execution.ErrorMsg,
+				Message:     execution.Message,
+				Latency:     execution.Latency,
+				Maintenance: execution.Maintenance,
+				Stats:       rawJSON(execution.Stats),
+				CreatedAt:   execution.CreatedAt,
+			})
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+		if !page.PageInfo.HasNextPage || len(page.Cursors) == 0 {
+			return count, nil
+		}
+		p.After = &page.Cursors[len(page.Cursors)-1]
+	}
+}
+
+// rawJSON keeps the JSON columns as they are, empty columns are omitted
+func rawJSON(data datatypes.JSON) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.RawMessage(data)
+}
@@ -0,0 +1,551 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"io"
+	"strings"
+	"time"
+)
+
+// ConflictPolicy decides what happens to the resources of the archive that already exist, checks
+// are matched by identifier, notification channels by name, status pages by slug, components by
+// name within their status page, maintenance windows and incidents by title and creation time
+type ConflictPolicy string
+
+const (
+	// FailOnConflict aborts the import before any change when a resource already exists
+	FailOnConflict ConflictPolicy = "fail"
+	// SkipConflicts keeps the existing resources, the imported ones referencing them are linked to them
+	SkipConflicts ConflictPolicy = "skip"
+	// OverwriteConflicts replaces the existing resources with the ones of the archive, incidents are
+	// kept as they are since their updates can't be rewritten
+	OverwriteConflicts ConflictPolicy = "overwrite"
+)
+
+type ImportOptions struct {
+	OnConflict ConflictPolicy
+	// Executions imports the execution history of the checks created by the import, the history
+	// of the existing checks is kept as it is so that it isn't duplicated
+	Executions bool
+}
+
+// Result counts the records of a kind by outcome
+type Result struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+type Summary map[Kind]Result
+
+// resources holds the records of the archive preceding the executions
+type resources struct {
+	checks      []Check
+	channels    []NotificationChannel
+	statusPages []StatusPage
+	windows     []MaintenanceWindow
+	incidents   []Incident
+}
+
+type importer struct {
+	store   storage.Storage
+	options ImportOptions
+	summary Summary
+	// checkIDs and componentIDs map the ids of the archive to the ids of the database
+	checkIDs     map[string]string
+	componentIDs map[string]string
+	// createdChecks are the checks whose executions are imported
+	createdChecks map[string]bool
+	checks        map[string]db.Check
+	channels      map[string]db.NotificationChannel
+	statusPages   map[string]db.StatusPage
+	windows       map[string]db.MaintenanceWindow
+	incidents     map[string]db.Incident
+}
+
+// Import restores the archive in the workspace of the context. The resources are created with new
+// ids and the references between them are remapped, the import runs in a single transaction so
+// that nothing is imported when it fails, except for the executions already written to a sink
+func Import(ctx context.Context, store storage.Storage, in io.Reader, options ImportOptions) (Summary, error) {
+	switch options.OnConflict {
+	case FailOnConflict, SkipConflicts, OverwriteConflicts:
+	default:
+		return nil, errors.Errorf("Invalid conflict policy %s, expected fail, skip or overwrite", options.OnConflict)
+	}
+	r, err := newReader(in)
+	if err != nil {
+		return nil, err
+	}
+	archive, execution, err := readResources(r)
+	if err != nil {
+		return nil, err
+	}
+	im := &importer{
+		options:       options,
+		summary:       Summary{},
+		checkIDs:      map[string]string{},
+		componentIDs:  map[string]string{},
+		createdChecks: map[string]bool{},
+	}
+	err = store.Transaction(ctx, func(tx storage.Storage) error {
+		im.store = tx
+		return im.run(ctx, r, archive, execution)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return im.summary, nil
+}
+
+func (im *importer) run(ctx context.Context, r *reader, archive *resources, execution json.RawMessage) error {
+	err := im.loadExisting(ctx)
+	if err != nil {
+		return err
+	}
+	if im.options.OnConflict == FailOnConflict {
+		conflicts := im.conflicts(archive)
+		if len(conflicts) > 0 {
+			return errors.Errorf("%d resources already exist, nothing was imported: %s", len(conflicts), strings.Join(conflicts, ", "))
+		}
+	}
+	steps := []func(ctx context.Context, archive *resources) error{
+		im.importChecks,
+		im.importChannels,
+		im.importStatusPages,
+		im.importWindows,
+		im.importIncidents,
+	}
+	for _, step := range steps {
+		err := step(ctx, archive)
+		if err != nil {
+			return err
+		}
+	}
+	if !im.options.Executions || execution == nil {
+		return nil
+	}
+	for execution != nil {
+		err := im.importExecution(ctx, execution)
+		if err != nil {
+			return err
+		}
+		kind, record, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if kind != ExecutionKind {
+			return errors.Errorf("Invalid archive, %s record after the executions at line %d", kind, r.lineNo)
+		}
+		execution = record
+	}
+	return nil
+}
+
+// readResources reads the records up to the first execution, which is returned unread
+func readResources(r *reader) (*resources, json.RawMessage, error) {
+	archive := &resources{}
+	for {
+		kind, record, err := r.next()
+		if err == io.EOF {
+			return archive, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		var target interface{}
+		switch kind {
+		case CheckKind:
+			archive.checks = append(archive.checks, Check{})
+			target = &archive.checks[len(archive.checks)-1]
+		case NotificationChannelKind:
+			archive.channels = append(archive.channels, NotificationChannel{})
+			target = &archive.channels[len(archive.channels)-1]
+		case StatusPageKind:
+			archive.statusPages = append(archive.statusPages, StatusPage{})
+			target = &archive.statusPages[len(archive.statusPages)-1]
+		case MaintenanceWindowKind:
+			archive.windows = append(archive.windows, MaintenanceWindow{})
+			target = &archive.windows[len(archive.windows)-1]
+		case IncidentKind:
+			archive.incidents = append(archive.incidents, Incident{})
+			target = &archive.incidents[len(archive.incidents)-1]
+		case ExecutionKind:
+			return archive, record, nil
+		default:
+			return nil, nil, errors.Errorf("Unknown record kind %s at line %d", kind, r.lineNo)
+		}
+		err = json.Unmarshal(record, target)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Invalid %s record at line %d", kind, r.lineNo)
+		}
+	}
+}
+
+// historyKey identifies the maintenance windows and the incidents, the creation time is truncated
+// since every database stores the times with a different precision
+func historyKey(title string, createdAt time.Time) string {
+	return fmt.Sprintf("%s@%s", title, createdAt.UTC().Truncate(time.Second).Format(time.RFC3339))
+}
+
+func (im *importer) loadExisting(ctx context.Context) error {
+	checks, err := listChecks(ctx, im.store)
+	if err != nil {
+		return err
+	}
+	im.checks = map[string]db.Check{}
+	for _, chk := range checks {
+		im.checks[chk.Identifier] = chk
+	}
+	channels, err := im.store.Channels().List(ctx)
+	if err != nil {
+		return err
+	}
+	im.channels = map[string]db.NotificationChannel{}
+	for _, channel := range channels {
+		im.channels[channel.Name] = channel
+	}
+	statusPages, err := im.store.StatusPages().List(ctx)
+	if err != nil {
+		return err
+	}
+	im.statusPages = map[string]db.StatusPage{}
+	for _, statusPage := range statusPages {
+		im.statusPages[statusPage.Slug] = statusPage
+	}
+	windows, err := im.store.MaintenanceWindows().List(ctx)
+	if err != nil {
+		return err
+	}
+	im.windows = map[string]db.MaintenanceWindow{}
+	for _, window := range windows {
+		im.windows[historyKey(window.Title, window.CreatedAt)] = window
+	}
+	incidents, err := im.store.Incidents().List(ctx, nil)
+	if err != nil {
+		return err
+	}
+	im.incidents = map[string]db.Incident{}
+	for _, incident := range incidents {
+		im.incidents[historyKey(incident.Title, incident.CreatedAt)] = incident
+	}
+	return nil
+}
+
+// conflicts returns the resources of the archive that already exist
+func (im *importer) conflicts(archive *resources) []string {
+	var conflicts []string
+	for _, record := range archive.checks {
+		if _, ok := im.checks[record.Identifier]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("check %s", record.Identifier))
+		}
+	}
+	for _, record := range archive.channels {
+		if _, ok := im.channels[record.Name]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("notification channel %s", record.Name))
+		}
+	}
+	for _, record := range archive.statusPages {
+		if _, ok := im.statusPages[record.Slug]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("status page %s", record.Slug))
+		}
+	}
+	for _, record := range archive.windows {
+		if _, ok := im.windows[historyKey(record.Title, record.CreatedAt)]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("maintenance window %s", record.Title))
+		}
+	}
+	for _, record := range archive.incidents {
+		if _, ok := im.incidents[historyKey(record.Title, record.CreatedAt)]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("incident %s", record.Title))
+		}
+	}
+	return conflicts
+}
+
+func (im *importer) count(kind Kind, update func(result *Result)) {
+	result := im.summary[kind]
+	update(&result)
+	im.summary[kind] = result
+}
+
+func (im *importer) importChecks(ctx context.Context, archive *resources) error {
+	for _, record := range archive.checks {
+		chk, exists := im.checks[record.Identifier]
+		if exists {
+			im.checkIDs[record.ID] = chk.ID
+			if im.options.OnConflict == SkipConflicts {
+				im.count(CheckKind, func(result *Result) { result.Skipped++ })
+				continue
+			}
+		} else {
+			chk = db.Check{
+				ID:         uuid.New().String(),
+				Identifier: record.Identifier,
+				Status:     db.Scheduled,
+				CreatedAt:  record.CreatedAt,
+			}
+		}
+		chk.Type = record.Type
+		chk.Data = datatypes.JSON(record.Data)
+		chk.Frequency = record.Frequency
+		chk.Description = record.Description
+		chk.Owner = record.Owner
+		chk.Labels = db.NewCheckLabels(chk.ID, record.Labels)
+		chk.Paused = record.Paused
+		if exists {
+			err := im.store.Checks().Save(ctx, db.UpdateOperation, &chk)
+			if err != nil {
+				return errors.Wrapf(err, "Failed overwriting check %s", record.Identifier)
+			}
+			im.count(CheckKind, func(result *Result) { result.Updated++ })
+			continue
+		}
+		err := im.store.Checks().Create(ctx, &chk)
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing check %s", record.Identifier)
+		}
+		im.checkIDs[record.ID] = chk.ID
+		im.createdChecks[chk.ID] = true
+		im.count(CheckKind, func(result *Result) { result.Created++ })
+	}
+	return nil
+}
+
+func (im *importer) importChannels(ctx context.Context, archive *resources) error {
+	for _, record := range archive.channels {
+		channel, exists := im.channels[record.Name]
+		if exists && im.options.OnConflict == SkipConflicts {
+			im.count(NotificationChannelKind, func(result *Result) { result.Skipped++ })
+			continue
+		}
+		if !exists {
+			channel = db.NotificationChannel{
+				ID:        uuid.New().String(),
+				Name:      record.Name,
+				CreatedAt: record.CreatedAt,
+			}
+		}
+		channel.Type = record.Type
+		channel.Url = record.Url
+		err := channel.SetLabels(record.Labels)
+		if err != nil {
+			return err
+		}
+		if exists {
+			err := im.store.Channels().Save(ctx, &channel)
+			if err != nil {
+				return errors.Wrapf(err, "Failed overwriting notification channel %s", record.Name)
+			}
+			im.count(NotificationChannelKind, func(result *Result) { result.Updated++ })
+			continue
+		}
+		err = im.store.Channels().Create(ctx, &channel)
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing notification channel %s", record.Name)
+		}
+		im.count(NotificationChannelKind, func(result *Result) { result.Created++ })
+	}
+	return nil
+}
+
+// importStatusPages imports the status pages along with their components, the components of the
+// existing status pages are matched by name and the missing ones are added unless they are skipped
+func (im *importer) importStatusPages(ctx context.Context, archive *resources) error {
+	for _, record := range archive.statusPages {
+		statusPage, exists := im.statusPages[record.Slug]
+		existingComponents := map[string]db.StatusPageComponent{}
+		for _, component := range statusPage.Components {
+			existingComponents[component.Name] = component
+		}
+		for _, component := range record.Components {
+			if existing, ok := existingComponents[component.Name]; ok {
+				im.componentIDs[component.ID] = existing.ID
+			}
+		}
+		if exists && im.options.OnConflict == SkipConflicts {
+			im.count(StatusPageKind, func(result *Result) { result.Skipped++ })
+			continue
+		}
+		if !exists {
+			statusPage = db.StatusPage{
+				ID:        uuid.New().String(),
+				Slug:      record.Slug,
+				CreatedAt: record.CreatedAt,
+			}
+		}
+		statusPage.Title = record.Title
+		statusPage.LogoURL = record.LogoURL
+		statusPage.Domain = record.Domain
+		statusPage.GroupByLabel = record.GroupByLabel
+		statusPage.Components = nil
+		if exists {
+			err := im.store.StatusPages().Save(ctx, &statusPage)
+			if err != nil {
+				return errors.Wrapf(err, "Failed overwriting status page %s", record.Slug)
+			}
+			im.count(StatusPageKind, func(result *Result) { result.Updated++ })
+		} else {
+			err := im.store.StatusPages().Create(ctx, &statusPage)
+			if err != nil {
+				return errors.Wrapf(err, "Failed importing status page %s", record.Slug)
+			}
+			im.count(StatusPageKind, func(result *Result) { result.Created++ })
+		}
+		for _, record := range record.Components {
+			if _, ok := existingComponents[record.Name]; ok {
+				continue
+			}
+			checkID, ok := im.checkIDs[record.CheckID]
+			if !ok {
+				log.Warnf("Skipping component %s of status page %s, its check isn't in the archive", record.Name, statusPage.Slug)
+				continue
+			}
+			component := db.StatusPageComponent{
+				ID:           uuid.New().String(),
+				StatusPageID: statusPage.ID,
+				Name:         record.Name,
+				GroupName:    record.GroupName,
+				Position:     record.Position,
+				CheckID:      checkID,
+				CreatedAt:    record.CreatedAt,
+			}
+			err := im.store.StatusPages().AddComponent(ctx, &component)
+			if err != nil {
+				return errors.Wrapf(err, "Failed importing component %s of status page %s", record.Name, statusPage.Slug)
+			}
+			im.componentIDs[record.ID] = component.ID
+		}
+	}
+	return nil
+}
+
+func (im *importer) importWindows(ctx context.Context, archive *resources) error {
+	for _, record := range archive.windows {
+		window, exists := im.windows[historyKey(record.Title, record.CreatedAt)]
+		if exists && im.options.OnConflict == SkipConflicts {
+			im.count(MaintenanceWindowKind, func(result *Result) { result.Skipped++ })
+			continue
+		}
+		if !exists {
+			window = db.MaintenanceWindow{
+				ID:        uuid.New().String(),
+				Title:     record.Title,
+				CreatedAt: record.CreatedAt,
+			}
+		}
+		window.Description = record.Description
+		window.Mode = record.Mode
+		window.StartsAt = record.StartsAt
+		window.EndsAt = record.EndsAt
+		window.Schedule = record.Schedule
+		window.Duration = record.Duration
+		window.Timezone = record.Timezone
+		err := window.SetLabels(record.Labels)
+		if err != nil {
+			return err
+		}
+		window.Checks = nil
+		for _, id := range record.CheckIDs {
+			checkID, ok := im.checkIDs[id]
+			if !ok {
+				log.Warnf("Skipping check %s of maintenance window %s, it isn't in the archive", id, record.Title)
+				continue
+			}
+			window.Checks = append(window.Checks, db.Check{ID: checkID})
+		}
+		if exists {
+			err := im.store.MaintenanceWindows().Save(ctx, &window)
+			if err != nil {
+				return errors.Wrapf(err, "Failed overwriting maintenance window %s", record.Title)
+			}
+			im.count(MaintenanceWindowKind, func(result *Result) { result.Updated++ })
+			continue
+		}
+		err = im.store.MaintenanceWindows().Create(ctx, &window)
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing maintenance window %s", record.Title)
+		}
+		im.count(MaintenanceWindowKind, func(result *Result) { result.Created++ })
+	}
+	return nil
+}
+
+func (im *importer) importIncidents(ctx context.Context, archive *resources) error {
+	for _, record := range archive.incidents {
+		if _, exists := im.incidents[historyKey(record.Title, record.CreatedAt)]; exists {
+			im.count(IncidentKind, func(result *Result) { result.Skipped++ })
+			continue
+		}
+		incident := db.Incident{
+			ID:         uuid.New().String(),
+			Title:      record.Title,
+			Status:     record.Status,
+			Impact:     record.Impact,
+			ResolvedAt: record.ResolvedAt,
+			CreatedAt:  record.CreatedAt,
+		}
+		for _, update := range record.Updates {
+			incident.Updates = append(incident.Updates, db.IncidentUpdate{
+				ID:        uuid.New().String(),
+				Status:    update.Status,
+				Body:      update.Body,
+				CreatedAt: update.CreatedAt,
+			})
+		}
+		var componentIDs []string
+		for _, id := range record.ComponentIDs {
+			componentID, ok := im.componentIDs[id]
+			if !ok {
+				log.Warnf("Skipping component %s of incident %s, it isn't in the archive", id, record.Title)
+				continue
+			}
+			componentIDs = append(componentIDs, componentID)
+		}
+		err := im.store.Incidents().Create(ctx, &incident, componentIDs)
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing incident %s", record.Title)
+		}
+		im.count(IncidentKind, func(result *Result) { result.Created++ })
+	}
+	return nil
+}
+
+func (im *importer) importExecution(ctx context.Context, raw json.RawMessage) error {
+	record := Execution{}
+	err := json.Unmarshal(raw, &record)
+	if err != nil {
+		return errors.Wrap(err, "Invalid execution record")
+	}
+	checkID, ok := im.checkIDs[record.CheckID]
+	if !ok || !im.createdChecks[checkID] {
+		im.count(ExecutionKind, func(result *Result) { result.Skipped++ })
+		return nil
+	}
+	err = im.store.Executions().Create(ctx, &db.CheckExecution{
+		ID:          uuid.New().String(),
+		CheckID:     checkID,
+		Status:      record.Status,
+		ErrorMsg:    record.ErrorMsg,
+		Message:     record.Message,
+		Latency:     record.Latency,
+		Maintenance: record.Maintenance,
+		Stats:       datatypes.JSON(record.Stats),
+		CreatedAt:   record.CreatedAt,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed importing execution of check %s", record.CheckID)
+	}
+	im.count(ExecutionKind, func(result *Result) { result.Created++ })
+	return nil
+}
@@ -0,0 +1,33 @@
+package check
+
+import (
+	"fmt"
+	"time"
+)
+
+// Assertions are verified on top of the result of a probe, zero values are not verified
+type Assertions struct {
+	// StatusCode expected from http checks
+	StatusCode int           `yaml:"statusCode"`
+	MaxLatency time.Duration `yaml:"maxLatency"`
+	// MinCertValidity is the minimum time left before the certificate of tls checks expires
+	MinCertValidity time.Duration `yaml:"minCertValidity"`
+}
+
+// Verify returns the assertions failed by the result
+func (a Assertions) Verify(result Result) []string {
+	var failures []string
+	if a.MaxLatency > 0 && result.Statistics != nil && result.Statistics.GetTimeTaken() > a.MaxLatency {
+		failures = append(failures, fmt.Sprintf("Latency %s exceeds %s", result.Statistics.GetTimeTaken(), a.MaxLatency))
+	}
+	tlsStatistics, ok := result.Statistics.(TlsStatistics)
+	if a.MinCertValidity > 0 && ok && len(tlsStatistics.PeerCertificates) > 0 {
+		expiry := tlsStatistics.CertificateExpiry()
+		if expiry == nil {
+			failures = append(failures, "Invalid certificate")
+		} else if validity := time.Until(*expiry); validity < a.MinCertValidity {
+			failures = append(failures, fmt.Sprintf("Certificate expires in %s, less than %s", validity.Round(time.Second), a.MinCertValidity))
+		}
+	}
+	return failures
+}
@@ -0,0 +1,106 @@
+package check
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// certificate returns a self-signed certificate expiring after the validity
+func certificate(t *testing.T, validity time.Duration) PeerCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+	}
+	content, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return PeerCertificate{Content: content}
+}
+
+func TestVerify(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name       string
+		assertions Assertions
+		result     Result
+		// failures are matched by prefix, the validity left depends on the time of the test
+		failures []string
+	}{
+		{
+			name:   "no assertions",
+			result: Result{Statistics: HttpStatistics{TimeTaken: time.Minute}},
+		},
+		{
+			name:       "latency below the maximum",
+			assertions: Assertions{MaxLatency: time.Second},
+			result:     Result{Statistics: HttpStatistics{TimeTaken: 200 * time.Millisecond}},
+		},
+		{
+			name:       "latency above the maximum",
+			assertions: Assertions{MaxLatency: time.Second},
+			result:     Result{Statistics: TcpStatistics{TimeTaken: 2 * time.Second}},
+			failures:   []string{"Latency 2s exceeds 1s"},
+		},
+		{
+			name:       "latency without statistics",
+			assertions: Assertions{MaxLatency: time.Second},
+			result:     Result{},
+		},
+		{
+			name:       "certificate valid long enough",
+			assertions: Assertions{MinCertValidity: 7 * day},
+			result:     Result{Statistics: TlsStatistics{PeerCertificates: []PeerCertificate{certificate(t, 30*day)}}},
+		},
+		{
+			name:       "certificate expiring soon",
+			assertions: Assertions{MinCertValidity: 7 * day},
+			result:     Result{Statistics: TlsStatistics{PeerCertificates: []PeerCertificate{certificate(t, 2*day)}}},
+			failures:   []string{"Certificate expires in 4"},
+		},
+		{
+			name:       "invalid certificate",
+			assertions: Assertions{MinCertValidity: 7 * day},
+			result:     Result{Statistics: TlsStatistics{PeerCertificates: []PeerCertificate{{Content: []byte("invalid")}}}},
+			failures:   []string{"Invalid certificate"},
+		},
+		{
+			name:       "certificate validity of a check without tls",
+			assertions: Assertions{MinCertValidity: 7 * day},
+			result:     Result{Statistics: HttpStatistics{}},
+		},
+		{
+			name:       "every assertion failed",
+			assertions: Assertions{MaxLatency: time.Second, MinCertValidity: 7 * day},
+			result:     Result{Statistics: TlsStatistics{TimeTaken: 3 * time.Second, PeerCertificates: []PeerCertificate{{}}}},
+			failures:   []string{"Latency 3s exceeds 1s", "Invalid certificate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := tt.assertions.Verify(tt.result)
+			if len(failures) != len(tt.failures) {
+				t.Fatalf("Verify() = %q, want %q", failures, tt.failures)
+			}
+			for i, failure := range failures {
+				if !strings.HasPrefix(failure, tt.failures[i]) {
+					t.Errorf("Verify() = %q, want %q", failures, tt.failures)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package check
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Statistics interface {
 	GetTimeTaken() time.Duration
@@ -22,5 +25,5 @@ const (
 
 type Check interface {
 	GetType() Type
-	Check() Result
+	Check(ctx context.Context) Result
 }
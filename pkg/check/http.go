@@ -1,8 +1,11 @@
 package check
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"net/http"
 	"strings"
 	"time"
@@ -26,11 +29,20 @@ func (h HttpCheck) GetType() Type {
 	return HttpType
 }
 
-func (h HttpCheck) Check()  Result {
+func (h HttpCheck) Check(ctx context.Context) Result {
 	result := Result{}
 	statistics := HttpStatistics{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		result.Statistics = statistics
+		result.Error = err
+		result.Message = err.Error()
+		return result
+	}
+	// the target can correlate its own trace with the probe
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	start := time.Now()
-	resp, err := http.Get(h.url)
+	resp, err := http.DefaultClient.Do(req)
 	end := time.Now()
 	statistics.TimeTaken = end.Sub(start)
 	if err != nil {
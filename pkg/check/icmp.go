@@ -1,6 +1,7 @@
 package check
 
 import (
+	"context"
 	"github.com/go-ping/ping"
 	"time"
 )
@@ -21,7 +22,7 @@ func (h IcmpCheck) GetType() Type {
 	return IcmpType
 }
 
-func (h IcmpCheck) Check() (result Result) {
+func (h IcmpCheck) Check(ctx context.Context) (result Result) {
 	statistics := IcmpStatistics{}
 	result.Statistics = statistics
 	start := time.Now()
@@ -35,6 +36,7 @@ func (h IcmpCheck) Check() (result Result) {
 	err = pinger.Run()
 	end := time.Now()
 	statistics.TimeTaken = end.Sub(start)
+	result.Statistics = statistics
 	if err != nil {
 		result.Error = err
 		result.Message = err.Error()
@@ -42,6 +44,7 @@ func (h IcmpCheck) Check() (result Result) {
 	}
 	stats := pinger.Statistics() // get send/receive/duplicate/rtt stats
 	statistics.PingStatistics = stats
+	result.Statistics = statistics
 	return
 }
 
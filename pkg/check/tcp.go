@@ -1,6 +1,7 @@
 package check
 
 import (
+	"context"
 	"net"
 	"time"
 )
@@ -21,11 +22,12 @@ func (h TcpCheck) GetType() Type {
 	return TcpType
 }
 
-func (h TcpCheck) Check() Result {
+func (h TcpCheck) Check(ctx context.Context) Result {
 	result := Result{}
 	statistics := TcpStatistics{}
 	start := time.Now()
-	resp, err := net.DialTimeout("tcp", h.addr, 10*time.Second)
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	resp, err := dialer.DialContext(ctx, "tcp", h.addr)
 	end := time.Now()
 	statistics.TimeTaken = end.Sub(start)
 	if err != nil {
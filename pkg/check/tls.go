@@ -1,7 +1,9 @@
 package check
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"github.com/pkg/errors"
 	"time"
 )
@@ -24,11 +26,23 @@ func (i TlsStatistics) GetTimeTaken() time.Duration {
 	return i.TimeTaken
 }
 
+// CertificateExpiry returns the expiry of the peer certificate, nil when there is none
+func (i TlsStatistics) CertificateExpiry() *time.Time {
+	if len(i.PeerCertificates) == 0 {
+		return nil
+	}
+	certificate, err := x509.ParseCertificate(i.PeerCertificates[0].Content)
+	if err != nil {
+		return nil
+	}
+	return &certificate.NotAfter
+}
+
 func (h TlsCheck) GetType() Type {
 	return TlsType
 }
 
-func (h TlsCheck) Check() Result {
+func (h TlsCheck) Check(ctx context.Context) Result {
 	result := Result{}
 	statistics := TlsStatistics{}
 	start := time.Now()
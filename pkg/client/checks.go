@@ -0,0 +1,264 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+const checkFragment = `
+fragment CheckFields on Check {
+	type: __typename
+	id
+	identifier
+	frecuency
+	status
+	latestCheck
+	message
+	errorMsg
+	description
+	owner
+	labels { name value }
+	... on HttpCheck { url }
+	... on TcpCheck { address }
+	... on TlsCheck { address }
+	... on IcmpCheck { address }
+}
+`
+
+type Label struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+type Check struct {
+	Type        string     `json:"type" yaml:"type"`
+	ID          string     `json:"id" yaml:"id"`
+	Identifier  string     `json:"identifier" yaml:"identifier"`
+	Frecuency   string     `json:"frecuency" yaml:"frecuency"`
+	Status      string     `json:"status" yaml:"status"`
+	Url         string     `json:"url,omitempty" yaml:"url,omitempty"`
+	Address     string     `json:"address,omitempty" yaml:"address,omitempty"`
+	LatestCheck *time.Time `json:"latestCheck" yaml:"latestCheck"`
+	Message     string     `json:"message" yaml:"message"`
+	ErrorMsg    string     `json:"errorMsg" yaml:"errorMsg"`
+	Description string     `json:"description" yaml:"description"`
+	Owner       string     `json:"owner" yaml:"owner"`
+	Labels      []Label    `json:"labels" yaml:"labels"`
+}
+
+// Target returns the url or the address probed by the check
+func (c Check) Target() string {
+	if c.Url != "" {
+		return c.Url
+	}
+	return c.Address
+}
+
+type CheckFilter struct {
+	Types    []string `json:"types,omitempty"`
+	Statuses []string `json:"statuses,omitempty"`
+	Owner    *string  `json:"owner,omitempty"`
+	Labels   []Label  `json:"labels,omitempty"`
+}
+
+type pageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// ListChecks returns every check matching the filter, fetching all the pages
+func (c *Client) ListChecks(filter CheckFilter) ([]Check, error) {
+	query := `query ($filter: CheckFilter, $after: String) {
+	checks(filter: $filter, first: 500, after: $after) {
+		edges { node { ...CheckFields } }
+		pageInfo { hasNextPage endCursor }
+	}
+}` + checkFragment
+	var checks []Check
+	var after *string
+	for {
+		data := struct {
+			Checks struct {
+				Edges []struct {
+					Node Check `json:"node"`
+				} `json:"edges"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"checks"`
+		}{}
+		err := c.Do(query, map[string]interface{}{"filter": filter, "after": after}, &data)
+		if err != nil {
+			return nil, err
+		}
+		for _, edge := range data.Checks.Edges {
+			checks = append(checks, edge.Node)
+		}
+		if !data.Checks.PageInfo.HasNextPage {
+			return checks, nil
+		}
+		after = data.Checks.PageInfo.EndCursor
+	}
+}
+
+// GetCheck finds the check by id or by identifier
+func (c *Client) GetCheck(idOrIdentifier string) (*Check, error) {
+	query := `query ($id: ID!, $identifier: String!) {
+	check(id: $id) { ...CheckFields }
+	checkByIdentifier(identifier: $identifier) { ...CheckFields }
+}` + checkFragment
+	data := struct {
+		Check             *Check `json:"check"`
+		CheckByIdentifier *Check `json:"checkByIdentifier"`
+	}{}
+	err := c.Do(query, map[string]interface{}{"id": idOrIdentifier, "identifier": idOrIdentifier}, &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Check != nil {
+		return data.Check, nil
+	}
+	if data.CheckByIdentifier != nil {
+		return data.CheckByIdentifier, nil
+	}
+	return nil, errors.Errorf("Check %s not found", idOrIdentifier)
+}
+
+type CreateCheckInput struct {
+	ID          string  `json:"id"`
+	Frecuency   string  `json:"frecuency"`
+	Url         string  `json:"url,omitempty"`
+	Address     string  `json:"address,omitempty"`
+	RootCAs     *string `json:"rootCAs,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Owner       *string `json:"owner,omitempty"`
+	Labels      []Label `json:"labels,omitempty"`
+}
+
+// CreateCheck creates a check of the given type, one of http, tcp, tls or icmp
+func (c *Client) CreateCheck(checkType string, input CreateCheckInput) (*Check, error) {
+	var mutation, inputType string
+	switch checkType {
+	case "http":
+		mutation, inputType = "createHttpCheck", "CreateHttpCheckInput"
+	case "tcp":
+		mutation, inputType = "createTcpCheck", "CreateTcpCheckInput"
+	case "tls":
+		mutation, inputType = "createTlsCheck", "CreateTlsCheckInput"
+	case "icmp":
+		mutation, inputType = "createIcmpCheck", "CreateIcmpCheckInput"
+	default:
+		return nil, errors.Errorf("Invalid check type %s", checkType)
+	}
+	query := `mutation ($input: ` + inputType + `!) {
+	check: ` + mutation + `(input: $input) { ...CheckFields }
+}` + checkFragment
+	data := struct {
+		Check Check `json:"check"`
+	}{}
+	err := c.Do(query, map[string]interface{}{"input": input}, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Check, nil
+}
+
+func (c *Client) DeleteCheck(id string) error {
+	query := `mutation ($id: ID!) {
+	deleteCheck(id: $id) { id }
+}`
+	return c.Do(query, map[string]interface{}{"id": id}, nil)
+}
+
+func (c *Client) PauseCheck(id string) (*Check, error) {
+	return c.checkMutation("pauseCheck", id)
+}
+
+func (c *Client) ResumeCheck(id string) (*Check, error) {
+	return c.checkMutation("resumeCheck", id)
+}
+
+func (c *Client) checkMutation(mutation string, id string) (*Check, error) {
+	query := `mutation ($id: ID!) {
+	check: ` + mutation + `(id: $id) { ...CheckFields }
+}` + checkFragment
+	data := struct {
+		Check Check `json:"check"`
+	}{}
+	err := c.Do(query, map[string]interface{}{"id": id}, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Check, nil
+}
+
+type Execution struct {
+	ID            string    `json:"id" yaml:"id"`
+	ExecutionTime time.Time `json:"executionTime" yaml:"executionTime"`
+	Status        string    `json:"status" yaml:"status"`
+	Latency       float64   `json:"latency" yaml:"latency"`
+	Maintenance   bool      `json:"maintenance" yaml:"maintenance"`
+	Message       string    `json:"message" yaml:"message"`
+	ErrorMsg      string    `json:"errorMsg" yaml:"errorMsg"`
+}
+
+type ExecutionFilter struct {
+	From     *time.Time
+	Until    *time.Time
+	Statuses []string
+	Limit    int
+}
+
+// ListExecutions returns the latest executions of the check
+func (c *Client) ListExecutions(checkID string, filter ExecutionFilter) ([]Execution, error) {
+	query := `query ($checkId: ID!, $from: Time, $until: Time, $statuses: [String!], $first: Int) {
+	executions(
+		checkId: $checkId,
+		from: $from,
+		until: $until,
+		statuses: $statuses,
+		orderBy: {field: EXECUTION_TIME, direction: DESC},
+		first: $first
+	) {
+		edges { node { id executionTime status latency maintenance message errorMsg } }
+	}
+}`
+	variables := map[string]interface{}{
+		"checkId":  checkID,
+		"from":     filter.From,
+		"until":    filter.Until,
+		"statuses": filter.Statuses,
+		"first":    filter.Limit,
+	}
+	data := struct {
+		Executions struct {
+			Edges []struct {
+				Node Execution `json:"node"`
+			} `json:"edges"`
+		} `json:"executions"`
+	}{}
+	err := c.Do(query, variables, &data)
+	if err != nil {
+		return nil, err
+	}
+	executions := []Execution{}
+	for _, edge := range data.Executions.Edges {
+		executions = append(executions, edge.Node)
+	}
+	return executions, nil
+}
+
+// Poll executes every check and returns the milliseconds taken
+func (c *Client) Poll() (int, error) {
+	query := `mutation {
+	poll { took }
+}`
+	data := struct {
+		Poll struct {
+			Took int `json:"took"`
+		} `json:"poll"`
+	}{}
+	err := c.Do(query, nil, &data)
+	if err != nil {
+		return 0, err
+	}
+	return data.Poll.Took, nil
+}
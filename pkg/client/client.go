@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client calls the GraphQL API of a running server
+type Client struct {
+	Url string
+	// Token is sent as a bearer token when set
+	Token      string
+	HTTPClient *http.Client
+}
+
+func New(url string) *Client {
+	return &Client{
+		Url:        url,
+		HTTPClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+type request struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Do executes the query and decodes its data into data
+func (c *Client) Do(query string, variables map[string]interface{}, data interface{}) error {
+	body, err := json.Marshal(request{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.Url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	r := response{}
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid response from %s, status %s", c.Url, resp.Status)
+	}
+	if len(r.Errors) > 0 {
+		var messages []string
+		for _, e := range r.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Unexpected status %s from %s", resp.Status, c.Url)
+	}
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Data, data)
+}
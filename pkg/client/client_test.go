@@ -0,0 +1,168 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stub is a GraphQL server answering every request with the response returned by its handler
+type stub struct {
+	t       *testing.T
+	tokens  []string
+	handler func(req request) (status int, body interface{})
+}
+
+func newStub(t *testing.T, handler func(req request) (int, interface{})) *Client {
+	t.Helper()
+	s := &stub{t: t, handler: handler}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return New(server.URL)
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.t.Errorf("invalid request: %v", err)
+	}
+	s.tokens = append(s.tokens, r.Header.Get("Authorization"))
+	status, body := s.handler(req)
+	w.WriteHeader(status)
+	if text, ok := body.(string); ok {
+		w.Write([]byte(text))
+		return
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func data(v interface{}) map[string]interface{} {
+	return map[string]interface{}{"data": v}
+}
+
+func checkNode(identifier string) map[string]interface{} {
+	return map[string]interface{}{"node": map[string]interface{}{"type": "HttpCheck", "id": identifier + "-id", "identifier": identifier}}
+}
+
+func TestListChecksFetchesEveryPage(t *testing.T) {
+	var afters []interface{}
+	c := newStub(t, func(req request) (int, interface{}) {
+		after := req.Variables["after"]
+		afters = append(afters, after)
+		if after == nil {
+			return http.StatusOK, data(map[string]interface{}{"checks": map[string]interface{}{
+				"edges":    []interface{}{checkNode("api"), checkNode("web")},
+				"pageInfo": map[string]interface{}{"hasNextPage": true, "endCursor": "cursor-web"},
+			}})
+		}
+		return http.StatusOK, data(map[string]interface{}{"checks": map[string]interface{}{
+			"edges":    []interface{}{checkNode("postgres")},
+			"pageInfo": map[string]interface{}{"hasNextPage": false, "endCursor": "cursor-postgres"},
+		}})
+	})
+	checks, err := c.ListChecks(CheckFilter{Types: []string{"HTTP"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var identifiers []string
+	for _, chk := range checks {
+		identifiers = append(identifiers, chk.Identifier)
+	}
+	if strings.Join(identifiers, ",") != "api,web,postgres" {
+		t.Errorf("ListChecks() = %v, want the checks of both pages", identifiers)
+	}
+	if !reflect.DeepEqual(afters, []interface{}{nil, "cursor-web"}) {
+		t.Errorf("after = %v, want the end cursor of the first page", afters)
+	}
+}
+
+func TestGetCheck(t *testing.T) {
+	tests := []struct {
+		name              string
+		check             interface{}
+		checkByIdentifier interface{}
+		identifier        string
+		err               string
+	}{
+		{"by id", checkNode("api")["node"], nil, "api", ""},
+		{"by identifier", nil, checkNode("web")["node"], "web", ""},
+		{"not found", nil, nil, "", "Check missing not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newStub(t, func(req request) (int, interface{}) {
+				return http.StatusOK, data(map[string]interface{}{"check": tt.check, "checkByIdentifier": tt.checkByIdentifier})
+			})
+			chk, err := c.GetCheck("missing")
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("GetCheck() error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chk.Identifier != tt.identifier {
+				t.Errorf("GetCheck() = %s, want %s", chk.Identifier, tt.identifier)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   interface{}
+		err    string
+	}{
+		{"graphql errors", http.StatusOK, map[string]interface{}{
+			"errors": []interface{}{map[string]interface{}{"message": "Forbidden"}, map[string]interface{}{"message": "Check api not found"}},
+		}, "Forbidden; Check api not found"},
+		{"errors of unauthenticated requests", http.StatusUnauthorized, map[string]interface{}{
+			"errors": []interface{}{map[string]interface{}{"message": "Unauthorized"}},
+		}, "Unauthorized"},
+		{"unexpected status", http.StatusBadGateway, map[string]interface{}{}, "Unexpected status 502 Bad Gateway"},
+		{"invalid response", http.StatusBadGateway, "<html>Bad Gateway</html>", "Invalid response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newStub(t, func(req request) (int, interface{}) {
+				return tt.status, tt.body
+			})
+			_, err := c.ListChecks(CheckFilter{})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("ListChecks() error = %v, want %s", err, tt.err)
+			}
+			_, err = c.GetCheck("api")
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("GetCheck() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTokenIsSent(t *testing.T) {
+	s := &stub{t: t, handler: func(req request) (int, interface{}) {
+		return http.StatusOK, data(map[string]interface{}{"poll": map[string]interface{}{"took": 12}})
+	}}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	c := New(server.URL)
+	c.Token = "secret"
+	took, err := c.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if took != 12 {
+		t.Errorf("Poll() = %d, want 12", took)
+	}
+	if s.tokens[0] != "Bearer secret" {
+		t.Errorf("Authorization = %s, want the bearer token", s.tokens[0])
+	}
+}
@@ -0,0 +1,284 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	uuid "github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"os/user"
+	"time"
+)
+
+type AuditOperation string
+
+const (
+	CreateOperation AuditOperation = "CREATE"
+	UpdateOperation AuditOperation = "UPDATE"
+	DeleteOperation AuditOperation = "DELETE"
+	PauseOperation  AuditOperation = "PAUSE"
+	ResumeOperation AuditOperation = "RESUME"
+	// RestoreOperation undeletes a check
+	RestoreOperation AuditOperation = "RESTORE"
+	// PurgeOperation deletes a check along with its history permanently
+	PurgeOperation AuditOperation = "PURGE"
+)
+
+// ResourceType is the kind of configuration recorded in the audit log
+type ResourceType string
+
+const (
+	CheckResource               ResourceType = "check"
+	NotificationChannelResource ResourceType = "notification_channel"
+	StatusPageResource          ResourceType = "status_page"
+	StatusPageComponentResource ResourceType = "status_page_component"
+	IncidentResource            ResourceType = "incident"
+	MaintenanceWindowResource   ResourceType = "maintenance_window"
+	ApiTokenResource            ResourceType = "api_token"
+	WorkspaceResource           ResourceType = "workspace"
+)
+
+// ErrAuditLogAppendOnly is returned when an audit entry is updated or deleted
+var ErrAuditLogAppendOnly = errors.New("The audit log is append-only")
+
+// AuditEntry records a change of the configuration, Before is empty for creations and After
+// for deletions
+type AuditEntry struct {
+	ID          string `gorm:"primaryKey"`
+	WorkspaceID string `gorm:"index;size:191;not null;default:'default'"`
+	// ActorID is the subject of the identity, or the user running the command line
+	ActorID      string
+	Actor        string         `gorm:"index;size:191"`
+	ActorMethod  string         `gorm:"size:32"`
+	Operation    AuditOperation `gorm:"index;size:32"`
+	ResourceType ResourceType   `gorm:"index:idx_audit_entry_resource;size:64"`
+	ResourceID   string         `gorm:"index:idx_audit_entry_resource;size:191"`
+	Before       datatypes.JSON
+	After        datatypes.JSON
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_entry"
+}
+
+func (AuditEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func (AuditEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+// Actor is the author of the changes recorded in the audit log
+type Actor struct {
+	ID     string
+	Name   string
+	Method string
+}
+
+// systemActor is the author of the changes without actor, such as the automatic ones
+var systemActor = Actor{Name: "system", Method: "system"}
+
+// CommandActor returns the user running a command of the command line
+func CommandActor(command string) Actor {
+	actor := Actor{Name: "unknown", Method: "cli:" + command}
+	current, err := user.Current()
+	if err == nil {
+		actor.ID = current.Uid
+		actor.Name = current.Username
+	}
+	return actor
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are recorded in the audit log as made by the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx == nil {
+		return systemActor
+	}
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok {
+		return systemActor
+	}
+	return actor
+}
+
+// auditRedactor is implemented by the resources holding credentials, which are replaced by a
+// fingerprint in the audit log so that changes are noticed without disclosing them
+type auditRedactor interface {
+	auditValue() interface{}
+}
+
+func fingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+func (c NotificationChannel) auditValue() interface{} {
+	c.Url = fingerprint(c.Url)
+	return c
+}
+
+func (t ApiToken) auditValue() interface{} {
+	t.Hash = ""
+	return t
+}
+
+func auditJSON(value interface{}) (datatypes.JSON, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if redactor, ok := value.(auditRedactor); ok {
+		value = redactor.auditValue()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RecordAudit appends a change to the audit log, the actor and the workspace are the ones of
+// the context of the connection
+func RecordAudit(db *gorm.DB, operation AuditOperation, resourceType ResourceType, resourceID string, before interface{}, after interface{}) error {
+	entry, err := NewAuditEntry(db.Statement.Context, operation, resourceType, resourceID, before, after)
+	if err != nil {
+		return err
+	}
+	return db.Session(&gorm.Session{NewDB: true}).Create(entry).Error
+}
+
+// NewAuditEntry returns the entry of a change made by the actor of the context, the credentials
+// of the resources are redacted
+func NewAuditEntry(ctx context.Context, operation AuditOperation, resourceType ResourceType, resourceID string, before interface{}, after interface{}) (*AuditEntry, error) {
+	actor := ActorFromContext(ctx)
+	entry := &AuditEntry{
+		ID:           uuid.New().String(),
+		ActorID:      actor.ID,
+		Actor:        actor.Name,
+		ActorMethod:  actor.Method,
+		Operation:    operation,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+	var err error
+	entry.Before, err = auditJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	entry.After, err = auditJSON(after)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+type AuditFilter struct {
+	ResourceType ResourceType
+	ResourceID   string
+	Actor        string
+	Operations   []string
+	From         *time.Time
+	Until        *time.Time
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry
+	Cursors    []string
+	PageInfo   PageInfo
+	TotalCount int64
+}
+
+func filterAuditEntries(db *gorm.DB, filter AuditFilter) *gorm.DB {
+	query := db.Model(&AuditEntry{})
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if len(filter.Operations) > 0 {
+		query = query.Where("operation IN ?", filter.Operations)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at <= ?", *filter.Until)
+	}
+	return query
+}
+
+// ListAuditEntries returns a page of the audit log, latest changes first
+func ListAuditEntries(db *gorm.DB, filter AuditFilter, p Pagination) (*AuditPage, error) {
+	query := filterAuditEntries(db, filter)
+	page := &AuditPage{}
+	result := query.Session(&gorm.Session{}).Count(&page.TotalCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	err := page.fetch(query, Order{Column: CreatedAtColumn, Direction: Desc}, p)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (page *AuditPage) fetch(query *gorm.DB, order Order, p Pagination) error {
+	query, limit, backwards, err := paginate(query, order, p)
+	if err != nil {
+		return err
+	}
+	var entries []AuditEntry
+	result := query.Find(&entries)
+	if result.Error != nil {
+		return result.Error
+	}
+	indexes, info := p.Trim(len(entries), limit, backwards)
+	page.PageInfo = info
+	for _, i := range indexes {
+		entry := entries[i]
+		page.Entries = append(page.Entries, entry)
+		page.Cursors = append(page.Cursors, EncodeCursor(entry.CreatedAt, entry.ID))
+	}
+	return nil
+}
+
+// ExportAuditEntries calls export with every entry matching the filter in chronological order,
+// the entries are read by pages so that the whole log is never held in memory
+func ExportAuditEntries(db *gorm.DB, filter AuditFilter, export func(AuditEntry) error) error {
+	size := maxPageSize
+	p := Pagination{First: &size}
+	for {
+		page := &AuditPage{}
+		err := page.fetch(filterAuditEntries(db, filter), Order{Column: CreatedAtColumn, Direction: Asc}, p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range page.Entries {
+			err = export(entry)
+			if err != nil {
+				return err
+			}
+		}
+		if !page.PageInfo.HasNextPage {
+			return nil
+		}
+		p.After = &page.Cursors[len(page.Cursors)-1]
+	}
+}
@@ -1,19 +1,19 @@
 package db
 
 import (
-	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	uuid "github.com/google/uuid"
 	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/events"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"net/http"
-	"sync"
+	"strings"
 	"time"
 )
 
@@ -24,22 +24,41 @@ const (
 	Scheduled Status = "SCHEDULED"
 	Checking  Status = "CHECKING"
 	Down      Status = "DOWN"
+	// Maintenance is set while the check is paused by a maintenance window
+	Maintenance Status = "MAINTENANCE"
+	// Paused checks are not scheduled until they are resumed
+	Paused Status = "PAUSED"
 )
 
 type Check struct {
 	ID          string `gorm:"primaryKey"`
-	Identifier  string `gorm:"uniqueIndex"`
+	WorkspaceID string `gorm:"index:idx_check_workspace_identifier,unique,priority:1;size:191;not null;default:'default'"`
+	Identifier  string `gorm:"index:idx_check_workspace_identifier,unique,priority:2;size:191"`
 	Type        check.Type
 	Data        datatypes.JSON
-	Frecuency   string
+	Frequency   string
+	Description string
+	Owner       string
+	Labels      []CheckLabel
 	Status      Status
+	Paused      bool `gorm:"not null;default:false"`
 	ErrorMsg    string
 	Message     string
 	LatestCheck time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
-	Executions  []CheckExecution
+	// Latency of the latest execution
+	Latency time.Duration
+	// CertExpiresAt is set by tls checks with the expiry of the peer certificate
+	CertExpiresAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Executions    []CheckExecution
+}
+
+func (c *Check) BeforeCreate(tx *gorm.DB) error {
+	return checkQuota(tx, c.WorkspaceID, &Check{}, "checks", func(w Workspace) int {
+		return w.MaxChecks
+	})
 }
 
 func (c Check) GetIcmpData() (*IcmpCheckData, error) {
@@ -94,10 +113,202 @@ func (c Check) GetTlsData() (*TlsCheckData, error) {
 	return tlsCheckData, nil
 }
 
+// GetData returns the data of the check decoded depending on its type
+func (c Check) GetData() (interface{}, error) {
+	switch c.Type {
+	case check.HttpType:
+		data, err := c.GetHttpData()
+		if err != nil {
+			return nil, err
+		}
+		return *data, nil
+	case check.TcpType:
+		data, err := c.GetTcpData()
+		if err != nil {
+			return nil, err
+		}
+		return *data, nil
+	case check.TlsType:
+		data, err := c.GetTlsData()
+		if err != nil {
+			return nil, err
+		}
+		return *data, nil
+	case check.IcmpType:
+		data, err := c.GetIcmpData()
+		if err != nil {
+			return nil, err
+		}
+		return *data, nil
+	}
+	return nil, nil
+}
+
+// HealthCheck returns the probe executing the check, nil when the type is not supported
+func (c Check) HealthCheck() (check.Check, error) {
+	switch c.Type {
+	case check.HttpType:
+		httpCheckData, err := c.GetHttpData()
+		if err != nil {
+			return nil, err
+		}
+		expectedStatusCode := httpCheckData.StatusCode
+		if expectedStatusCode == 0 {
+			expectedStatusCode = http.StatusOK
+		}
+		return check.NewHttpCheck(httpCheckData.Url, &expectedStatusCode), nil
+	case check.TlsType:
+		tlsCheckData, err := c.GetTlsData()
+		if err != nil {
+			return nil, err
+		}
+		rootCAs, _ := x509.SystemCertPool()
+		if rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if tlsCheckData.RootCAs != "" {
+			ok := rootCAs.AppendCertsFromPEM([]byte(tlsCheckData.RootCAs))
+			if !ok {
+				log.Warnf("Root CAs not valid: %v", ok)
+			}
+		}
+		tlsConfig := &tls.Config{
+			VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+				return nil
+			},
+			VerifyConnection: func(state tls.ConnectionState) error {
+				return nil
+			},
+			RootCAs: rootCAs,
+		}
+		return check.NewTlsCheck(tlsCheckData.Address, tlsConfig), nil
+	case check.IcmpType:
+		icmpCheckData, err := c.GetIcmpData()
+		if err != nil {
+			return nil, err
+		}
+		return check.NewIcmpCheck(icmpCheckData.Address), nil
+	case check.TcpType:
+		tcpCheckData, err := c.GetTcpData()
+		if err != nil {
+			return nil, err
+		}
+		return check.NewTcpCheck(tcpCheckData.Address), nil
+	}
+	return nil, nil
+}
+
 func (Check) TableName() string {
 	return "check"
 }
 
+// deletedSuffix is appended to the identifier of the deleted checks, followed by a uuid
+const deletedSuffix = "-deleted-"
+
+// DeleteCheck soft deletes the check, freeing its identifier so that it can be reused
+func DeleteCheck(db *gorm.DB, chk *Check) error {
+	chk.Identifier = fmt.Sprintf("%s%s%s", chk.Identifier, deletedSuffix, uuid.New().String())
+	result := db.Omit("Labels").Save(chk)
+	if result.Error != nil {
+		return result.Error
+	}
+	return db.Delete(chk).Error
+}
+
+// OriginalIdentifier returns the identifier of the check before it was deleted
+func (c Check) OriginalIdentifier() string {
+	i := strings.LastIndex(c.Identifier, deletedSuffix)
+	if i < 0 {
+		return c.Identifier
+	}
+	_, err := uuid.Parse(c.Identifier[i+len(deletedSuffix):])
+	if err != nil {
+		return c.Identifier
+	}
+	return c.Identifier[:i]
+}
+
+// GetDeletedChecks returns the deleted checks that haven't been purged yet, latest first
+func GetDeletedChecks(db *gorm.DB, teams []string) ([]Check, error) {
+	var checks []Check
+	query := db.Unscoped().Preload("Labels").Where("deleted_at IS NOT NULL")
+	if teams != nil {
+		query = query.Where("owner IN ?", teams)
+	}
+	result := query.Order("deleted_at desc").Find(&checks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return checks, nil
+}
+
+// RestoreCheck undeletes the check, it gets back its original identifier unless another
+// check took it in the meantime
+func RestoreCheck(db *gorm.DB, chk *Check) error {
+	if !chk.DeletedAt.Valid {
+		return errors.Errorf("Check %s is not deleted", chk.ID)
+	}
+	err := checkQuota(db, chk.WorkspaceID, &Check{}, "checks", func(w Workspace) int {
+		return w.MaxChecks
+	})
+	if err != nil {
+		return err
+	}
+	identifier := chk.OriginalIdentifier()
+	var taken int64
+	result := db.Model(&Check{}).
+		Where("workspace_id = ? AND identifier = ?", workspaceOrDefault(chk.WorkspaceID), identifier).
+		Count(&taken)
+	if result.Error != nil {
+		return result.Error
+	}
+	if taken == 0 {
+		chk.Identifier = identifier
+	}
+	chk.DeletedAt = gorm.DeletedAt{}
+	result = db.Unscoped().Model(chk).Select("identifier", "deleted_at").Updates(chk)
+	return result.Error
+}
+
+// PurgeCheck permanently deletes the deleted check along with its history, the status page
+// components publishing it and its memberships of maintenance windows
+func PurgeCheck(db *gorm.DB, chk *Check) error {
+	if !chk.DeletedAt.Valid {
+		return errors.Errorf("Check %s must be deleted before it is purged", chk.ID)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		components := tx.Model(&StatusPageComponent{}).Select("id").Where("check_id = ?", chk.ID)
+		deletions := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&CheckExecution{}, "check_id = ?", []interface{}{chk.ID}},
+			{&CheckExecutionRollup{}, "check_id = ?", []interface{}{chk.ID}},
+			{&CheckLabel{}, "check_id = ?", []interface{}{chk.ID}},
+		}
+		for _, deletion := range deletions {
+			result := tx.Where(deletion.query, deletion.args...).Delete(deletion.model)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+		result := tx.Exec("DELETE FROM incident_component WHERE status_page_component_id IN (?)", components)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("check_id = ?", chk.ID).Delete(&StatusPageComponent{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Exec("DELETE FROM maintenance_window_check WHERE check_id = ?", chk.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Unscoped().Delete(chk).Error
+	})
+}
+
 type CheckExecution struct {
 	ID        string `gorm:"primaryKey"`
 	Status    Status
@@ -105,16 +316,57 @@ type CheckExecution struct {
 	UpdatedAt time.Time
 	ErrorMsg  string
 	Message   string
-	Stats     datatypes.JSON
-	CheckID   string
+	Latency   time.Duration
+	// Maintenance executions are excluded from the uptime
+	Maintenance bool `gorm:"not null;default:false"`
+	Stats       datatypes.JSON
+	CheckID     string `gorm:"index"`
 }
 
 func (CheckExecution) TableName() string {
 	return "check_execution"
 }
 
+// GetStatistics decodes the statistics stored by the probe of the given check type
+func (e CheckExecution) GetStatistics(checkType check.Type) (check.Statistics, error) {
+	if len(e.Stats) == 0 {
+		return nil, nil
+	}
+	marshalJSON, err := e.Stats.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var statistics check.Statistics
+	switch checkType {
+	case check.HttpType:
+		httpStatistics := check.HttpStatistics{}
+		err = json.Unmarshal(marshalJSON, &httpStatistics)
+		statistics = httpStatistics
+	case check.TcpType:
+		tcpStatistics := check.TcpStatistics{}
+		err = json.Unmarshal(marshalJSON, &tcpStatistics)
+		statistics = tcpStatistics
+	case check.TlsType:
+		tlsStatistics := check.TlsStatistics{}
+		err = json.Unmarshal(marshalJSON, &tlsStatistics)
+		statistics = tlsStatistics
+	case check.IcmpType:
+		icmpStatistics := check.IcmpStatistics{}
+		err = json.Unmarshal(marshalJSON, &icmpStatistics)
+		statistics = icmpStatistics
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return statistics, nil
+}
+
 type HttpCheckData struct {
 	Url string `json:"url"`
+	// StatusCode expected from the target, 200 when zero
+	StatusCode int `json:"statusCode,omitempty"`
 }
 type TlsCheckData struct {
 	Address string `json:"address"`
@@ -127,150 +379,14 @@ type IcmpCheckData struct {
 	Address string `json:"address"`
 }
 
-func notifyEndpointDown(chk Check) {
-	slackWebhook := viper.GetString("slack.webhook")
-	if slackWebhook != "" {
-		data := map[string]string{}
-		data["text"] = fmt.Sprintf("Endpoint down: %s\n%s", chk.Identifier, chk.ErrorMsg)
-		dataBytes, err := json.Marshal(data)
-		if err != nil {
-			log.Warnf("Error sending notification to slack:%v", err)
-			return
-		}
-		_, err = http.Post(slackWebhook, "application/json", bytes.NewBuffer(dataBytes))
-		if err != nil {
-			log.Warnf("Error sending notification to slack:%v", err)
-			return
-		}
-	}
-}
-
-func CheckAll(db *gorm.DB) {
-	start := time.Now()
-	err := checkAll(db)
-	end := time.Now()
-	took := end.Sub(start)
-	if err != nil {
-		log.Warnf("Failed checking the endpoints: %v", err)
-	} else {
-		log.Infof("Check executed successfully in %s", took)
-	}
-	checkUrl := viper.GetString("check.url")
-	if checkUrl != "" {
-		_, err := http.Get(checkUrl)
-		if err != nil {
-			log.Errorf("Failed invoking url: %s", checkUrl)
-		}
-	}
-}
-func checkAll(db *gorm.DB) error {
-	var checks []Check
-	result := db.Find(&checks)
-	if result.Error != nil {
-		return result.Error
-	}
-	var wg sync.WaitGroup
-	wg.Add(len(checks))
-	for _, chk := range checks {
-		chk.Status = Checking
-		db.Save(chk)
-		var healthChk check.Check
-		switch chk.Type {
-		case check.HttpType:
-			httpCheckData, err := chk.GetHttpData()
-			if err != nil {
-				log.Errorf("Failed to get http data:%v", err)
-				continue
-			}
-			url := httpCheckData.Url
-			expectedStatusCode := 200
-			healthChk = check.NewHttpCheck(url, &expectedStatusCode)
-		case check.TlsType:
-			tlsCheckData, err := chk.GetTlsData()
-			if err != nil {
-				log.Errorf("Failed to get http data:%v", err)
-				continue
-			}
-			address := tlsCheckData.Address
-			rootCAs, _ := x509.SystemCertPool()
-			if rootCAs == nil {
-				rootCAs = x509.NewCertPool()
-			}
-			if tlsCheckData.RootCAs != "" {
-				ok := rootCAs.AppendCertsFromPEM([]byte(tlsCheckData.RootCAs))
-				if !ok {
-					log.Warnf("Root CAs not valid: %v", ok)
-				}
-			}
-			tlsConfig := &tls.Config{
-				VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-					return nil
-				},
-				VerifyConnection: func(state tls.ConnectionState) error {
-					return nil
-				},
-				RootCAs: rootCAs,
-			}
-			healthChk = check.NewTlsCheck(address, tlsConfig)
-		case check.IcmpType:
-			icmpCheckData, err := chk.GetIcmpData()
-			if err != nil {
-				log.Errorf("Failed to get http data:%v", err)
-				continue
-			}
-			address := icmpCheckData.Address
-			healthChk = check.NewIcmpCheck(address)
-		case check.TcpType:
-			tlsCheckData, err := chk.GetTcpData()
-			if err != nil {
-				log.Errorf("Failed to get http data:%v", err)
-				continue
-			}
-			address := tlsCheckData.Address
-			healthChk = check.NewTcpCheck(address)
-		}
-		if healthChk != nil {
-			result := healthChk.Check()
-			var status Status
-			if result.Error != nil {
-				status = Down
-			} else {
-				status = Up
-			}
-			statsBytes, err := json.Marshal(result.Statistics)
-			if err != nil {
-				log.Errorf("Health check failed id=%s type=%s err=%v", chk.ID, chk.Type, err)
-				continue
-			}
-			chkExecution := CheckExecution{
-				ID:      uuid.New().String(),
-				Status:  status,
-				Stats:   statsBytes,
-				CheckID: chk.ID,
-			}
-			resultDb := db.Create(&chkExecution)
-			if resultDb.Error != nil {
-				log.Errorf("Health check failed id=%s type=%s err=%v", chk.ID, chk.Type, resultDb.Error)
-			}
-			chk.Status = status
-			if result.Error != nil {
-				chk.ErrorMsg = result.Error.Error()
-			}
-			chk.Message = result.Message
-			chk.LatestCheck = time.Now()
-			resultDb = db.Save(&chk)
-			if resultDb.Error != nil {
-				log.Errorf("Failed to save check id=%s type=%s err=%v", chk.ID, chk.Type, resultDb.Error)
-			}
-			if chk.Status == Down {
-				chkToNotify := chk
-				go func() {
-					notifyEndpointDown(chkToNotify)
-				}()
-			}
-		} else {
-			log.Warnf("No healthcheck found for id=%s type=%s", chk.ID, string(chk.Type))
-		}
+// PublishStatusChange notifies the subscribers when the status of the check has changed
+func PublishStatusChange(bus *events.Bus, chk Check, previousStatus Status) {
+	if chk.Status == previousStatus {
+		return
 	}
-	return nil
+	bus.Publish(events.Event{
+		Type:    events.CheckStatusChanged,
+		ID:      chk.ID,
+		Payload: chk,
+	})
 }
@@ -0,0 +1,818 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/internal/dbtest"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestDatabase migrates a new SQLite database, every storage test runs against it
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, SQLite, Migrate, WorkspacePlugin{})
+}
+
+// openEmptyDatabase returns a new SQLite database without any table
+func openEmptyDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, SQLite, nil, WorkspacePlugin{})
+}
+
+func createCheck(t *testing.T, db *gorm.DB, identifier string, labels Labels) *Check {
+	t.Helper()
+	chk := &Check{
+		ID:         uuid.New().String(),
+		Identifier: identifier,
+		Type:       "http",
+		Frequency:  "@every 1m",
+		Status:     Up,
+	}
+	err := db.Omit("Labels").Create(chk).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SetCheckLabels(db, chk.ID, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chk
+}
+
+func createExecution(t *testing.T, db *gorm.DB, checkID string, createdAt time.Time, status Status, latency time.Duration) {
+	t.Helper()
+	err := db.Create(&CheckExecution{
+		ID:        uuid.New().String(),
+		CheckID:   checkID,
+		Status:    status,
+		Latency:   latency,
+		CreatedAt: createdAt,
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openTestDatabase(t)
+	err := Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate() on a migrated database = %v", err)
+	}
+	workspaces, err := GetWorkspaces(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workspaces) != 1 || workspaces[0].ID != DefaultWorkspaceID {
+		t.Errorf("GetWorkspaces() = %v, want the default workspace", workspaces)
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db := openTestDatabase(t)
+	migrations, err := Migrations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverted, err := MigrateDown(db, len(migrations))
+	if err != nil {
+		t.Fatalf("MigrateDown() = %v", err)
+	}
+	if len(reverted) != len(migrations) || reverted[0].Version != migrations[len(migrations)-1].Version {
+		t.Errorf("MigrateDown() reverted %v, want every migration from the latest", reverted)
+	}
+	if db.Migrator().HasTable(&Check{}) {
+		t.Error("check table exists after reverting every migration")
+	}
+	err = CheckSchema(db)
+	if err == nil || !strings.Contains(err.Error(), "not up to date") {
+		t.Errorf("CheckSchema() on a reverted database = %v, want pending migrations", err)
+	}
+	applied, err := MigrateUp(db, 1)
+	if err != nil {
+		t.Fatalf("MigrateUp(1) = %v", err)
+	}
+	if len(applied) != 1 || !db.Migrator().HasColumn(&Check{}, "frecuency") {
+		t.Errorf("MigrateUp(1) applied %v, want the initial schema only", applied)
+	}
+	err = Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	createCheck(t, db, "api", nil)
+	err = CheckSchema(db)
+	if err != nil {
+		t.Errorf("CheckSchema() on a migrated database = %v", err)
+	}
+}
+
+// baselineSchema is the schema created by AutoMigrate in the first release, with the checks and
+// their executions only
+const baselineSchema = `
+CREATE TABLE "check" ("id" text,"identifier" text,"type" text,"data" JSON,"frecuency" text,"status" text,"error_msg" text,"message" text,"latest_check" datetime,"created_at" datetime,"updated_at" datetime,"deleted_at" datetime,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX "idx_check_identifier" ON "check"("identifier");
+CREATE INDEX "idx_check_deleted_at" ON "check"("deleted_at");
+CREATE TABLE "check_execution" ("id" text,"status" text,"created_at" datetime,"updated_at" datetime,"error_msg" text,"message" text,"stats" JSON,"check_id" text,PRIMARY KEY ("id"),CONSTRAINT "fk_check_executions" FOREIGN KEY ("check_id") REFERENCES "check"("id"));
+INSERT INTO "check" ("id","identifier","type","data","frecuency","status","created_at","updated_at") VALUES ('legacy','api','http','{"url":"https://example.com"}','@every 1m','UP','2021-01-01 00:00:00','2021-01-01 00:00:00');
+INSERT INTO "check_execution" ("id","status","created_at","updated_at","check_id") VALUES ('legacy-execution','UP','2021-01-01 00:01:00','2021-01-01 00:01:00','legacy');
+`
+
+func TestMigrateCompletesBaselineSchema(t *testing.T) {
+	db := openEmptyDatabase(t)
+	for _, statement := range splitStatements(baselineSchema) {
+		err := db.Exec(statement).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	err = CheckSchema(db)
+	if err != nil {
+		t.Errorf("CheckSchema() = %v", err)
+	}
+	for _, table := range []string{"workspace", "check_label", "check_execution_rollup", "status_page", "incident", "maintenance_window", "notification_channel", "api_token", "audit_entry"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s missing after migrating the baseline schema", table)
+		}
+	}
+	_, err = GetWorkspace(db, DefaultWorkspaceID)
+	if err != nil {
+		t.Errorf("GetWorkspace(default) = %v", err)
+	}
+	defaultWorkspace := db.WithContext(WithWorkspace(context.Background(), DefaultWorkspaceID))
+	chk := Check{}
+	err = defaultWorkspace.Preload("Labels").First(&chk, "id = ?", "legacy").Error
+	if err != nil {
+		t.Fatalf("reading the check of the baseline = %v", err)
+	}
+	if chk.Identifier != "api" || chk.Frequency != "@every 1m" || chk.WorkspaceID != DefaultWorkspaceID || chk.Paused {
+		t.Errorf("check of the baseline = %+v", chk)
+	}
+	createExecution(t, db, chk.ID, time.Date(2021, 1, 1, 0, 2, 0, 0, time.UTC), Down, time.Second)
+	// the identifiers are unique per workspace, not across every workspace like in the baseline
+	err = SaveWorkspace(db, &Workspace{ID: "acme", Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	createCheck(t, db.WithContext(WithWorkspace(context.Background(), "acme")), "api", nil)
+}
+
+func TestCheckSchemaRefusesNewerSchema(t *testing.T) {
+	db := openTestDatabase(t)
+	err := db.Create(&SchemaMigration{Version: 1000, Name: "future", AppliedAt: time.Now()}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CheckSchema(db)
+	if err == nil || !strings.Contains(err.Error(), "newer release") {
+		t.Errorf("CheckSchema() = %v, want an error about the newer release", err)
+	}
+	_, err = MigrateDown(db, 1)
+	if err == nil {
+		t.Error("MigrateDown() reverted a migration unknown to this release")
+	}
+}
+
+func TestWorkspaceIsolation(t *testing.T) {
+	db := openTestDatabase(t)
+	err := SaveWorkspace(db, &Workspace{ID: "acme", Name: "Acme", MaxChecks: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := db.WithContext(WithWorkspace(context.Background(), "acme"))
+	defaultWorkspace := db.WithContext(WithWorkspace(context.Background(), DefaultWorkspaceID))
+	chk := createCheck(t, acme, "api", nil)
+	if chk.WorkspaceID != "acme" {
+		t.Errorf("WorkspaceID = %s, want acme", chk.WorkspaceID)
+	}
+	// identifiers are unique per workspace
+	createCheck(t, defaultWorkspace, "api", nil)
+
+	var checks []Check
+	err = defaultWorkspace.Find(&checks).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].WorkspaceID != DefaultWorkspaceID {
+		t.Errorf("checks of the default workspace = %v", checks)
+	}
+	result := defaultWorkspace.Model(&Check{}).Where("id = ?", chk.ID).Update("description", "updated")
+	if result.Error != nil || result.RowsAffected != 0 {
+		t.Errorf("updating a check of another workspace affected %d rows, err=%v", result.RowsAffected, result.Error)
+	}
+	err = acme.Omit("Labels").Create(&Check{ID: uuid.New().String(), Identifier: "web"}).Error
+	if err == nil {
+		t.Errorf("creating a check over the quota succeeded")
+	}
+	usage, err := GetWorkspaceUsage(db, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Checks != 1 {
+		t.Errorf("usage.Checks = %d, want 1", usage.Checks)
+	}
+}
+
+func TestStatusPageDomainIsUnique(t *testing.T) {
+	db := openTestDatabase(t)
+	err := SaveWorkspace(db, &Workspace{ID: "acme", Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := db.WithContext(WithWorkspace(context.Background(), "acme"))
+	defaultWorkspace := db.WithContext(WithWorkspace(context.Background(), DefaultWorkspaceID))
+	statusPage := &StatusPage{ID: uuid.New().String(), Slug: "status", Domain: "status.example.com"}
+	err = defaultWorkspace.Create(statusPage).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim := &StatusPage{ID: uuid.New().String(), Slug: "status", Domain: "status.example.com"}
+	taken, err := DomainTaken(acme, claim)
+	if err != nil || !taken {
+		t.Errorf("DomainTaken() by the page of another workspace = %v, %v, want true", taken, err)
+	}
+	if err := acme.Create(claim).Error; err == nil {
+		t.Error("creating a status page with the domain of another workspace succeeded")
+	}
+	for _, slug := range []string{"first", "second"} {
+		if err := acme.Create(&StatusPage{ID: uuid.New().String(), Slug: slug}).Error; err != nil {
+			t.Errorf("creating status page %s without domain = %v", slug, err)
+		}
+	}
+	err = DeleteStatusPage(defaultWorkspace, statusPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := acme.Create(claim).Error; err != nil {
+		t.Errorf("creating a status page with the domain of a deleted page = %v", err)
+	}
+}
+
+func TestListChecks(t *testing.T) {
+	db := openTestDatabase(t)
+	for _, identifier := range []string{"a", "b", "c", "d", "e"} {
+		labels := Labels{"env": "prod"}
+		if identifier == "c" {
+			labels = Labels{"env": "staging"}
+		}
+		createCheck(t, db, identifier, labels)
+	}
+	first := 2
+	page, err := ListChecks(db, CheckFilter{Labels: Labels{"env": "prod"}}, Order{}, Pagination{First: &first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 4 || !page.PageInfo.HasNextPage {
+		t.Fatalf("TotalCount = %d, HasNextPage = %v", page.TotalCount, page.PageInfo.HasNextPage)
+	}
+	var identifiers []string
+	for page != nil {
+		for _, chk := range page.Checks {
+			identifiers = append(identifiers, chk.Identifier)
+			if chk.GetLabels()["env"] != "prod" {
+				t.Errorf("check %s has labels %v", chk.Identifier, chk.GetLabels())
+			}
+		}
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		after := page.Cursors[len(page.Cursors)-1]
+		page, err = ListChecks(db, CheckFilter{Labels: Labels{"env": "prod"}}, Order{}, Pagination{First: &first, After: &after})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := len(identifiers), 4; got != want || identifiers[0] != "a" || identifiers[3] != "e" {
+		t.Errorf("identifiers = %v", identifiers)
+	}
+}
+
+func TestListExecutionsByTime(t *testing.T) {
+	db := openTestDatabase(t)
+	chk := createCheck(t, db, "api", nil)
+	start := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	// times with other offsets are stored in UTC so that they are ordered
+	berlin := time.FixedZone("CEST", 2*60*60)
+	for i := 0; i < 6; i++ {
+		createExecution(t, db, chk.ID, start.Add(time.Duration(i)*time.Minute).In(berlin), Up, time.Millisecond)
+	}
+	from := start.Add(2 * time.Minute)
+	last := 2
+	page, err := ListExecutions(db, ExecutionFilter{CheckID: chk.ID, From: &from}, Order{Column: CreatedAtColumn, Direction: Desc}, Pagination{Last: &last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 4 || len(page.Executions) != 2 {
+		t.Fatalf("TotalCount = %d, executions = %d", page.TotalCount, len(page.Executions))
+	}
+	if got := page.Executions[0].CreatedAt; !got.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("first execution of the last page at %s", got)
+	}
+}
+
+func TestDeleteRestorePurgeCheck(t *testing.T) {
+	db := openTestDatabase(t)
+	chk := createCheck(t, db, "api", Labels{"env": "prod"})
+	createExecution(t, db, chk.ID, time.Now(), Up, time.Millisecond)
+	err := DeleteCheck(db, chk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := GetDeletedChecks(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].OriginalIdentifier() != "api" {
+		t.Fatalf("GetDeletedChecks() = %v", deleted)
+	}
+	err = RestoreCheck(db, &deleted[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := &Check{}
+	err = db.First(restored, "id = ?", chk.ID).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Identifier != "api" {
+		t.Errorf("restored identifier = %s", restored.Identifier)
+	}
+
+	err = DeleteCheck(db, restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the identifier is taken meanwhile, the check keeps the one of its deletion
+	createCheck(t, db, "api", nil)
+	deleted, err = GetDeletedChecks(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RestoreCheck(db, &deleted[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted[0].Identifier == "api" || deleted[0].OriginalIdentifier() != "api" {
+		t.Errorf("restored identifier = %s", deleted[0].Identifier)
+	}
+	err = PurgeCheck(db, &deleted[0])
+	if err == nil {
+		t.Errorf("purging a check that isn't deleted succeeded")
+	}
+	err = DeleteCheck(db, &deleted[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, err = GetDeletedChecks(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PurgeCheck(db, &deleted[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []interface{}{&CheckExecution{}, &CheckLabel{}} {
+		var count int64
+		err = db.Model(model).Where("check_id = ?", chk.ID).Count(&count).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%d rows of %T left after purging", count, model)
+		}
+	}
+	var count int64
+	err = db.Unscoped().Model(&Check{}).Where("id = ?", chk.ID).Count(&count).Error
+	if err != nil || count != 0 {
+		t.Errorf("purged check still stored, count=%d err=%v", count, err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := WithActor(WithWorkspace(context.Background(), DefaultWorkspaceID), Actor{ID: "1", Name: "alice", Method: "token"})
+	tx := db.WithContext(ctx)
+	channel := NotificationChannel{ID: "channel", Name: "ops", Url: "https://hooks.example.com/secret"}
+	for _, operation := range []AuditOperation{CreateOperation, UpdateOperation, DeleteOperation} {
+		err := RecordAudit(tx, operation, NotificationChannelResource, channel.ID, nil, channel)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	page, err := ListAuditEntries(tx, AuditFilter{ResourceType: NotificationChannelResource}, Pagination{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 3 || page.Entries[0].Operation != DeleteOperation {
+		t.Fatalf("TotalCount = %d, latest entry %v", page.TotalCount, page.Entries[0].Operation)
+	}
+	entry := page.Entries[0]
+	if entry.Actor != "alice" || entry.WorkspaceID != DefaultWorkspaceID {
+		t.Errorf("entry recorded for %s in %s", entry.Actor, entry.WorkspaceID)
+	}
+	if string(entry.After) == "" || strings.Contains(string(entry.After), "secret") {
+		t.Errorf("after = %s, want the url redacted", entry.After)
+	}
+	err = tx.Model(&entry).Update("actor", "mallory").Error
+	if err != ErrAuditLogAppendOnly {
+		t.Errorf("updating an entry = %v, want %v", err, ErrAuditLogAppendOnly)
+	}
+	err = tx.Delete(&entry).Error
+	if err != ErrAuditLogAppendOnly {
+		t.Errorf("deleting an entry = %v, want %v", err, ErrAuditLogAppendOnly)
+	}
+	var operations []AuditOperation
+	err = ExportAuditEntries(tx, AuditFilter{}, func(entry AuditEntry) error {
+		operations = append(operations, entry.Operation)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(operations) != 3 || operations[0] != CreateOperation {
+		t.Errorf("exported operations = %v", operations)
+	}
+}
+
+func TestApiTokens(t *testing.T) {
+	db := openTestDatabase(t)
+	token, err := CreateApiToken(db, &ApiToken{Name: "ci", Role: "EDITOR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken, err := FindApiToken(db, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiToken == nil || apiToken.Name != "ci" || apiToken.WorkspaceID != DefaultWorkspaceID {
+		t.Fatalf("FindApiToken() = %v", apiToken)
+	}
+	expiresAt := time.Now().Add(-time.Minute)
+	expired, err := CreateApiToken(db, &ApiToken{Name: "old", Role: "VIEWER", ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken, err = FindApiToken(db, expired)
+	if err != nil || apiToken != nil {
+		t.Errorf("FindApiToken() of an expired token = %v, %v", apiToken, err)
+	}
+	err = DeleteApiToken(db, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken, err = FindApiToken(db, token)
+	if err != nil || apiToken != nil {
+		t.Errorf("FindApiToken() of a revoked token = %v, %v", apiToken, err)
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	db := openTestDatabase(t)
+	labeled := createCheck(t, db, "labeled", Labels{"team": "payments"})
+	listed := createCheck(t, db, "listed", nil)
+	createCheck(t, db, "other", nil)
+	now := time.Now()
+	// the window is written with an offset and compared with a local time
+	startsAt := now.Add(-time.Hour).In(time.FixedZone("UTC-8", -8*60*60))
+	endsAt := now.Add(time.Hour).In(time.FixedZone("UTC+9", 9*60*60))
+	window := &MaintenanceWindow{
+		ID:       uuid.New().String(),
+		Title:    "Upgrade",
+		Mode:     PauseMode,
+		StartsAt: &startsAt,
+		EndsAt:   &endsAt,
+		Checks:   []Check{*listed},
+	}
+	err := window.SetLabels(Labels{"team": "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Omit("Checks.*").Create(window).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := GetActiveMaintenanceWindows(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 || active[labeled.ID].ID != window.ID || active[listed.ID].ID != window.ID {
+		t.Errorf("GetActiveMaintenanceWindows() = %v", active)
+	}
+	active, err = GetActiveMaintenanceWindows(db, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 0 {
+		t.Errorf("GetActiveMaintenanceWindows() after the window = %v", active)
+	}
+	windows, err := GetCheckMaintenanceWindows(db, []string{labeled.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 1 {
+		t.Errorf("GetCheckMaintenanceWindows() = %v", windows)
+	}
+}
+
+func TestStatusPageIncidents(t *testing.T) {
+	db := openTestDatabase(t)
+	chk := createCheck(t, db, "api", Labels{"region": "eu"})
+	page := &StatusPage{
+		ID:           uuid.New().String(),
+		Slug:         "acme",
+		Title:        "Acme",
+		GroupByLabel: "region",
+		Components: []StatusPageComponent{
+			{ID: uuid.New().String(), Name: "API", CheckID: chk.ID},
+		},
+	}
+	err := db.Omit("Components.Check").Create(page).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := GetStatusPageByDomain(db, "status.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != page.ID || found.GroupedComponents()[0].Group(found.GroupByLabel) != "eu" {
+		t.Errorf("GetStatusPageByDomain() = %v", found)
+	}
+	resolvedAt := time.Now().Add(-48 * time.Hour)
+	incidents := []Incident{
+		{ID: uuid.New().String(), Title: "Outage", Status: Investigating, Impact: MajorImpact},
+		{ID: uuid.New().String(), Title: "Old outage", Status: Resolved, Impact: MinorImpact, ResolvedAt: &resolvedAt},
+	}
+	for i := range incidents {
+		incidents[i].Components = page.Components
+		err = db.Omit("Components.*").Create(&incidents[i]).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := &StatusPage{
+		ID:    uuid.New().String(),
+		Slug:  "other",
+		Title: "Other",
+		Components: []StatusPageComponent{
+			{ID: uuid.New().String(), Name: "Other API", CheckID: chk.ID},
+		},
+	}
+	err = db.Omit("Components.Check").Create(other).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SaveWorkspace(db, &Workspace{ID: "acme", Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// announcements are shown on every page of their workspace
+	for _, incident := range []Incident{
+		{ID: uuid.New().String(), Title: "Other outage", Status: Investigating, Components: other.Components},
+		{ID: uuid.New().String(), Title: "Maintenance", Status: Investigating, Impact: NoImpact, CreatedAt: time.Now().Add(-time.Hour)},
+		{ID: uuid.New().String(), WorkspaceID: "acme", Title: "Acme maintenance", Status: Investigating},
+	} {
+		err = db.Omit("Components.*").Create(&incident).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	open, err := GetStatusPageIncidents(db, page, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 2 || open[0].Title != "Outage" || open[1].Title != "Maintenance" {
+		t.Errorf("GetStatusPageIncidents() = %v, want the outage and the announcement", open)
+	}
+}
+
+func TestMetricsAndCompaction(t *testing.T) {
+	db := openTestDatabase(t)
+	chk := createCheck(t, db, "api", nil)
+	now := time.Now().Truncate(time.Hour)
+	hour := now.Add(-72 * time.Hour)
+	for i := 1; i <= 10; i++ {
+		status := Up
+		if i == 10 {
+			status = Down
+		}
+		createExecution(t, db, chk.ID, hour.Add(time.Duration(i)*time.Minute), status, time.Duration(i)*time.Millisecond)
+	}
+	buckets, err := getRawMetrics(db, chk.ID, hour, hour.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 {
+		t.Fatalf("getRawMetrics() = %v", buckets)
+	}
+	b := buckets[0]
+	if !b.Time.Equal(hour) || b.Count != 10 || b.Up != 9 || b.Down != 1 {
+		t.Errorf("bucket at %s with count=%d up=%d down=%d", b.Time, b.Count, b.Up, b.Down)
+	}
+	if b.P50 != 5*time.Millisecond || b.P95 != 10*time.Millisecond || b.MaxLatency != 10*time.Millisecond {
+		t.Errorf("p50=%s p95=%s max=%s", b.P50, b.P95, b.MaxLatency)
+	}
+
+	err = Compact(db, RetentionPolicy{Raw: 48 * time.Hour, Hourly: defaultHourlyRetention}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var executions int64
+	err = db.Model(&CheckExecution{}).Where("check_id = ?", chk.ID).Count(&executions).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if executions != 0 {
+		t.Errorf("%d executions left after compaction", executions)
+	}
+	rollups, err := getRollupMetrics(db, chk.ID, hour, now, now.Add(-defaultHourlyRetention))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollups) != 1 || rollups[0].Count != 10 || rollups[0].P50 != 5*time.Millisecond {
+		t.Errorf("getRollupMetrics() = %v", rollups)
+	}
+	// compacting again doesn't roll up the same buckets twice
+	err = Compact(db, RetentionPolicy{Raw: 48 * time.Hour, Hourly: defaultHourlyRetention}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+	db := openTestDatabase(t)
+	chk := createCheck(t, db, "api", nil)
+	// a time zone other than UTC, the buckets are aligned to the epoch whatever the zone
+	zone := time.FixedZone("UTC-3", -3*60*60)
+	from := time.Date(2021, 3, 1, 10, 0, 0, 0, zone)
+	for _, execution := range []struct {
+		at          time.Duration
+		status      Status
+		maintenance bool
+	}{
+		{0, Up, false},
+		{10 * time.Minute, Down, false},
+		{59*time.Minute + 59*time.Second, Down, true},
+		{60 * time.Minute, Up, false},
+		{3 * time.Hour, Up, false},
+	} {
+		err := db.Create(&CheckExecution{
+			ID:          uuid.New().String(),
+			CheckID:     chk.ID,
+			Status:      execution.status,
+			Maintenance: execution.maintenance,
+			Latency:     time.Millisecond,
+			CreatedAt:   from.Add(execution.at),
+		}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	buckets, err := getRawMetrics(db, chk.ID, from, from.Add(3*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range buckets {
+		got = append(got, fmt.Sprintf("%s count=%d up=%d down=%d", b.Time.UTC().Format("15:04"), b.Count, b.Up, b.Down))
+	}
+	want := []string{"13:00 count=3 up=1 down=1", "14:00 count=1 up=1 down=0"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("getRawMetrics() = %v, want %v", got, want)
+	}
+	for _, b := range buckets {
+		if !b.Time.Equal(BucketStart(b.Time, time.Hour)) {
+			t.Errorf("bucket %s isn't aligned like BucketStart", b.Time)
+		}
+	}
+}
+
+func TestMetricsPercentiles(t *testing.T) {
+	hour := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	// percentiles of the latencies 1ms to count ms with the nearest-rank method
+	tests := []struct {
+		count int
+		p50   time.Duration
+		p95   time.Duration
+		p99   time.Duration
+	}{
+		{1, 1, 1, 1},
+		{2, 1, 2, 2},
+		{10, 5, 10, 10},
+		{20, 10, 19, 20},
+		{100, 50, 95, 99},
+		{101, 51, 96, 100},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.count), func(t *testing.T) {
+			db := openTestDatabase(t)
+			chk := createCheck(t, db, "api", nil)
+			// inserted in the reverse order of their latency, the percentiles don't depend on the order
+			for i := tt.count; i >= 1; i-- {
+				createExecution(t, db, chk.ID, hour.Add(time.Duration(tt.count-i)*time.Second), Up, time.Duration(i)*time.Millisecond)
+			}
+			buckets, err := getRawMetrics(db, chk.ID, hour, hour.Add(time.Hour), time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(buckets) != 1 {
+				t.Fatalf("getRawMetrics() = %v, want a bucket", buckets)
+			}
+			b := buckets[0]
+			want := MetricsBucket{
+				Time:       hour,
+				Count:      int64(tt.count),
+				MinLatency: time.Millisecond,
+				AvgLatency: time.Duration(tt.count+1) * time.Millisecond / 2,
+				P50:        tt.p50 * time.Millisecond,
+				P95:        tt.p95 * time.Millisecond,
+				P99:        tt.p99 * time.Millisecond,
+				MaxLatency: time.Duration(tt.count) * time.Millisecond,
+				Up:         int64(tt.count),
+			}
+			b.Time = b.Time.UTC()
+			if b != want {
+				t.Errorf("getRawMetrics() = %+v, want %+v", b, want)
+			}
+		})
+	}
+}
+
+func TestMetricsIncludeTheRollupOfFrom(t *testing.T) {
+	viper.Set("retention.raw", "48h")
+	viper.Set("retention.hourly", "240h")
+	t.Cleanup(viper.Reset)
+	db := openTestDatabase(t)
+	chk := createCheck(t, db, "api", nil)
+	now := time.Now()
+	hour := BucketStart(now.Add(-72*time.Hour), time.Hour)
+	day := BucketStart(now.Add(-20*24*time.Hour), 24*time.Hour)
+	for i := 1; i <= 4; i++ {
+		createExecution(t, db, chk.ID, hour.Add(time.Duration(i)*time.Minute), Up, time.Duration(i)*time.Millisecond)
+		createExecution(t, db, chk.ID, day.Add(time.Duration(i)*time.Hour), Down, time.Duration(i)*time.Millisecond)
+	}
+	err := Compact(db, GetRetentionPolicy(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		from   time.Time
+		bucket time.Duration
+		time   time.Time
+		up     int64
+		down   int64
+	}{
+		{"hourly rollup", hour.Add(30 * time.Minute), time.Minute, hour, 4, 0},
+		{"daily rollup", day.Add(12 * time.Hour), time.Hour, day, 0, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, err := GetMetrics(db, chk.ID, tt.from, tt.from.Add(time.Hour), tt.bucket)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(buckets) != 1 {
+				t.Fatalf("GetMetrics() = %v, want the rollup containing from", buckets)
+			}
+			b := buckets[0]
+			if !b.Time.Equal(tt.time) || b.Count != 4 || b.Up != tt.up || b.Down != tt.down {
+				t.Errorf("GetMetrics() = %s count=%d up=%d down=%d, want %s count=4 up=%d down=%d", b.Time, b.Count, b.Up, b.Down, tt.time, tt.up, tt.down)
+			}
+		})
+	}
+}
+
+func TestMergeBuckets(t *testing.T) {
+	hour := time.Unix(0, 0).Add(1000 * time.Hour)
+	buckets := []MetricsBucket{
+		{Time: hour, Count: 1, MinLatency: 10, AvgLatency: 10, P50: 10, P95: 10, P99: 10, MaxLatency: 10, Up: 1},
+		{Time: hour.Add(time.Hour), Count: 3, MinLatency: 2, AvgLatency: 30, P50: 20, P95: 50, P99: 90, MaxLatency: 90, Up: 2, Down: 1},
+		{Time: hour.Add(24 * time.Hour), Count: 2, MinLatency: 5, AvgLatency: 5, P50: 5, P95: 5, P99: 5, MaxLatency: 5, Down: 2},
+	}
+	merged := mergeBuckets(buckets, 24*time.Hour)
+	day := BucketStart(hour, 24*time.Hour)
+	// percentiles of merged buckets are the average of the percentiles weighted by the count
+	want := []MetricsBucket{
+		{Time: day, Count: 4, MinLatency: 2, AvgLatency: 25, P50: 17, P95: 40, P99: 70, MaxLatency: 90, Up: 3, Down: 1},
+		{Time: day.Add(24 * time.Hour), Count: 2, MinLatency: 5, AvgLatency: 5, P50: 5, P95: 5, P99: 5, MaxLatency: 5, Down: 2},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("mergeBuckets() = %+v, want %+v", merged, want)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Errorf("mergeBuckets()[%d] = %+v, want %+v", i, merged[i], want[i])
+		}
+	}
+	if merged := mergeBuckets(buckets, time.Hour); len(merged) != 3 {
+		t.Errorf("mergeBuckets() of aligned buckets = %+v, want them unchanged", merged)
+	}
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type IncidentStatus string
+
+const (
+	Investigating IncidentStatus = "INVESTIGATING"
+	Identified    IncidentStatus = "IDENTIFIED"
+	Monitoring    IncidentStatus = "MONITORING"
+	Resolved      IncidentStatus = "RESOLVED"
+)
+
+type Impact string
+
+const (
+	NoImpact       Impact = "NONE"
+	MinorImpact    Impact = "MINOR"
+	MajorImpact    Impact = "MAJOR"
+	CriticalImpact Impact = "CRITICAL"
+)
+
+// Incident is authored by an operator, incidents without impact are used as announcements
+type Incident struct {
+	ID          string `gorm:"primaryKey"`
+	WorkspaceID string `gorm:"index;size:191;not null;default:'default'"`
+	Title       string
+	Status      IncidentStatus `gorm:"index"`
+	Impact      Impact
+	ResolvedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt        `gorm:"index"`
+	Components  []StatusPageComponent `gorm:"many2many:incident_component"`
+	Updates     []IncidentUpdate
+}
+
+func (Incident) TableName() string {
+	return "incident"
+}
+
+type IncidentUpdate struct {
+	ID         string `gorm:"primaryKey"`
+	IncidentID string `gorm:"index"`
+	Status     IncidentStatus
+	Body       string
+	CreatedAt  time.Time
+}
+
+func (IncidentUpdate) TableName() string {
+	return "incident_update"
+}
+
+func preloadIncident(db *gorm.DB) *gorm.DB {
+	return db.Preload("Components.Check.Labels").Preload("Updates", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at desc")
+	})
+}
+
+func GetIncident(db *gorm.DB, id string) (*Incident, error) {
+	incident := &Incident{}
+	result := preloadIncident(db).First(incident, "id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incident, nil
+}
+
+// GetStatusPageIncidents returns the incidents affecting any component of the status page
+// that are still open or have been resolved after since. The incidents without components,
+// such as announcements or outages of the whole site, are shown on every page of the workspace
+func GetStatusPageIncidents(db *gorm.DB, statusPage *StatusPage, since time.Time) ([]Incident, error) {
+	var incidents []Incident
+	result := preloadIncident(db).
+		Where("(id IN (?) OR (workspace_id = ? AND id NOT IN (?)))",
+			db.Table("incident_component").
+				Select("incident_component.incident_id").
+				Joins("JOIN status_page_component ON status_page_component.id = incident_component.status_page_component_id").
+				Where("status_page_component.status_page_id = ?", statusPage.ID),
+			workspaceOrDefault(statusPage.WorkspaceID),
+			db.Table("incident_component").Select("incident_component.incident_id")).
+		Where("(status <> ? OR resolved_at >= ?)", Resolved, since).
+		Order("created_at desc").
+		Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
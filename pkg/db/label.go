@@ -0,0 +1,100 @@
+package db
+
+import (
+	"encoding/json"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CheckLabel is a key/value pair attached to a check, such as team=payments
+type CheckLabel struct {
+	CheckID string `gorm:"primaryKey"`
+	Name    string `gorm:"primaryKey"`
+	Value   string
+}
+
+func (CheckLabel) TableName() string {
+	return "check_label"
+}
+
+type Labels map[string]string
+
+// Matches returns true when every label of the selector is present with the same value
+func (l Labels) Matches(selector Labels) bool {
+	for name, value := range selector {
+		if v, ok := l[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (l Labels) Equal(other Labels) bool {
+	return len(l) == len(other) && l.Matches(other)
+}
+
+func (c Check) GetLabels() Labels {
+	labels := Labels{}
+	for _, label := range c.Labels {
+		labels[label.Name] = label.Value
+	}
+	return labels
+}
+
+func NewCheckLabels(checkID string, labels Labels) []CheckLabel {
+	var checkLabels []CheckLabel
+	for name, value := range labels {
+		checkLabels = append(checkLabels, CheckLabel{
+			CheckID: checkID,
+			Name:    name,
+			Value:   value,
+		})
+	}
+	return checkLabels
+}
+
+// SetCheckLabels replaces the labels of the check
+func SetCheckLabels(db *gorm.DB, checkID string, labels Labels) error {
+	result := db.Where("check_id = ?", checkID).Delete(&CheckLabel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	checkLabels := NewCheckLabels(checkID, labels)
+	if len(checkLabels) == 0 {
+		return nil
+	}
+	return db.Create(&checkLabels).Error
+}
+
+// whereLabels restricts the query on checks to the ones matching every label of the selector
+func whereLabels(query *gorm.DB, db *gorm.DB, column string, selector Labels) *gorm.DB {
+	for name, value := range selector {
+		query = query.Where(column+" IN (?)", db.Model(&CheckLabel{}).
+			Select("check_id").
+			Where("name = ? AND value = ?", name, value))
+	}
+	return query
+}
+
+func labelsFromJSON(data datatypes.JSON) (Labels, error) {
+	labels := Labels{}
+	if len(data) == 0 {
+		return labels, nil
+	}
+	marshalJSON, err := data.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(marshalJSON, &labels)
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func labelsToJSON(labels Labels) (datatypes.JSON, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(labels)
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"time"
+)
+
+type MaintenanceMode string
+
+const (
+	// PauseMode skips the probes during the window
+	PauseMode MaintenanceMode = "PAUSE"
+	// FlagMode keeps probing but flags the executions as maintenance
+	FlagMode MaintenanceMode = "FLAG"
+)
+
+// MaintenanceWindow is either a one-off window between StartsAt and EndsAt or a
+// recurring window starting at every occurrence of the cron Schedule in Timezone,
+// it affects its Checks and every check matching all of its Labels
+type MaintenanceWindow struct {
+	ID          string `gorm:"primaryKey"`
+	WorkspaceID string `gorm:"index;size:191;not null;default:'default'"`
+	Title       string
+	Description string
+	Mode        MaintenanceMode
+	StartsAt    *time.Time
+	EndsAt      *time.Time
+	Schedule    string
+	Duration    string
+	Timezone    string
+	Checks      []Check `gorm:"many2many:maintenance_window_check"`
+	Labels      datatypes.JSON
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (MaintenanceWindow) TableName() string {
+	return "maintenance_window"
+}
+
+type MaintenanceOccurrence struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (w MaintenanceWindow) GetLabels() (Labels, error) {
+	return labelsFromJSON(w.Labels)
+}
+
+func (w *MaintenanceWindow) SetLabels(labels Labels) error {
+	data, err := labelsToJSON(labels)
+	if err != nil {
+		return err
+	}
+	w.Labels = data
+	return nil
+}
+
+func (w MaintenanceWindow) IsRecurring() bool {
+	return w.Schedule != ""
+}
+
+func (w MaintenanceWindow) Validate() error {
+	switch w.Mode {
+	case PauseMode, FlagMode:
+	default:
+		return errors.Errorf("Invalid maintenance mode %s", w.Mode)
+	}
+	if w.IsRecurring() {
+		_, _, err := w.schedule()
+		return err
+	}
+	if w.StartsAt == nil || w.EndsAt == nil {
+		return errors.New("Maintenance windows require either a schedule and a duration or a start and an end")
+	}
+	if !w.EndsAt.After(*w.StartsAt) {
+		return errors.New("Maintenance window must end after it starts")
+	}
+	return nil
+}
+
+func (w MaintenanceWindow) schedule() (cron.Schedule, time.Duration, error) {
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "Invalid duration %s", w.Duration)
+	}
+	if duration <= 0 {
+		return nil, 0, errors.Errorf("Invalid duration %s", w.Duration)
+	}
+	timezone := w.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	_, err = time.LoadLocation(timezone)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "Invalid timezone %s", timezone)
+	}
+	schedule, err := cron.ParseStandard(fmt.Sprintf("CRON_TZ=%s %s", timezone, w.Schedule))
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "Invalid schedule %s", w.Schedule)
+	}
+	return schedule, duration, nil
+}
+
+// Occurrences returns the occurrences of the window overlapping [from, until), limited to max
+func (w MaintenanceWindow) Occurrences(from time.Time, until time.Time, max int) ([]MaintenanceOccurrence, error) {
+	if !w.IsRecurring() {
+		if w.StartsAt == nil || w.EndsAt == nil || !w.EndsAt.After(from) || !w.StartsAt.Before(until) {
+			return nil, nil
+		}
+		return []MaintenanceOccurrence{{Start: *w.StartsAt, End: *w.EndsAt}}, nil
+	}
+	schedule, duration, err := w.schedule()
+	if err != nil {
+		return nil, err
+	}
+	var occurrences []MaintenanceOccurrence
+	// occurrences starting after from-duration may still be running at from
+	for start := schedule.Next(from.Add(-duration)); start.Before(until) && len(occurrences) < max; start = schedule.Next(start) {
+		occurrences = append(occurrences, MaintenanceOccurrence{Start: start, End: start.Add(duration)})
+	}
+	return occurrences, nil
+}
+
+func (w MaintenanceWindow) ActiveAt(t time.Time) (bool, error) {
+	occurrences, err := w.Occurrences(t, t.Add(time.Nanosecond), 1)
+	if err != nil {
+		return false, err
+	}
+	return len(occurrences) > 0, nil
+}
+
+// addLabeledChecks adds to the checks of every window the checks matching its labels
+func addLabeledChecks(db *gorm.DB, windows []MaintenanceWindow) error {
+	for i := range windows {
+		window := &windows[i]
+		labels, err := window.GetLabels()
+		if err != nil {
+			return err
+		}
+		if len(labels) == 0 {
+			continue
+		}
+		var checks []Check
+		query := db.Preload("Labels").Where("workspace_id = ?", workspaceOrDefault(window.WorkspaceID))
+		result := whereLabels(query, db, "id", labels).Find(&checks)
+		if result.Error != nil {
+			return result.Error
+		}
+		included := map[string]bool{}
+		for _, chk := range window.Checks {
+			included[chk.ID] = true
+		}
+		for _, chk := range checks {
+			if !included[chk.ID] {
+				window.Checks = append(window.Checks, chk)
+			}
+		}
+	}
+	return nil
+}
+
+// GetMaintenanceWindows returns every window along with the checks matching its labels
+func GetMaintenanceWindows(db *gorm.DB) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	result := db.Preload("Checks.Labels").Order("created_at").Find(&windows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	err := addLabeledChecks(db, windows)
+	if err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+func GetMaintenanceWindow(db *gorm.DB, id string) (*MaintenanceWindow, error) {
+	windows := []MaintenanceWindow{{}}
+	result := db.Preload("Checks.Labels").First(&windows[0], "id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	err := addLabeledChecks(db, windows)
+	if err != nil {
+		return nil, err
+	}
+	return &windows[0], nil
+}
+
+// GetActiveMaintenanceWindows returns the maintenance window in progress for every check under maintenance
+func GetActiveMaintenanceWindows(db *gorm.DB, now time.Time) (map[string]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	result := db.Preload("Checks").
+		Where("schedule <> ? OR (starts_at <= ? AND ends_at > ?)", "", now, now).
+		Find(&windows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	active := map[string]MaintenanceWindow{}
+	var activeWindows []MaintenanceWindow
+	for _, window := range windows {
+		isActive, err := window.ActiveAt(now)
+		if err != nil {
+			return nil, err
+		}
+		if isActive {
+			activeWindows = append(activeWindows, window)
+		}
+	}
+	err := addLabeledChecks(db, activeWindows)
+	if err != nil {
+		return nil, err
+	}
+	for _, window := range activeWindows {
+		for _, chk := range window.Checks {
+			active[chk.ID] = window
+		}
+	}
+	return active, nil
+}
+
+// GetCheckMaintenanceWindows returns the windows affecting any of the checks
+func GetCheckMaintenanceWindows(db *gorm.DB, checkIDs []string) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	if len(checkIDs) == 0 {
+		return windows, nil
+	}
+	result := db.Preload("Checks").
+		Where("(id IN (?) OR labels IS NOT NULL)", db.Table("maintenance_window_check").
+			Select("maintenance_window_id").
+			Where("check_id IN ?", checkIDs)).
+		Find(&windows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	err := addLabeledChecks(db, windows)
+	if err != nil {
+		return nil, err
+	}
+	requested := map[string]bool{}
+	for _, checkID := range checkIDs {
+		requested[checkID] = true
+	}
+	var checkWindows []MaintenanceWindow
+	for _, window := range windows {
+		for _, chk := range window.Checks {
+			if requested[chk.ID] {
+				checkWindows = append(checkWindows, window)
+				break
+			}
+		}
+	}
+	return checkWindows, nil
+}
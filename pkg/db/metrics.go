@@ -0,0 +1,243 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type MetricsBucket struct {
+	Time       time.Time
+	Count      int64
+	MinLatency time.Duration
+	AvgLatency time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	MaxLatency time.Duration
+	// Up and Down count the executions by status, executions during maintenance windows do not
+	// count towards the uptime
+	Up   int64
+	Down int64
+}
+
+type metricsRow struct {
+	Bucket     float64
+	Count      int64
+	MinLatency float64
+	AvgLatency float64
+	P50        float64
+	P95        float64
+	P99        float64
+	MaxLatency float64
+	Up         int64
+	Down       int64
+}
+
+// percentiles are computed with the nearest-rank method so that both
+// dialects return the same values for the same data
+const postgresMetricsQuery = `
+SELECT FLOOR(EXTRACT(EPOCH FROM created_at) / @bucket) * @bucket AS bucket,
+       COUNT(*) AS count,
+       MIN(latency) AS min_latency,
+       AVG(latency) AS avg_latency,
+       PERCENTILE_DISC(0.5) WITHIN GROUP (ORDER BY latency) AS p50,
+       PERCENTILE_DISC(0.95) WITHIN GROUP (ORDER BY latency) AS p95,
+       PERCENTILE_DISC(0.99) WITHIN GROUP (ORDER BY latency) AS p99,
+       MAX(latency) AS max_latency,
+       SUM(CASE WHEN status = @up AND maintenance = @maintenance THEN 1 ELSE 0 END) AS up,
+       SUM(CASE WHEN status = @down AND maintenance = @maintenance THEN 1 ELSE 0 END) AS down
+FROM check_execution
+WHERE check_id = @check AND created_at >= @from AND created_at < @until
+GROUP BY 1
+ORDER BY 1`
+
+// mysqlMetricsQuery computes the epoch with TIMESTAMPDIFF because UNIX_TIMESTAMP reads the
+// DATETIME columns, stored in UTC, in the time zone of the session
+const mysqlMetricsQuery = `
+SELECT bucket,
+       COUNT(*) AS count,
+       MIN(latency) AS min_latency,
+       AVG(latency) AS avg_latency,
+       MIN(CASE WHEN rn >= CEIL(0.5 * cnt) THEN latency END) AS p50,
+       MIN(CASE WHEN rn >= CEIL(0.95 * cnt) THEN latency END) AS p95,
+       MIN(CASE WHEN rn >= CEIL(0.99 * cnt) THEN latency END) AS p99,
+       MAX(latency) AS max_latency,
+       SUM(CASE WHEN status = @up AND maintenance = @maintenance THEN 1 ELSE 0 END) AS up,
+       SUM(CASE WHEN status = @down AND maintenance = @maintenance THEN 1 ELSE 0 END) AS down
+FROM (
+    SELECT FLOOR(TIMESTAMPDIFF(SECOND, '1970-01-01', created_at) / @bucket) * @bucket AS bucket,
+           latency,
+           status,
+           maintenance,
+           ROW_NUMBER() OVER (PARTITION BY FLOOR(TIMESTAMPDIFF(SECOND, '1970-01-01', created_at) / @bucket) ORDER BY latency) AS rn,
+           COUNT(*) OVER (PARTITION BY FLOOR(TIMESTAMPDIFF(SECOND, '1970-01-01', created_at) / @bucket)) AS cnt
+    FROM check_execution
+    WHERE check_id = @check AND created_at >= @from AND created_at < @until
+) t
+GROUP BY bucket
+ORDER BY bucket`
+
+// sqliteMetricsQuery relies on the integer division of SQLite to floor the buckets, rn is an
+// integer so comparing it with the rank is the same as comparing it with its ceiling
+const sqliteMetricsQuery = `
+SELECT bucket,
+       COUNT(*) AS count,
+       MIN(latency) AS min_latency,
+       AVG(latency) AS avg_latency,
+       MIN(CASE WHEN rn >= 0.5 * cnt THEN latency END) AS p50,
+       MIN(CASE WHEN rn >= 0.95 * cnt THEN latency END) AS p95,
+       MIN(CASE WHEN rn >= 0.99 * cnt THEN latency END) AS p99,
+       MAX(latency) AS max_latency,
+       SUM(CASE WHEN status = @up AND maintenance = @maintenance THEN 1 ELSE 0 END) AS up,
+       SUM(CASE WHEN status = @down AND maintenance = @maintenance THEN 1 ELSE 0 END) AS down
+FROM (
+    SELECT CAST(strftime('%s', created_at) AS INTEGER) / @bucket * @bucket AS bucket,
+           latency,
+           status,
+           maintenance,
+           ROW_NUMBER() OVER (PARTITION BY CAST(strftime('%s', created_at) AS INTEGER) / @bucket ORDER BY latency) AS rn,
+           COUNT(*) OVER (PARTITION BY CAST(strftime('%s', created_at) AS INTEGER) / @bucket) AS cnt
+    FROM check_execution
+    WHERE check_id = @check AND created_at >= @from AND created_at < @until
+) t
+GROUP BY bucket
+ORDER BY bucket`
+
+// GetMetrics aggregates the executions of a check between from and until
+// into buckets of the given size, reading the rollups for the periods
+// whose raw executions have already been pruned
+func GetMetrics(db *gorm.DB, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]MetricsBucket, error) {
+	if bucket < time.Second {
+		return nil, errors.Errorf("Bucket must be at least one second, got %s", bucket)
+	}
+	policy := GetRetentionPolicy()
+	now := time.Now()
+	rawCutoff := policy.rawCutoff(now)
+	var buckets []MetricsBucket
+	if from.Before(rawCutoff) {
+		rollupUntil := until
+		if rawCutoff.Before(rollupUntil) {
+			rollupUntil = rawCutoff
+		}
+		rollupBuckets, err := getRollupMetrics(db, checkID, from, rollupUntil, policy.hourlyCutoff(now))
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, rollupBuckets...)
+		from = rawCutoff
+	}
+	if from.Before(until) {
+		rawBuckets, err := getRawMetrics(db, checkID, from, until, bucket)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, rawBuckets...)
+	}
+	return mergeBuckets(buckets, bucket), nil
+}
+
+// GetUptime returns the number of up and down executions of a check between from and until
+func GetUptime(db *gorm.DB, checkID string, from time.Time, until time.Time) (up int64, down int64, err error) {
+	bucket := until.Sub(from)
+	if bucket < time.Second {
+		bucket = time.Second
+	}
+	buckets, err := GetMetrics(db, checkID, from, until, bucket)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, b := range buckets {
+		up += b.Up
+		down += b.Down
+	}
+	return up, down, nil
+}
+
+// mergeBuckets combines the buckets that fall into the same interval of the given size,
+// percentiles of merged buckets are approximated by their weighted average
+func mergeBuckets(buckets []MetricsBucket, size time.Duration) []MetricsBucket {
+	var merged []MetricsBucket
+	for _, b := range buckets {
+		start := BucketStart(b.Time, size)
+		last := len(merged) - 1
+		if last < 0 || !merged[last].Time.Equal(start) {
+			b.Time = start
+			merged = append(merged, b)
+			continue
+		}
+		m := &merged[last]
+		count := m.Count + b.Count
+		if count > 0 {
+			m.AvgLatency = weightedAverage(m.AvgLatency, m.Count, b.AvgLatency, b.Count)
+			m.P50 = weightedAverage(m.P50, m.Count, b.P50, b.Count)
+			m.P95 = weightedAverage(m.P95, m.Count, b.P95, b.Count)
+			m.P99 = weightedAverage(m.P99, m.Count, b.P99, b.Count)
+		}
+		if b.MinLatency < m.MinLatency {
+			m.MinLatency = b.MinLatency
+		}
+		if b.MaxLatency > m.MaxLatency {
+			m.MaxLatency = b.MaxLatency
+		}
+		m.Count = count
+		m.Up += b.Up
+		m.Down += b.Down
+	}
+	return merged
+}
+
+// BucketStart aligns t to the epoch the same way the SQL queries do
+func BucketStart(t time.Time, size time.Duration) time.Time {
+	seconds := int64(size / time.Second)
+	unix := t.Unix()
+	return time.Unix(unix-unix%seconds, 0)
+}
+
+func weightedAverage(a time.Duration, aCount int64, b time.Duration, bCount int64) time.Duration {
+	return time.Duration((int64(a)*aCount + int64(b)*bCount) / (aCount + bCount))
+}
+
+func getRawMetrics(db *gorm.DB, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]MetricsBucket, error) {
+	bucketSeconds := int64(bucket / time.Second)
+	var query string
+	switch db.Dialector.Name() {
+	case "postgres":
+		query = postgresMetricsQuery
+	case "mysql":
+		query = mysqlMetricsQuery
+	case "sqlite":
+		query = sqliteMetricsQuery
+	default:
+		return nil, errors.Errorf("Metrics not supported for %s", db.Dialector.Name())
+	}
+	var rows []metricsRow
+	result := db.Raw(query, map[string]interface{}{
+		"bucket":      bucketSeconds,
+		"check":       checkID,
+		"from":        from,
+		"until":       until,
+		"up":          Up,
+		"down":        Down,
+		"maintenance": false,
+	}).Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var buckets []MetricsBucket
+	for _, row := range rows {
+		buckets = append(buckets, MetricsBucket{
+			Time:       time.Unix(int64(row.Bucket), 0),
+			Count:      row.Count,
+			MinLatency: time.Duration(row.MinLatency),
+			AvgLatency: time.Duration(row.AvgLatency),
+			P50:        time.Duration(row.P50),
+			P95:        time.Duration(row.P95),
+			P99:        time.Duration(row.P99),
+			MaxLatency: time.Duration(row.MaxLatency),
+			Up:         row.Up,
+			Down:       row.Down,
+		})
+	}
+	return buckets, nil
+}
@@ -0,0 +1,426 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the migrations of every dialect, named <version>_<name>.<up|down>.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// the statements of the initial schema adapted to the databases created by AutoMigrate, the
+// identifiers are quoted with double quotes or with backticks for MySQL
+var (
+	createTableStatement = regexp.MustCompile("^CREATE TABLE [`\"](\\w+)[`\"]")
+	createIndexStatement = regexp.MustCompile("^CREATE (?:UNIQUE )?INDEX [`\"](\\w+)[`\"] ON [`\"](\\w+)[`\"]")
+	insertStatement      = regexp.MustCompile("^INSERT INTO [`\"](\\w+)[`\"]")
+	columnDefinition     = regexp.MustCompile("^\\s*([`\"](\\w+)[`\"]\\s.*?),?$")
+)
+
+// legacyIndexes were created by AutoMigrate before the resources were isolated in workspaces,
+// they would keep the names unique across every workspace
+var legacyIndexes = []struct {
+	table string
+	name  string
+}{
+	{"check", "idx_check_identifier"},
+	{"notification_channel", "idx_notification_channel_name"},
+}
+
+// Migration is a versioned change of the schema along with the SQL reverting it
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// SchemaMigration records a migration applied to the database
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migration"
+}
+
+// MigrationStatus is a migration known by this release or applied to the database
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Unknown migrations were applied by a newer release
+	Unknown bool
+}
+
+// Migrations returns the migrations of the dialect of the database ordered by version
+func Migrations(db *gorm.DB) ([]Migration, error) {
+	dialect := db.Dialector.Name()
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, errors.Errorf("Migrations not available for %s databases", dialect)
+	}
+	migrations := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, errors.Errorf("Invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		contents, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := migrations[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrations[version] = migration
+		}
+		if match[3] == "up" {
+			migration.up = string(contents)
+		} else {
+			migration.down = string(contents)
+		}
+	}
+	var sorted []Migration
+	for _, migration := range migrations {
+		sorted = append(sorted, *migration)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted, nil
+}
+
+// Migrate applies every pending migration
+func Migrate(db *gorm.DB) error {
+	_, err := MigrateUp(db, 0)
+	return err
+}
+
+// MigrateUp applies the pending migrations up to the target version, every pending migration
+// when the target is 0
+func MigrateUp(db *gorm.DB, target int) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+	err = ensureMigrationTable(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	latest := migrations[len(migrations)-1].Version
+	if target == 0 {
+		target = latest
+	}
+	if target > latest {
+		return nil, errors.Errorf("Unknown migration version %d, the latest version is %d", target, latest)
+	}
+	var migrated []Migration
+	for _, migration := range migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		log.Infof("Applying migration %d %s", migration.Version, migration.Name)
+		err := runMigration(db, migration.up, func(tx *gorm.DB) error {
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return migrated, errors.Wrapf(err, "Failed applying migration %d %s", migration.Version, migration.Name)
+		}
+		migrated = append(migrated, migration)
+	}
+	return migrated, nil
+}
+
+// MigrateDown reverts the latest applied migrations, steps is the number of migrations reverted
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+	err = ensureMigrationTable(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	known := map[int]Migration{}
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+	var versions []int
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	var reverted []Migration
+	for _, version := range versions {
+		if len(reverted) == steps {
+			break
+		}
+		migration, ok := known[version]
+		if !ok {
+			return reverted, errors.Errorf("Migration %d was applied by a newer release, revert it with that release", version)
+		}
+		log.Infof("Reverting migration %d %s", migration.Version, migration.Name)
+		err := runMigration(db, migration.down, func(tx *gorm.DB) error {
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return reverted, errors.Wrapf(err, "Failed reverting migration %d %s", migration.Version, migration.Name)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// GetMigrationStatus returns the migrations known by this release or applied to the database
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if schemaMigration, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &schemaMigration.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, schemaMigration := range applied {
+		schemaMigration := schemaMigration
+		statuses = append(statuses, MigrationStatus{
+			Version:   schemaMigration.Version,
+			Name:      schemaMigration.Name,
+			AppliedAt: &schemaMigration.AppliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// CheckSchema returns an error unless every migration of this release is applied and the database
+// wasn't migrated by a newer release
+func CheckSchema(db *gorm.DB) error {
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, status := range statuses {
+		if status.Unknown {
+			return errors.Errorf("Database schema was migrated to version %d by a newer release", status.Version)
+		}
+		if status.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%d %s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return errors.Errorf("Database schema is not up to date, run `statuspage migrate up` to apply the pending migrations: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// ensureMigrationTable creates the table recording the applied migrations, the databases created
+// by AutoMigrate before versioned migrations existed are completed to the initial schema and
+// marked as migrated to the first version
+func ensureMigrationTable(db *gorm.DB) error {
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil
+	}
+	migrations, err := Migrations(db)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		err := migrator.CreateTable(&SchemaMigration{})
+		if err != nil {
+			return err
+		}
+		if !migrator.HasTable(&Check{}) {
+			return nil
+		}
+		log.Infof("Database created by a previous release, completing it to the initial schema")
+		err = adoptLegacySchema(tx, migrations[0].up)
+		if err != nil {
+			return errors.Wrap(err, "Failed completing the schema of the previous release")
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   migrations[0].Version,
+			Name:      migrations[0].Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+// adoptLegacySchema runs the initial schema on a database created by AutoMigrate, from the
+// baseline with the checks and their executions only up to the last release without
+// migrations. The missing tables and indexes are created and the missing columns are added
+// to the existing tables, rows are only inserted in the tables created
+func adoptLegacySchema(tx *gorm.DB, script string) error {
+	migrator := tx.Migrator()
+	created := map[string]bool{}
+	for _, statement := range splitStatements(script) {
+		if match := createTableStatement.FindStringSubmatch(statement); match != nil {
+			table := match[1]
+			if !migrator.HasTable(table) {
+				err := tx.Exec(statement).Error
+				if err != nil {
+					return err
+				}
+				created[table] = true
+				continue
+			}
+			err := addMissingColumns(tx, statement, table)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if match := createIndexStatement.FindStringSubmatch(statement); match != nil {
+			if migrator.HasIndex(match[2], match[1]) {
+				continue
+			}
+		}
+		if match := insertStatement.FindStringSubmatch(statement); match != nil && !created[match[1]] {
+			continue
+		}
+		err := tx.Exec(statement).Error
+		if err != nil {
+			return err
+		}
+	}
+	for _, index := range legacyIndexes {
+		if !migrator.HasIndex(index.table, index.name) {
+			continue
+		}
+		err := migrator.DropIndex(index.table, index.name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMissingColumns adds the columns of the CREATE TABLE statement missing in the table, the
+// constraints of the statement are not added to the existing tables
+func addMissingColumns(tx *gorm.DB, statement string, table string) error {
+	rows, err := tx.Table(table).Limit(1).Rows()
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for _, column := range columns {
+		existing[strings.ToLower(column)] = true
+	}
+	quote := statement[len("CREATE TABLE ")]
+	for _, line := range strings.Split(statement, "\n")[1:] {
+		match := columnDefinition.FindStringSubmatch(line)
+		if match == nil || existing[match[2]] {
+			continue
+		}
+		err := tx.Exec(fmt.Sprintf("ALTER TABLE %c%s%c ADD COLUMN %s", quote, table, quote, match[1])).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the migrations applied to the database by version, none when the
+// migrations table doesn't exist yet
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	applied := map[int]SchemaMigration{}
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return applied, nil
+	}
+	var schemaMigrations []SchemaMigration
+	err := db.Find(&schemaMigrations).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, schemaMigration := range schemaMigrations {
+		applied[schemaMigration.Version] = schemaMigration
+	}
+	return applied, nil
+}
+
+// runMigration executes the statements of the migration and records it in a transaction, MySQL
+// commits every schema change on its own so that a failed migration has to be fixed by hand
+func runMigration(db *gorm.DB, script string, record func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range splitStatements(script) {
+			err := tx.Exec(statement).Error
+			if err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
+
+// splitStatements splits the script on semicolons and drops the comments, the migrations don't
+// use semicolons in literals
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		statement = strings.TrimSpace(statement)
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
@@ -0,0 +1 @@
+ALTER TABLE `check` CHANGE `frequency` `frecuency` longtext;
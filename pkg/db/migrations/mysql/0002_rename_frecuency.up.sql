@@ -0,0 +1,2 @@
+-- CHANGE instead of RENAME COLUMN, which requires MySQL 8
+ALTER TABLE `check` CHANGE `frecuency` `frequency` longtext;
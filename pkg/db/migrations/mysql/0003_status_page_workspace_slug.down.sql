@@ -0,0 +1,2 @@
+DROP INDEX `idx_status_page_workspace_slug` ON `status_page`;
+CREATE UNIQUE INDEX `idx_status_page_slug` ON `status_page` (`slug`);
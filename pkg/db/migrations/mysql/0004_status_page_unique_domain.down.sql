@@ -0,0 +1,2 @@
+DROP INDEX `idx_status_page_custom_domain` ON `status_page`;
+ALTER TABLE `status_page` DROP COLUMN `custom_domain`;
@@ -0,0 +1,5 @@
+-- the deleted status pages release their custom domain
+UPDATE `status_page` SET `domain` = '' WHERE `deleted_at` IS NOT NULL;
+-- MySQL has no partial indexes, the generated column is NULL for the pages without a custom domain
+ALTER TABLE `status_page` ADD COLUMN `custom_domain` varchar(191) AS (NULLIF(`domain`, '')) STORED;
+CREATE UNIQUE INDEX `idx_status_page_custom_domain` ON `status_page` (`custom_domain`);
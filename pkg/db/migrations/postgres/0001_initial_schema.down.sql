@@ -0,0 +1,29 @@
+DROP TABLE "audit_entry";
+
+DROP TABLE "api_token";
+
+DROP TABLE "notification_channel";
+
+DROP TABLE "maintenance_window_check";
+
+DROP TABLE "maintenance_window";
+
+DROP TABLE "incident_update";
+
+DROP TABLE "incident_component";
+
+DROP TABLE "incident";
+
+DROP TABLE "status_page_component";
+
+DROP TABLE "status_page";
+
+DROP TABLE "check_execution_rollup";
+
+DROP TABLE "check_execution";
+
+DROP TABLE "check_label";
+
+DROP TABLE "check";
+
+DROP TABLE "workspace";
@@ -0,0 +1,250 @@
+CREATE TABLE "workspace" (
+    "id" varchar(191),
+    "name" text,
+    "max_checks" bigint NOT NULL DEFAULT 0,
+    "max_status_pages" bigint NOT NULL DEFAULT 0,
+    "max_notification_channels" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+INSERT INTO "workspace" ("id", "name", "created_at", "updated_at") VALUES ('default', 'Default', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+CREATE TABLE "check" (
+    "id" text,
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "identifier" varchar(191),
+    "type" text,
+    "data" JSONB,
+    "frecuency" text,
+    "description" text,
+    "owner" text,
+    "status" text,
+    "paused" boolean NOT NULL DEFAULT false,
+    "error_msg" text,
+    "message" text,
+    "latest_check" timestamptz,
+    "latency" bigint,
+    "cert_expires_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_check_deleted_at" ON "check" ("deleted_at");
+
+CREATE UNIQUE INDEX "idx_check_workspace_identifier" ON "check" ("workspace_id", "identifier");
+
+CREATE TABLE "check_label" (
+    "check_id" text,
+    "name" text,
+    "value" text,
+    PRIMARY KEY ("check_id", "name"),
+    CONSTRAINT "fk_check_labels" FOREIGN KEY ("check_id") REFERENCES "check" ("id")
+);
+
+CREATE TABLE "check_execution" (
+    "id" text,
+    "status" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "error_msg" text,
+    "message" text,
+    "latency" bigint,
+    "maintenance" boolean NOT NULL DEFAULT false,
+    "stats" JSONB,
+    "check_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_check_executions" FOREIGN KEY ("check_id") REFERENCES "check" ("id")
+);
+
+CREATE INDEX "idx_check_execution_check_id" ON "check_execution" ("check_id");
+
+CREATE TABLE "check_execution_rollup" (
+    "id" text,
+    "check_id" text,
+    "resolution" varchar(16),
+    "bucket_start" timestamptz,
+    "count" bigint,
+    "up" bigint,
+    "down" bigint,
+    "min_latency" bigint,
+    "avg_latency" bigint,
+    "p50" bigint,
+    "p95" bigint,
+    "p99" bigint,
+    "max_latency" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX "idx_check_execution_rollup_bucket" ON "check_execution_rollup" ("check_id", "resolution", "bucket_start");
+
+CREATE TABLE "status_page" (
+    "id" text,
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "slug" text,
+    "title" text,
+    "logo_url" text,
+    "domain" text,
+    "group_by_label" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_status_page_deleted_at" ON "status_page" ("deleted_at");
+
+CREATE INDEX "idx_status_page_domain" ON "status_page" ("domain");
+
+CREATE UNIQUE INDEX "idx_status_page_slug" ON "status_page" ("slug");
+
+CREATE INDEX "idx_status_page_workspace_id" ON "status_page" ("workspace_id");
+
+CREATE TABLE "status_page_component" (
+    "id" text,
+    "status_page_id" text,
+    "name" text,
+    "group_name" text,
+    "position" bigint,
+    "check_id" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_status_page_component_check" FOREIGN KEY ("check_id") REFERENCES "check" ("id"),
+    CONSTRAINT "fk_status_page_components" FOREIGN KEY ("status_page_id") REFERENCES "status_page" ("id")
+);
+
+CREATE INDEX "idx_status_page_component_status_page_id" ON "status_page_component" ("status_page_id");
+
+CREATE TABLE "incident" (
+    "id" text,
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "title" text,
+    "status" text,
+    "impact" text,
+    "resolved_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_incident_deleted_at" ON "incident" ("deleted_at");
+
+CREATE INDEX "idx_incident_status" ON "incident" ("status");
+
+CREATE INDEX "idx_incident_workspace_id" ON "incident" ("workspace_id");
+
+CREATE TABLE "incident_component" (
+    "incident_id" text,
+    "status_page_component_id" text,
+    PRIMARY KEY ("incident_id", "status_page_component_id"),
+    CONSTRAINT "fk_incident_component_incident" FOREIGN KEY ("incident_id") REFERENCES "incident" ("id"),
+    CONSTRAINT "fk_incident_component_status_page_component" FOREIGN KEY ("status_page_component_id") REFERENCES "status_page_component" ("id")
+);
+
+CREATE TABLE "incident_update" (
+    "id" text,
+    "incident_id" text,
+    "status" text,
+    "body" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_incident_updates" FOREIGN KEY ("incident_id") REFERENCES "incident" ("id")
+);
+
+CREATE INDEX "idx_incident_update_incident_id" ON "incident_update" ("incident_id");
+
+CREATE TABLE "maintenance_window" (
+    "id" text,
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "title" text,
+    "description" text,
+    "mode" text,
+    "starts_at" timestamptz,
+    "ends_at" timestamptz,
+    "schedule" text,
+    "duration" text,
+    "timezone" text,
+    "labels" JSONB,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_maintenance_window_deleted_at" ON "maintenance_window" ("deleted_at");
+
+CREATE INDEX "idx_maintenance_window_workspace_id" ON "maintenance_window" ("workspace_id");
+
+CREATE TABLE "maintenance_window_check" (
+    "maintenance_window_id" text,
+    "check_id" text,
+    PRIMARY KEY ("maintenance_window_id", "check_id"),
+    CONSTRAINT "fk_maintenance_window_check_maintenance_window" FOREIGN KEY ("maintenance_window_id") REFERENCES "maintenance_window" ("id"),
+    CONSTRAINT "fk_maintenance_window_check_check" FOREIGN KEY ("check_id") REFERENCES "check" ("id")
+);
+
+CREATE TABLE "notification_channel" (
+    "id" text,
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "name" varchar(191),
+    "type" text,
+    "url" text,
+    "labels" JSONB,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX "idx_notification_channel_workspace_name" ON "notification_channel" ("workspace_id", "name");
+
+CREATE TABLE "api_token" (
+    "id" text,
+    "name" varchar(191),
+    "hash" varchar(64),
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "role" text NOT NULL DEFAULT 'ADMIN',
+    "teams" JSONB,
+    "hint" text,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_api_token_workspace_id" ON "api_token" ("workspace_id");
+
+CREATE UNIQUE INDEX "idx_api_token_hash" ON "api_token" ("hash");
+
+CREATE UNIQUE INDEX "idx_api_token_name" ON "api_token" ("name");
+
+CREATE TABLE "audit_entry" (
+    "id" text,
+    "workspace_id" varchar(191) NOT NULL DEFAULT 'default',
+    "actor_id" text,
+    "actor" varchar(191),
+    "actor_method" varchar(32),
+    "operation" varchar(32),
+    "resource_type" varchar(64),
+    "resource_id" varchar(191),
+    "before" JSONB,
+    "after" JSONB,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_audit_entry_created_at" ON "audit_entry" ("created_at");
+
+CREATE INDEX "idx_audit_entry_resource" ON "audit_entry" ("resource_type", "resource_id");
+
+CREATE INDEX "idx_audit_entry_operation" ON "audit_entry" ("operation");
+
+CREATE INDEX "idx_audit_entry_actor" ON "audit_entry" ("actor");
+
+CREATE INDEX "idx_audit_entry_workspace_id" ON "audit_entry" ("workspace_id");
@@ -0,0 +1 @@
+ALTER TABLE "check" RENAME COLUMN "frecuency" TO "frequency";
@@ -0,0 +1 @@
+DROP INDEX "idx_status_page_custom_domain";
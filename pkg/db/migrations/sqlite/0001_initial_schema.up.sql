@@ -0,0 +1,250 @@
+CREATE TABLE "workspace" (
+    "id" text,
+    "name" text,
+    "max_checks" integer NOT NULL DEFAULT 0,
+    "max_status_pages" integer NOT NULL DEFAULT 0,
+    "max_notification_channels" integer NOT NULL DEFAULT 0,
+    "created_at" datetime,
+    "updated_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+INSERT INTO "workspace" ("id", "name", "created_at", "updated_at") VALUES ('default', 'Default', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+CREATE TABLE "check" (
+    "id" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "identifier" text,
+    "type" text,
+    "data" JSON,
+    "frecuency" text,
+    "description" text,
+    "owner" text,
+    "status" text,
+    "paused" numeric NOT NULL DEFAULT false,
+    "error_msg" text,
+    "message" text,
+    "latest_check" datetime,
+    "latency" integer,
+    "cert_expires_at" datetime,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_check_deleted_at" ON "check" ("deleted_at");
+
+CREATE UNIQUE INDEX "idx_check_workspace_identifier" ON "check" ("workspace_id", "identifier");
+
+CREATE TABLE "check_label" (
+    "check_id" text,
+    "name" text,
+    "value" text,
+    PRIMARY KEY ("check_id", "name"),
+    CONSTRAINT "fk_check_labels" FOREIGN KEY ("check_id") REFERENCES "check" ("id")
+);
+
+CREATE TABLE "check_execution" (
+    "id" text,
+    "status" text,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "error_msg" text,
+    "message" text,
+    "latency" integer,
+    "maintenance" numeric NOT NULL DEFAULT false,
+    "stats" JSON,
+    "check_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_check_executions" FOREIGN KEY ("check_id") REFERENCES "check" ("id")
+);
+
+CREATE INDEX "idx_check_execution_check_id" ON "check_execution" ("check_id");
+
+CREATE TABLE "check_execution_rollup" (
+    "id" text,
+    "check_id" text,
+    "resolution" text,
+    "bucket_start" datetime,
+    "count" integer,
+    "up" integer,
+    "down" integer,
+    "min_latency" integer,
+    "avg_latency" integer,
+    "p50" integer,
+    "p95" integer,
+    "p99" integer,
+    "max_latency" integer,
+    "created_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX "idx_check_execution_rollup_bucket" ON "check_execution_rollup" ("check_id", "resolution", "bucket_start");
+
+CREATE TABLE "status_page" (
+    "id" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "slug" text,
+    "title" text,
+    "logo_url" text,
+    "domain" text,
+    "group_by_label" text,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_status_page_deleted_at" ON "status_page" ("deleted_at");
+
+CREATE INDEX "idx_status_page_domain" ON "status_page" ("domain");
+
+CREATE UNIQUE INDEX "idx_status_page_slug" ON "status_page" ("slug");
+
+CREATE INDEX "idx_status_page_workspace_id" ON "status_page" ("workspace_id");
+
+CREATE TABLE "status_page_component" (
+    "id" text,
+    "status_page_id" text,
+    "name" text,
+    "group_name" text,
+    "position" integer,
+    "check_id" text,
+    "created_at" datetime,
+    "updated_at" datetime,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_status_page_component_check" FOREIGN KEY ("check_id") REFERENCES "check" ("id"),
+    CONSTRAINT "fk_status_page_components" FOREIGN KEY ("status_page_id") REFERENCES "status_page" ("id")
+);
+
+CREATE INDEX "idx_status_page_component_status_page_id" ON "status_page_component" ("status_page_id");
+
+CREATE TABLE "incident" (
+    "id" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "title" text,
+    "status" text,
+    "impact" text,
+    "resolved_at" datetime,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_incident_deleted_at" ON "incident" ("deleted_at");
+
+CREATE INDEX "idx_incident_status" ON "incident" ("status");
+
+CREATE INDEX "idx_incident_workspace_id" ON "incident" ("workspace_id");
+
+CREATE TABLE "incident_component" (
+    "incident_id" text,
+    "status_page_component_id" text,
+    PRIMARY KEY ("incident_id", "status_page_component_id"),
+    CONSTRAINT "fk_incident_component_incident" FOREIGN KEY ("incident_id") REFERENCES "incident" ("id"),
+    CONSTRAINT "fk_incident_component_status_page_component" FOREIGN KEY ("status_page_component_id") REFERENCES "status_page_component" ("id")
+);
+
+CREATE TABLE "incident_update" (
+    "id" text,
+    "incident_id" text,
+    "status" text,
+    "body" text,
+    "created_at" datetime,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_incident_updates" FOREIGN KEY ("incident_id") REFERENCES "incident" ("id")
+);
+
+CREATE INDEX "idx_incident_update_incident_id" ON "incident_update" ("incident_id");
+
+CREATE TABLE "maintenance_window" (
+    "id" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "title" text,
+    "description" text,
+    "mode" text,
+    "starts_at" datetime,
+    "ends_at" datetime,
+    "schedule" text,
+    "duration" text,
+    "timezone" text,
+    "labels" JSON,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_maintenance_window_deleted_at" ON "maintenance_window" ("deleted_at");
+
+CREATE INDEX "idx_maintenance_window_workspace_id" ON "maintenance_window" ("workspace_id");
+
+CREATE TABLE "maintenance_window_check" (
+    "maintenance_window_id" text,
+    "check_id" text,
+    PRIMARY KEY ("maintenance_window_id", "check_id"),
+    CONSTRAINT "fk_maintenance_window_check_maintenance_window" FOREIGN KEY ("maintenance_window_id") REFERENCES "maintenance_window" ("id"),
+    CONSTRAINT "fk_maintenance_window_check_check" FOREIGN KEY ("check_id") REFERENCES "check" ("id")
+);
+
+CREATE TABLE "notification_channel" (
+    "id" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "name" text,
+    "type" text,
+    "url" text,
+    "labels" JSON,
+    "created_at" datetime,
+    "updated_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX "idx_notification_channel_workspace_name" ON "notification_channel" ("workspace_id", "name");
+
+CREATE TABLE "api_token" (
+    "id" text,
+    "name" text,
+    "hash" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "role" text NOT NULL DEFAULT 'ADMIN',
+    "teams" JSON,
+    "hint" text,
+    "expires_at" datetime,
+    "last_used_at" datetime,
+    "created_at" datetime,
+    "updated_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_api_token_workspace_id" ON "api_token" ("workspace_id");
+
+CREATE UNIQUE INDEX "idx_api_token_hash" ON "api_token" ("hash");
+
+CREATE UNIQUE INDEX "idx_api_token_name" ON "api_token" ("name");
+
+CREATE TABLE "audit_entry" (
+    "id" text,
+    "workspace_id" text NOT NULL DEFAULT 'default',
+    "actor_id" text,
+    "actor" text,
+    "actor_method" text,
+    "operation" text,
+    "resource_type" text,
+    "resource_id" text,
+    "before" JSON,
+    "after" JSON,
+    "created_at" datetime,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX "idx_audit_entry_created_at" ON "audit_entry" ("created_at");
+
+CREATE INDEX "idx_audit_entry_resource" ON "audit_entry" ("resource_type", "resource_id");
+
+CREATE INDEX "idx_audit_entry_operation" ON "audit_entry" ("operation");
+
+CREATE INDEX "idx_audit_entry_actor" ON "audit_entry" ("actor");
+
+CREATE INDEX "idx_audit_entry_workspace_id" ON "audit_entry" ("workspace_id");
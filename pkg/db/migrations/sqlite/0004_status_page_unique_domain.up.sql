@@ -0,0 +1,3 @@
+-- the deleted status pages release their custom domain
+UPDATE "status_page" SET "domain" = '' WHERE "deleted_at" IS NOT NULL;
+CREATE UNIQUE INDEX "idx_status_page_custom_domain" ON "status_page" ("domain") WHERE "domain" <> '';
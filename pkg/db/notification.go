@@ -0,0 +1,170 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kfsoftware/statuspage/pkg/metrics"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ChannelType string
+
+const (
+	// SlackChannel posts a message to a slack incoming webhook
+	SlackChannel ChannelType = "SLACK"
+	// WebhookChannel posts the check as JSON to an arbitrary url
+	WebhookChannel ChannelType = "WEBHOOK"
+)
+
+// NotificationChannel receives the notifications of the checks matching all of its Labels,
+// channels without labels receive the notifications of every check
+type NotificationChannel struct {
+	ID          string `gorm:"primaryKey"`
+	WorkspaceID string `gorm:"index:idx_notification_channel_workspace_name,unique,priority:1;size:191;not null;default:'default'"`
+	Name        string `gorm:"index:idx_notification_channel_workspace_name,unique,priority:2;size:191"`
+	Type        ChannelType
+	Url         string
+	Labels      datatypes.JSON
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channel"
+}
+
+func (c *NotificationChannel) BeforeCreate(tx *gorm.DB) error {
+	return checkQuota(tx, c.WorkspaceID, &NotificationChannel{}, "notification channels", func(w Workspace) int {
+		return w.MaxNotificationChannels
+	})
+}
+
+func (c NotificationChannel) GetLabels() (Labels, error) {
+	return labelsFromJSON(c.Labels)
+}
+
+func (c *NotificationChannel) SetLabels(labels Labels) error {
+	data, err := labelsToJSON(labels)
+	if err != nil {
+		return err
+	}
+	c.Labels = data
+	return nil
+}
+
+func (c NotificationChannel) Validate() error {
+	switch c.Type {
+	case SlackChannel, WebhookChannel:
+	default:
+		return errors.Errorf("Invalid channel type %s", c.Type)
+	}
+	if c.Url == "" {
+		return errors.New("Notification channels require an url")
+	}
+	return nil
+}
+
+type webhookPayload struct {
+	ID          string    `json:"id"`
+	Identifier  string    `json:"identifier"`
+	Status      Status    `json:"status"`
+	Message     string    `json:"message"`
+	ErrorMsg    string    `json:"errorMsg"`
+	Owner       string    `json:"owner"`
+	Labels      Labels    `json:"labels"`
+	LatestCheck time.Time `json:"latestCheck"`
+}
+
+func (c NotificationChannel) notify(chk Check) error {
+	var data interface{}
+	switch c.Type {
+	case SlackChannel:
+		data = slackMessage(chk)
+	case WebhookChannel:
+		data = webhookPayload{
+			ID:          chk.ID,
+			Identifier:  chk.Identifier,
+			Status:      chk.Status,
+			Message:     chk.Message,
+			ErrorMsg:    chk.ErrorMsg,
+			Owner:       chk.Owner,
+			Labels:      chk.GetLabels(),
+			LatestCheck: chk.LatestCheck,
+		}
+	default:
+		return errors.Errorf("Invalid channel type %s", c.Type)
+	}
+	return postJSON(c.Url, data)
+}
+
+func slackMessage(chk Check) map[string]string {
+	data := map[string]string{}
+	data["text"] = fmt.Sprintf("Endpoint down: %s\n%s", chk.Identifier, chk.ErrorMsg)
+	return data
+}
+
+func postJSON(url string, data interface{}) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(dataBytes))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetCheckNotificationChannels returns the channels routing the notifications of the check
+func GetCheckNotificationChannels(db *gorm.DB, chk Check) ([]NotificationChannel, error) {
+	var channels []NotificationChannel
+	result := db.Find(&channels, "workspace_id = ?", workspaceOrDefault(chk.WorkspaceID))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	checkLabels := chk.GetLabels()
+	var checkChannels []NotificationChannel
+	for _, channel := range channels {
+		labels, err := channel.GetLabels()
+		if err != nil {
+			return nil, err
+		}
+		if checkLabels.Matches(labels) {
+			checkChannels = append(checkChannels, channel)
+		}
+	}
+	return checkChannels, nil
+}
+
+// NotifyEndpointDown sends the notification of the check to the channels routing it and to the
+// slack webhook of the configuration
+func NotifyEndpointDown(channels []NotificationChannel, chk Check) {
+	// the webhook of the configuration is shared by the operators, not by the tenants
+	slackWebhook := viper.GetString("slack.webhook")
+	if slackWebhook != "" && workspaceOrDefault(chk.WorkspaceID) == DefaultWorkspaceID {
+		err := postJSON(slackWebhook, slackMessage(chk))
+		if err != nil {
+			metrics.NotificationFailures.WithLabelValues(strings.ToLower(string(SlackChannel))).Inc()
+			log.Warnf("Error sending notification to slack:%v", err)
+		}
+	}
+	for _, channel := range channels {
+		err := channel.notify(chk)
+		if err != nil {
+			metrics.NotificationFailures.WithLabelValues(strings.ToLower(string(channel.Type))).Inc()
+			log.Warnf("Error sending notification to channel %s:%v", channel.Name, err)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+type OrderDirection string
+
+const (
+	Asc  OrderDirection = "ASC"
+	Desc OrderDirection = "DESC"
+)
+
+// OrderColumn is a column rows can be sorted by, ties are broken by id
+type OrderColumn struct {
+	Name string
+	Time bool
+}
+
+var (
+	IdentifierColumn  = OrderColumn{Name: "identifier"}
+	StatusColumn      = OrderColumn{Name: "status"}
+	LatencyColumn     = OrderColumn{Name: "latency"}
+	CreatedAtColumn   = OrderColumn{Name: "created_at", Time: true}
+	LatestCheckColumn = OrderColumn{Name: "latest_check", Time: true}
+)
+
+type Order struct {
+	Column    OrderColumn
+	Direction OrderDirection
+}
+
+// Pagination selects a page of rows using opaque cursors, either First/After or Last/Before
+type Pagination struct {
+	First  *int
+	After  *string
+	Last   *int
+	Before *string
+}
+
+type PageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+}
+
+type cursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// EncodeCursor returns the opaque cursor of a row, value is the one of the column the rows are
+// sorted by
+func EncodeCursor(value interface{}, id string) string {
+	var v string
+	switch value := value.(type) {
+	case time.Time:
+		v = value.UTC().Format(time.RFC3339Nano)
+	default:
+		v = fmt.Sprint(value)
+	}
+	cursorBytes, _ := json.Marshal(cursor{Value: v, ID: id})
+	return base64.URLEncoding.EncodeToString(cursorBytes)
+}
+
+func DecodeCursor(encoded string, column OrderColumn) (interface{}, string, error) {
+	cursorBytes, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", errors.Errorf("Invalid cursor %s", encoded)
+	}
+	c := cursor{}
+	err = json.Unmarshal(cursorBytes, &c)
+	if err != nil {
+		return nil, "", errors.Errorf("Invalid cursor %s", encoded)
+	}
+	if column.Time {
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, "", errors.Errorf("Invalid cursor %s", encoded)
+		}
+		return t, c.ID, nil
+	}
+	return c.Value, c.ID, nil
+}
+
+// Limit returns the number of rows requested and whether they are fetched in reverse order,
+// which is the case of the last rows before a cursor
+func (p Pagination) Limit() (int, bool, error) {
+	if p.First != nil && p.Last != nil {
+		return 0, false, errors.New("first and last cannot be used together")
+	}
+	backwards := p.Last != nil || (p.Before != nil && p.First == nil)
+	limit := defaultPageSize
+	if p.First != nil {
+		limit = *p.First
+	}
+	if p.Last != nil {
+		limit = *p.Last
+	}
+	if limit < 0 || limit > maxPageSize {
+		return 0, false, errors.Errorf("Page size must be between 0 and %d", maxPageSize)
+	}
+	return limit, backwards, nil
+}
+
+// paginate orders the query and restricts it to the rows after or before the cursors,
+// it returns the number of rows requested and whether they are fetched in reverse order
+func paginate(query *gorm.DB, order Order, p Pagination) (*gorm.DB, int, bool, error) {
+	if order.Direction == "" {
+		order.Direction = Asc
+	}
+	limit, backwards, err := p.Limit()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	column := order.Column.Name
+	if p.After != nil {
+		value, id, err := DecodeCursor(*p.After, order.Column)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		query = query.Where(keysetCondition(column, order.Direction == Asc), value, value, id)
+	}
+	if p.Before != nil {
+		value, id, err := DecodeCursor(*p.Before, order.Column)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		query = query.Where(keysetCondition(column, order.Direction == Desc), value, value, id)
+	}
+	direction := order.Direction
+	if backwards {
+		if direction == Asc {
+			direction = Desc
+		} else {
+			direction = Asc
+		}
+	}
+	query = query.
+		Order(fmt.Sprintf("%s %s", column, direction)).
+		Order(fmt.Sprintf("id %s", direction)).
+		Limit(limit + 1)
+	return query, limit, backwards, nil
+}
+
+func keysetCondition(column string, greater bool) string {
+	operator := "<"
+	if greater {
+		operator = ">"
+	}
+	return fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, operator, column, operator)
+}
+
+// Trim drops the extra row fetched to tell whether there are more pages and returns the indexes
+// of the rows to keep in order
+func (p Pagination) Trim(fetched int, limit int, backwards bool) ([]int, PageInfo) {
+	info := PageInfo{
+		HasPreviousPage: p.After != nil,
+		HasNextPage:     p.Before != nil,
+	}
+	if fetched > limit {
+		fetched = limit
+		if backwards {
+			info.HasPreviousPage = true
+		} else {
+			info.HasNextPage = true
+		}
+	}
+	indexes := make([]int, fetched)
+	for i := range indexes {
+		if backwards {
+			indexes[i] = fetched - 1 - i
+		} else {
+			indexes[i] = i
+		}
+	}
+	return indexes, info
+}
+
+type CheckFilter struct {
+	Types    []string
+	Statuses []string
+	Owner    string
+	// Labels selects the checks having every label
+	Labels Labels
+	// Teams restricts the checks to the ones owned by the teams when it is not nil
+	Teams []string
+}
+
+type CheckPage struct {
+	Checks     []Check
+	Cursors    []string
+	PageInfo   PageInfo
+	TotalCount int64
+}
+
+func ListChecks(db *gorm.DB, filter CheckFilter, order Order, p Pagination) (*CheckPage, error) {
+	if order.Column.Name == "" {
+		order.Column = IdentifierColumn
+	}
+	query := db.Model(&Check{})
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Owner != "" {
+		query = query.Where("owner = ?", filter.Owner)
+	}
+	if filter.Teams != nil {
+		if len(filter.Teams) == 0 {
+			return &CheckPage{}, nil
+		}
+		query = query.Where("owner IN ?", filter.Teams)
+	}
+	query = whereLabels(query, db, "id", filter.Labels)
+	page := &CheckPage{}
+	result := query.Session(&gorm.Session{}).Count(&page.TotalCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	query, limit, backwards, err := paginate(query, order, p)
+	if err != nil {
+		return nil, err
+	}
+	var checks []Check
+	result = query.Preload("Labels").Find(&checks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	indexes, info := p.Trim(len(checks), limit, backwards)
+	page.PageInfo = info
+	for _, i := range indexes {
+		chk := checks[i]
+		page.Checks = append(page.Checks, chk)
+		page.Cursors = append(page.Cursors, EncodeCursor(chk.OrderValue(order.Column), chk.ID))
+	}
+	return page, nil
+}
+
+// OrderValue returns the value of the column the checks are sorted by
+func (c Check) OrderValue(column OrderColumn) interface{} {
+	switch column {
+	case StatusColumn:
+		return c.Status
+	case CreatedAtColumn:
+		return c.CreatedAt
+	case LatestCheckColumn:
+		return c.LatestCheck
+	default:
+		return c.Identifier
+	}
+}
+
+type ExecutionFilter struct {
+	CheckID  string
+	From     *time.Time
+	Until    *time.Time
+	Statuses []string
+}
+
+type ExecutionPage struct {
+	Executions []CheckExecution
+	Cursors    []string
+	PageInfo   PageInfo
+	TotalCount int64
+}
+
+func ListExecutions(db *gorm.DB, filter ExecutionFilter, order Order, p Pagination) (*ExecutionPage, error) {
+	if order.Column.Name == "" {
+		order.Column = CreatedAtColumn
+	}
+	query := db.Model(&CheckExecution{}).Where("check_id = ?", filter.CheckID)
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at <= ?", *filter.Until)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	page := &ExecutionPage{}
+	result := query.Session(&gorm.Session{}).Count(&page.TotalCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	query, limit, backwards, err := paginate(query, order, p)
+	if err != nil {
+		return nil, err
+	}
+	var executions []CheckExecution
+	result = query.Find(&executions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	indexes, info := p.Trim(len(executions), limit, backwards)
+	page.PageInfo = info
+	for _, i := range indexes {
+		execution := executions[i]
+		page.Executions = append(page.Executions, execution)
+		page.Cursors = append(page.Cursors, EncodeCursor(execution.OrderValue(order.Column), execution.ID))
+	}
+	return page, nil
+}
+
+// OrderValue returns the value of the column the executions are sorted by
+func (e CheckExecution) OrderValue(column OrderColumn) interface{} {
+	switch column {
+	case StatusColumn:
+		return e.Status
+	case LatencyColumn:
+		return int64(e.Latency)
+	default:
+		return e.CreatedAt
+	}
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	uuid "github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Resolution string
+
+const (
+	HourResolution Resolution = "hour"
+	DayResolution  Resolution = "day"
+)
+
+func (r Resolution) Duration() time.Duration {
+	switch r {
+	case DayResolution:
+		return 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+// CheckExecutionRollup aggregates the executions of a check over an hour or a day,
+// it outlives the raw executions according to the retention policy
+type CheckExecutionRollup struct {
+	ID          string     `gorm:"primaryKey"`
+	CheckID     string     `gorm:"index:idx_check_execution_rollup_bucket,unique"`
+	Resolution  Resolution `gorm:"size:16;index:idx_check_execution_rollup_bucket,unique"`
+	BucketStart time.Time  `gorm:"index:idx_check_execution_rollup_bucket,unique"`
+	Count       int64
+	Up          int64
+	Down        int64
+	MinLatency  time.Duration
+	AvgLatency  time.Duration
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	MaxLatency  time.Duration
+	CreatedAt   time.Time
+}
+
+func (CheckExecutionRollup) TableName() string {
+	return "check_execution_rollup"
+}
+
+// RetentionPolicy defines for how long each kind of data is kept, zero means forever
+type RetentionPolicy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+const defaultHourlyRetention = 90 * 24 * time.Hour
+
+func GetRetentionPolicy() RetentionPolicy {
+	policy := RetentionPolicy{
+		Hourly: defaultHourlyRetention,
+	}
+	if viper.IsSet("retention.raw") {
+		policy.Raw = viper.GetDuration("retention.raw")
+	}
+	if viper.IsSet("retention.hourly") {
+		policy.Hourly = viper.GetDuration("retention.hourly")
+	}
+	if viper.IsSet("retention.daily") {
+		policy.Daily = viper.GetDuration("retention.daily")
+	}
+	// daily rollups are computed from the raw executions, which must span at least a full day
+	if policy.Raw > 0 && policy.Raw < 48*time.Hour {
+		log.Warnf("retention.raw %s is too short, using 48h", policy.Raw)
+		policy.Raw = 48 * time.Hour
+	}
+	return policy
+}
+
+// rawCutoff returns the instant before which raw executions are read from the rollups
+func (p RetentionPolicy) rawCutoff(now time.Time) time.Time {
+	if p.Raw == 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.Raw).Truncate(time.Hour)
+}
+
+// hourlyCutoff returns the instant before which hourly rollups are replaced by daily rollups
+func (p RetentionPolicy) hourlyCutoff(now time.Time) time.Time {
+	if p.Hourly == 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.Hourly).Truncate(24 * time.Hour)
+}
+
+func (p RetentionPolicy) dailyCutoff(now time.Time) time.Time {
+	if p.Daily == 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.Daily).Truncate(24 * time.Hour)
+}
+
+// Compact rolls up the complete buckets of every check and prunes the executions and the
+// rollups that are past their retention
+func Compact(db *gorm.DB, policy RetentionPolicy, now time.Time) error {
+	var checkIDs []string
+	result := db.Model(&Check{}).Unscoped().Pluck("id", &checkIDs)
+	if result.Error != nil {
+		return result.Error
+	}
+	for _, checkID := range checkIDs {
+		for _, resolution := range []Resolution{HourResolution, DayResolution} {
+			err := rollup(db, checkID, resolution, now.Truncate(resolution.Duration()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if cutoff := policy.rawCutoff(now); !cutoff.IsZero() {
+		result = db.Where("created_at < ?", cutoff).Delete(&CheckExecution{})
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Debugf("Pruned %d executions older than %s", result.RowsAffected, cutoff)
+	}
+	if cutoff := policy.hourlyCutoff(now); !cutoff.IsZero() {
+		result = db.Where("resolution = ? AND bucket_start < ?", HourResolution, cutoff).Delete(&CheckExecutionRollup{})
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	if cutoff := policy.dailyCutoff(now); !cutoff.IsZero() {
+		result = db.Where("resolution = ? AND bucket_start < ?", DayResolution, cutoff).Delete(&CheckExecutionRollup{})
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
+
+// rollup aggregates every complete bucket of the check that has not been rolled up yet
+func rollup(db *gorm.DB, checkID string, resolution Resolution, until time.Time) error {
+	var latest []CheckExecutionRollup
+	result := db.Where("check_id = ? AND resolution = ?", checkID, resolution).
+		Order("bucket_start desc").
+		Limit(1).
+		Find(&latest)
+	if result.Error != nil {
+		return result.Error
+	}
+	from := time.Unix(0, 0)
+	if len(latest) > 0 {
+		from = latest[0].BucketStart.Add(resolution.Duration())
+	}
+	if !from.Before(until) {
+		return nil
+	}
+	buckets, err := getRawMetrics(db, checkID, from, until, resolution.Duration())
+	if err != nil {
+		return err
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+	var rollups []CheckExecutionRollup
+	for _, b := range buckets {
+		rollups = append(rollups, CheckExecutionRollup{
+			ID:          uuid.New().String(),
+			CheckID:     checkID,
+			Resolution:  resolution,
+			BucketStart: b.Time,
+			Count:       b.Count,
+			Up:          b.Up,
+			Down:        b.Down,
+			MinLatency:  b.MinLatency,
+			AvgLatency:  b.AvgLatency,
+			P50:         b.P50,
+			P95:         b.P95,
+			P99:         b.P99,
+			MaxLatency:  b.MaxLatency,
+		})
+	}
+	return db.CreateInBatches(rollups, 100).Error
+}
+
+// getRollupMetrics returns the rollups between from and until, including the rollup that
+// contains from when it isn't aligned to the resolution
+func getRollupMetrics(db *gorm.DB, checkID string, from time.Time, until time.Time, hourlyCutoff time.Time) ([]MetricsBucket, error) {
+	var rollups []CheckExecutionRollup
+	result := db.Where(
+		"check_id = ? AND bucket_start < ? AND ((resolution = ? AND bucket_start >= ? AND bucket_start >= ?) OR (resolution = ? AND bucket_start >= ? AND bucket_start < ?))",
+		checkID, until,
+		HourResolution, BucketStart(from, HourResolution.Duration()), hourlyCutoff,
+		DayResolution, BucketStart(from, DayResolution.Duration()), hourlyCutoff,
+	).Order("bucket_start").Find(&rollups)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var buckets []MetricsBucket
+	for _, r := range rollups {
+		buckets = append(buckets, MetricsBucket{
+			Time:       r.BucketStart,
+			Count:      r.Count,
+			MinLatency: r.MinLatency,
+			AvgLatency: r.AvgLatency,
+			P50:        r.P50,
+			P95:        r.P95,
+			P99:        r.P99,
+			MaxLatency: r.MaxLatency,
+			Up:         r.Up,
+			Down:       r.Down,
+		})
+	}
+	return buckets, nil
+}
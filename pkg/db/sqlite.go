@@ -0,0 +1,59 @@
+package db
+
+import (
+	"database/sql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/url"
+	"time"
+	// pure Go driver, registered as sqlite, so that the binary builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+// SQLite opens the database stored in the file at path, :memory: keeps it in memory
+func SQLite(path string) gorm.Dialector {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(1)")
+	// times are written in a format understood by the date functions of SQLite
+	params.Set("_time_format", "sqlite")
+	return sqliteDialector{
+		Dialector: sqlite.Dialector{
+			DriverName: "sqlite",
+			DSN:        "file:" + path + "?" + params.Encode(),
+		},
+	}
+}
+
+// sqliteDialector stores the times in UTC, SQLite compares them as text so that times with
+// different offsets would not be ordered
+type sqliteDialector struct {
+	sqlite.Dialector
+}
+
+func (d sqliteDialector) Initialize(db *gorm.DB) error {
+	err := d.Dialector.Initialize(db)
+	if err != nil {
+		return err
+	}
+	// SQLite allows a single writer, a single connection avoids failing on locks and keeps
+	// the in memory databases shared by every statement
+	if sqlDB, ok := db.ConnPool.(*sql.DB); ok {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return nil
+}
+
+func (d sqliteDialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	last := len(stmt.Vars) - 1
+	switch t := v.(type) {
+	case time.Time:
+		stmt.Vars[last] = t.UTC()
+	case *time.Time:
+		if t != nil {
+			stmt.Vars[last] = t.UTC()
+		}
+	}
+	d.Dialector.BindVarTo(writer, stmt, v)
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+type StatusPage struct {
+	ID          string `gorm:"primaryKey"`
+	WorkspaceID string `gorm:"index;index:idx_status_page_workspace_slug,unique,priority:1;size:191;not null;default:'default'"`
+	Slug        string `gorm:"index:idx_status_page_workspace_slug,unique,priority:2"`
+	Title       string
+	LogoURL     string
+	// Domain is unique across the workspaces since it alone selects the page served under it
+	Domain string `gorm:"index"`
+	// GroupByLabel groups the components without an explicit group by the value of this label
+	GroupByLabel string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	Components   []StatusPageComponent
+}
+
+func (StatusPage) TableName() string {
+	return "status_page"
+}
+
+func (p *StatusPage) BeforeCreate(tx *gorm.DB) error {
+	return checkQuota(tx, p.WorkspaceID, &StatusPage{}, "status pages", func(w Workspace) int {
+		return w.MaxStatusPages
+	})
+}
+
+// DeleteStatusPage soft deletes the status page, freeing its slug and its domain so that they
+// can be reused
+func DeleteStatusPage(db *gorm.DB, statusPage *StatusPage) error {
+	statusPage.Slug = statusPage.Slug + "-deleted-" + uuid.New().String()
+	statusPage.Domain = ""
+	result := db.Omit("Components").Save(statusPage)
+	if result.Error != nil {
+		return result.Error
+	}
+	return db.Delete(statusPage).Error
+}
+
+// DomainTaken tells whether another status page of any workspace has the custom domain of
+// the status page
+func DomainTaken(db *gorm.DB, statusPage *StatusPage) (bool, error) {
+	if statusPage.Domain == "" {
+		return false, nil
+	}
+	var count int64
+	result := db.WithContext(context.Background()).
+		Model(&StatusPage{}).
+		Where("domain = ? AND id <> ?", statusPage.Domain, statusPage.ID).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// StatusPageComponent publishes a check in a status page, components sharing
+// the same group are displayed together
+type StatusPageComponent struct {
+	ID           string `gorm:"primaryKey"`
+	StatusPageID string `gorm:"index"`
+	Name         string
+	GroupName    string
+	Position     int
+	CheckID      string
+	Check        Check
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (StatusPageComponent) TableName() string {
+	return "status_page_component"
+}
+
+// Group returns the name of the group the component is displayed in
+func (c StatusPageComponent) Group(groupByLabel string) string {
+	if c.GroupName != "" || groupByLabel == "" {
+		return c.GroupName
+	}
+	return c.Check.GetLabels()[groupByLabel]
+}
+
+// GroupedComponents returns the components sorted by group, keeping the order of
+// the components within each group
+func (p StatusPage) GroupedComponents() []StatusPageComponent {
+	components := make([]StatusPageComponent, len(p.Components))
+	copy(components, p.Components)
+	sort.SliceStable(components, func(i, j int) bool {
+		return components[i].Group(p.GroupByLabel) < components[j].Group(p.GroupByLabel)
+	})
+	return components
+}
+
+func preloadStatusPage(db *gorm.DB) *gorm.DB {
+	return db.Preload("Components", func(db *gorm.DB) *gorm.DB {
+		return db.Order("group_name, position, name")
+	}).Preload("Components.Check.Labels")
+}
+
+func GetStatusPage(db *gorm.DB, id string) (*StatusPage, error) {
+	page := &StatusPage{}
+	result := preloadStatusPage(db).Where("id = ?", id).First(page)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return page, nil
+}
+
+func GetStatusPages(db *gorm.DB) ([]StatusPage, error) {
+	var pages []StatusPage
+	result := preloadStatusPage(db).Order("slug").Find(&pages)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return pages, nil
+}
+
+// GetStatusPageBySlug returns the status page of the workspace with the slug
+func GetStatusPageBySlug(db *gorm.DB, workspaceID string, slug string) (*StatusPage, error) {
+	page := &StatusPage{}
+	result := preloadStatusPage(inWorkspace(db, workspaceID)).Where("slug = ?", slug).First(page)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return page, nil
+}
+
+// GetStatusPageByDomain returns the status page served under the given host,
+// falling back to the oldest page of the default workspace without a custom domain
+func GetStatusPageByDomain(db *gorm.DB, domain string) (*StatusPage, error) {
+	var pages []StatusPage
+	result := preloadStatusPage(db).Where("domain = ?", domain).Limit(1).Find(&pages)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(pages) == 0 {
+		result = preloadStatusPage(db).
+			Where("domain = ? AND workspace_id = ?", "", DefaultWorkspaceID).
+			Order("created_at").Limit(1).Find(&pages)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+	if len(pages) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &pages[0], nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	uuid "github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// ApiTokenPrefix identifies the bearer tokens issued by the server, unlike the JWTs of an OIDC issuer
+const ApiTokenPrefix = "sp_"
+
+// ApiToken is a static credential of the API, only the SHA-256 hash of the token is stored
+type ApiToken struct {
+	ID   string `gorm:"primaryKey"`
+	Name string `gorm:"index:idx_api_token_name,unique;size:191"`
+	Hash string `gorm:"index:idx_api_token_hash,unique;size:64"`
+	// WorkspaceID is the workspace accessed with the token
+	WorkspaceID string `gorm:"index;size:191;not null;default:'default'"`
+	// Role granted to the token, tokens issued before roles existed are admins
+	Role string `gorm:"not null;default:'ADMIN'"`
+	// Teams owning the checks accessed by the token unless it is an admin
+	Teams datatypes.JSON
+	// Hint holds the first characters of the token to tell tokens apart
+	Hint       string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (ApiToken) TableName() string {
+	return "api_token"
+}
+
+func (t ApiToken) GetTeams() ([]string, error) {
+	var teams []string
+	if len(t.Teams) == 0 {
+		return teams, nil
+	}
+	err := json.Unmarshal(t.Teams, &teams)
+	if err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (t *ApiToken) SetTeams(teams []string) error {
+	if len(teams) == 0 {
+		t.Teams = nil
+		return nil
+	}
+	data, err := json.Marshal(teams)
+	if err != nil {
+		return err
+	}
+	t.Teams = data
+	return nil
+}
+
+func (t ApiToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HashApiToken returns the hash stored for the token, tokens are random so a fast hash is enough
+func HashApiToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateApiToken() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return ApiTokenPrefix + hex.EncodeToString(secret), nil
+}
+
+// CreateApiToken generates the secret of the token and stores it, the returned secret can't
+// be recovered afterwards
+func CreateApiToken(db *gorm.DB, apiToken *ApiToken) (string, error) {
+	if apiToken.Name == "" {
+		return "", errors.New("Tokens require a name")
+	}
+	if apiToken.Role == "" {
+		return "", errors.New("Tokens require a role")
+	}
+	token, err := generateApiToken()
+	if err != nil {
+		return "", err
+	}
+	apiToken.ID = uuid.New().String()
+	apiToken.Hash = HashApiToken(token)
+	apiToken.Hint = token[:len(ApiTokenPrefix)+6]
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(apiToken)
+		if result.Error != nil {
+			return result.Error
+		}
+		return RecordAudit(inWorkspace(tx, apiToken.WorkspaceID), CreateOperation, ApiTokenResource, apiToken.ID, nil, apiToken)
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// FindApiToken returns the token stored for the bearer token, nil when it doesn't exist or expired
+func FindApiToken(db *gorm.DB, token string) (*ApiToken, error) {
+	if !strings.HasPrefix(token, ApiTokenPrefix) {
+		return nil, nil
+	}
+	var apiTokens []ApiToken
+	result := db.Where("hash = ?", HashApiToken(token)).Limit(1).Find(&apiTokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(apiTokens) == 0 {
+		return nil, nil
+	}
+	apiToken := apiTokens[0]
+	now := time.Now()
+	if apiToken.Expired(now) {
+		return nil, nil
+	}
+	// failing to record the usage must not reject the request
+	db.Model(&apiToken).UpdateColumn("last_used_at", now)
+	return &apiToken, nil
+}
+
+func GetApiTokens(db *gorm.DB) ([]ApiToken, error) {
+	var apiTokens []ApiToken
+	result := db.Order("name").Find(&apiTokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return apiTokens, nil
+}
+
+// DeleteApiToken revokes the token with the name
+func DeleteApiToken(db *gorm.DB, name string) error {
+	var apiTokens []ApiToken
+	result := db.Where("name = ?", name).Limit(1).Find(&apiTokens)
+	if result.Error != nil {
+		return result.Error
+	}
+	if len(apiTokens) == 0 {
+		return errors.Errorf("Token %s not found", name)
+	}
+	apiToken := apiTokens[0]
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&apiToken)
+		if result.Error != nil {
+			return result.Error
+		}
+		return RecordAudit(inWorkspace(tx, apiToken.WorkspaceID), DeleteOperation, ApiTokenResource, apiToken.ID, apiToken, nil)
+	})
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"time"
+)
+
+// DefaultWorkspaceID holds the resources created before workspaces existed and the ones
+// created without a workspace
+const DefaultWorkspaceID = "default"
+
+// Workspace isolates the checks, notification channels, status pages and API tokens of a tenant,
+// quotas set to 0 are unlimited
+type Workspace struct {
+	ID                      string `gorm:"primaryKey;size:191"`
+	Name                    string
+	MaxChecks               int `gorm:"not null;default:0"`
+	MaxStatusPages          int `gorm:"not null;default:0"`
+	MaxNotificationChannels int `gorm:"not null;default:0"`
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+func (Workspace) TableName() string {
+	return "workspace"
+}
+
+func GetWorkspace(db *gorm.DB, id string) (*Workspace, error) {
+	workspace := &Workspace{}
+	result := db.First(workspace, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.Errorf("Workspace %s not found", id)
+		}
+		return nil, result.Error
+	}
+	return workspace, nil
+}
+
+// SaveWorkspace creates or updates the workspace
+func SaveWorkspace(db *gorm.DB, workspace *Workspace) error {
+	var existing []Workspace
+	result := db.Where("id = ?", workspace.ID).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return result.Error
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Save(workspace)
+		if result.Error != nil {
+			return result.Error
+		}
+		tx = inWorkspace(tx, workspace.ID)
+		if len(existing) == 0 {
+			return RecordAudit(tx, CreateOperation, WorkspaceResource, workspace.ID, nil, workspace)
+		}
+		return RecordAudit(tx, UpdateOperation, WorkspaceResource, workspace.ID, existing[0], workspace)
+	})
+}
+
+func GetWorkspaces(db *gorm.DB) ([]Workspace, error) {
+	var workspaces []Workspace
+	result := db.Order("id").Find(&workspaces)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return workspaces, nil
+}
+
+// WorkspaceUsage counts the resources limited by the quotas of a workspace
+type WorkspaceUsage struct {
+	Checks               int64
+	StatusPages          int64
+	NotificationChannels int64
+}
+
+func GetWorkspaceUsage(db *gorm.DB, id string) (*WorkspaceUsage, error) {
+	usage := &WorkspaceUsage{}
+	counts := []struct {
+		model interface{}
+		count *int64
+	}{
+		{&Check{}, &usage.Checks},
+		{&StatusPage{}, &usage.StatusPages},
+		{&NotificationChannel{}, &usage.NotificationChannels},
+	}
+	for _, c := range counts {
+		result := db.Model(c.model).Where("workspace_id = ?", id).Count(c.count)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+	return usage, nil
+}
+
+// workspaceOrDefault returns the workspace of a row, rows created before workspaces existed
+// belong to the default workspace
+func workspaceOrDefault(workspaceID string) string {
+	if workspaceID == "" {
+		return DefaultWorkspaceID
+	}
+	return workspaceID
+}
+
+// inWorkspace returns the connection restricted to the workspace of a row, so that the rows
+// written along with it belong to the same workspace
+func inWorkspace(db *gorm.DB, workspaceID string) *gorm.DB {
+	return db.WithContext(WithWorkspace(db.Statement.Context, workspaceOrDefault(workspaceID)))
+}
+
+// checkQuota fails when the workspace already holds the maximum number of resources of the model
+func checkQuota(tx *gorm.DB, workspaceID string, model interface{}, resource string, quota func(Workspace) int) error {
+	workspaceID = workspaceOrDefault(workspaceID)
+	session := tx.Session(&gorm.Session{NewDB: true})
+	workspace, err := GetWorkspace(session, workspaceID)
+	if err != nil {
+		return err
+	}
+	max := quota(*workspace)
+	if max <= 0 {
+		return nil
+	}
+	var count int64
+	result := session.Model(model).Where("workspace_id = ?", workspaceID).Count(&count)
+	if result.Error != nil {
+		return result.Error
+	}
+	if count >= int64(max) {
+		return errors.Errorf("Quota exceeded, workspace %s is limited to %d %s", workspaceID, max, resource)
+	}
+	return nil
+}
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context restricting the statements of the connections using it to
+// the workspace, see WorkspacePlugin
+func WithWorkspace(ctx context.Context, workspaceID string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+func WorkspaceFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	workspaceID, ok := ctx.Value(workspaceKey{}).(string)
+	return workspaceID, ok
+}
+
+// WorkspacePlugin isolates the workspaces at the data access layer: the statements executed
+// with a context holding a workspace only read, update and delete the rows of the models with
+// a WorkspaceID that belong to the workspace, and the created rows are assigned to it.
+// Statements without workspace, such as the ones of the scheduler, access every workspace
+type WorkspacePlugin struct{}
+
+func (WorkspacePlugin) Name() string {
+	return "workspace"
+}
+
+func (WorkspacePlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	err := callback.Create().Before("gorm:before_create").Register("workspace:assign_create", assignWorkspace)
+	if err != nil {
+		return err
+	}
+	err = callback.Query().Before("gorm:query").Register("workspace:query", scopeWorkspace)
+	if err != nil {
+		return err
+	}
+	err = callback.Row().Before("gorm:row").Register("workspace:row", scopeWorkspace)
+	if err != nil {
+		return err
+	}
+	err = callback.Update().Before("gorm:before_update").Register("workspace:assign_update", assignWorkspace)
+	if err != nil {
+		return err
+	}
+	err = callback.Update().Before("gorm:update").Register("workspace:update", scopeWorkspace)
+	if err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:delete").Register("workspace:delete", scopeWorkspace)
+}
+
+func workspaceField(db *gorm.DB) (string, bool) {
+	workspaceID, ok := WorkspaceFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField("WorkspaceID") == nil {
+		return "", false
+	}
+	return workspaceID, true
+}
+
+func scopeWorkspace(db *gorm.DB) {
+	workspaceID, ok := workspaceField(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("WorkspaceID")
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: workspaceID},
+	}})
+}
+
+func assignWorkspace(db *gorm.DB) {
+	workspaceID, ok := workspaceField(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("WorkspaceID")
+	reflectValue := db.Statement.ReflectValue
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflectValue.Len(); i++ {
+			db.AddError(field.Set(reflect.Indirect(reflectValue.Index(i)), workspaceID))
+		}
+	case reflect.Struct:
+		db.AddError(field.Set(reflectValue, workspaceID))
+	}
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+type Type string
+
+const (
+	CheckStatusChanged Type = "CHECK_STATUS_CHANGED"
+	ExecutionRecorded  Type = "EXECUTION_RECORDED"
+	IncidentUpdated    Type = "INCIDENT_UPDATED"
+)
+
+// Event is published on the bus, ID references the check or the incident
+// and Payload holds the entity as stored in the database
+type Event struct {
+	Type    Type
+	ID      string
+	Payload interface{}
+}
+
+const subscriberBuffer = 64
+
+type subscriber struct {
+	types map[Type]bool
+	ch    chan Event
+}
+
+// Bus dispatches events in process, slow subscribers miss events instead of blocking publishers
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[int]*subscriber
+	next        int
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: map[int]*subscriber{},
+	}
+}
+
+// Subscribe returns a channel receiving the events of the given types
+// and the function that must be called to stop receiving them
+func (b *Bus) Subscribe(types ...Type) (<-chan Event, func()) {
+	s := &subscriber{
+		types: map[Type]bool{},
+		ch:    make(chan Event, subscriberBuffer),
+	}
+	for _, t := range types {
+		s.types[t] = true
+	}
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subscribers[id] = s
+	b.mu.Unlock()
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
+
+// Publish is a no-op on a nil bus so that callers without subscribers do not need one
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subscribers {
+		if !s.types[event.Type] {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			log.Warnf("Dropping event type=%s id=%s, subscriber is not keeping up", event.Type, event.ID)
+		}
+	}
+}
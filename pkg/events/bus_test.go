@@ -0,0 +1,76 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan Event) (Event, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}, false
+	}
+}
+
+func TestSubscribersReceiveTheirTypes(t *testing.T) {
+	b := NewBus()
+	checks, unsubscribeChecks := b.Subscribe(CheckStatusChanged)
+	defer unsubscribeChecks()
+	all, unsubscribeAll := b.Subscribe(CheckStatusChanged, ExecutionRecorded)
+	defer unsubscribeAll()
+
+	b.Publish(Event{Type: ExecutionRecorded, ID: "execution"})
+	b.Publish(Event{Type: CheckStatusChanged, ID: "check"})
+	if event, _ := receive(t, checks); event.ID != "check" {
+		t.Errorf("subscriber of the checks received %s, want check", event.ID)
+	}
+	for _, id := range []string{"execution", "check"} {
+		if event, _ := receive(t, all); event.ID != id {
+			t.Errorf("subscriber of every type received %s, want %s", event.ID, id)
+		}
+	}
+	if len(checks) != 0 {
+		t.Errorf("subscriber of the checks has %d events left, want none", len(checks))
+	}
+}
+
+func TestSlowSubscribersMissEvents(t *testing.T) {
+	b := NewBus()
+	ch, unsubscribe := b.Subscribe(CheckStatusChanged)
+	defer unsubscribe()
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer+10; i++ {
+			b.Publish(Event{Type: CheckStatusChanged})
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish() blocked on a subscriber that isn't reading")
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("subscriber received %d events, want the %d buffered", len(ch), subscriberBuffer)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	b := NewBus()
+	ch, unsubscribe := b.Subscribe(CheckStatusChanged)
+	unsubscribe()
+	unsubscribe()
+	if _, ok := receive(t, ch); ok {
+		t.Error("channel is open after unsubscribing")
+	}
+	b.Publish(Event{Type: CheckStatusChanged})
+	if len(b.subscribers) != 0 {
+		t.Errorf("bus has %d subscribers after unsubscribing, want none", len(b.subscribers))
+	}
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: CheckStatusChanged})
+}
@@ -0,0 +1,175 @@
+package exporter
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/metrics"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// pageSize is the number of checks read at once when scraped
+const pageSize = 500
+
+// CheckCollector exports the latest result of every check of every workspace when scraped,
+// the check labels listed in metrics.labels are exported as label_<name>
+type CheckCollector struct {
+	Storage storage.Storage
+	Labels  []string
+
+	up           *prometheus.Desc
+	latency      *prometheus.Desc
+	lastRun      *prometheus.Desc
+	certExpiry   *prometheus.Desc
+	scrapeErrors prometheus.Counter
+}
+
+// NewCheckCollector fails when two check labels are exported with the same name, such as a-b
+// and a_b
+func NewCheckCollector(store storage.Storage, labels []string) (*CheckCollector, error) {
+	// the identifiers are only unique within a workspace
+	variableLabels := []string{"workspace", "check", "type"}
+	exported := map[string]string{}
+	for _, label := range labels {
+		name := "label_" + invalidLabelChars.ReplaceAllString(label, "_")
+		if other, ok := exported[name]; ok {
+			return nil, errors.Errorf("Labels %q and %q are both exported as %s", other, label, name)
+		}
+		exported[name] = label
+		variableLabels = append(variableLabels, name)
+	}
+	return &CheckCollector{
+		Storage: store,
+		Labels:  labels,
+		up: prometheus.NewDesc(
+			"statuspage_check_up",
+			"Whether the check is up, only exported for checks that are up or down.",
+			variableLabels, nil,
+		),
+		latency: prometheus.NewDesc(
+			"statuspage_check_latency_seconds",
+			"Time taken by the latest execution of the check.",
+			variableLabels, nil,
+		),
+		lastRun: prometheus.NewDesc(
+			"statuspage_check_last_run_timestamp_seconds",
+			"Time of the latest execution of the check.",
+			variableLabels, nil,
+		),
+		certExpiry: prometheus.NewDesc(
+			"statuspage_check_cert_expiry_seconds",
+			"Seconds until the certificate presented to a tls check expires.",
+			variableLabels, nil,
+		),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "statuspage",
+			Name:      "check_scrape_errors_total",
+			Help:      "Scrapes that failed to read the checks.",
+		}),
+	}, nil
+}
+
+func (c *CheckCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.latency
+	ch <- c.lastRun
+	ch <- c.certExpiry
+	c.scrapeErrors.Describe(ch)
+}
+
+func (c *CheckCollector) Collect(ch chan<- prometheus.Metric) {
+	defer c.scrapeErrors.Collect(ch)
+	checks, err := c.checks(context.Background())
+	if err != nil {
+		log.Warnf("Failed to read the checks to export: %v", err)
+		c.scrapeErrors.Inc()
+		return
+	}
+	now := time.Now()
+	for _, chk := range checks {
+		if chk.LatestCheck.IsZero() {
+			continue
+		}
+		labelValues := []string{chk.WorkspaceID, chk.Identifier, string(chk.Type)}
+		checkLabels := chk.GetLabels()
+		for _, label := range c.Labels {
+			labelValues = append(labelValues, checkLabels[label])
+		}
+		switch chk.Status {
+		case db.Up:
+			ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1, labelValues...)
+		case db.Down:
+			ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0, labelValues...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, chk.Latency.Seconds(), labelValues...)
+		ch <- prometheus.MustNewConstMetric(c.lastRun, prometheus.GaugeValue, float64(chk.LatestCheck.UnixNano())/1e9, labelValues...)
+		if chk.CertExpiresAt != nil {
+			ch <- prometheus.MustNewConstMetric(c.certExpiry, prometheus.GaugeValue, chk.CertExpiresAt.Sub(now).Seconds(), labelValues...)
+		}
+	}
+}
+
+// checks reads the checks page by page, the context has no workspace so that every workspace
+// is read
+func (c *CheckCollector) checks(ctx context.Context) ([]db.Check, error) {
+	first := pageSize
+	p := db.Pagination{First: &first}
+	var checks []db.Check
+	for {
+		page, err := c.Storage.Checks().List(ctx, db.CheckFilter{}, db.Order{}, p)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, page.Checks...)
+		if !page.PageInfo.HasNextPage || len(page.Cursors) == 0 {
+			return checks, nil
+		}
+		p.After = &page.Cursors[len(page.Cursors)-1]
+	}
+}
+
+// Register serves the metrics at /metrics, which requires the bearer token set in metrics.token
+// unless metrics.anonymous is enabled. The metrics expose the identifiers of every workspace,
+// they aren't served when neither is set
+func Register(r gin.IRouter, store storage.Storage) error {
+	token := viper.GetString("metrics.token")
+	if token == "" && !viper.GetBool("metrics.anonymous") {
+		log.Infof("Metrics not served, set metrics.token or metrics.anonymous to serve them")
+		return nil
+	}
+	collector, err := NewCheckCollector(store, viper.GetStringSlice("metrics.labels"))
+	if err != nil {
+		return err
+	}
+	err = metrics.Registry.Register(collector)
+	if err != nil {
+		return err
+	}
+	handler := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+	r.GET("/metrics", requireToken(token), gin.WrapH(handler))
+	return nil
+}
+
+// requireToken rejects the requests without the bearer token, every request is accepted when
+// the token is empty
+func requireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}
+}
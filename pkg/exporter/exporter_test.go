@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCollectorLabelsTheWorkspace(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	store.SaveWorkspace(db.Workspace{ID: "acme", Name: "Acme"})
+	for _, result := range []struct {
+		workspace string
+		status    db.Status
+	}{
+		{db.DefaultWorkspaceID, db.Up},
+		{"acme", db.Down},
+	} {
+		ctx := db.WithWorkspace(context.Background(), result.workspace)
+		id := uuid.New().String()
+		chk := &db.Check{ID: id, Identifier: "web", Type: "http", Frequency: "@every 1m", Labels: db.NewCheckLabels(id, db.Labels{"team": result.workspace})}
+		err := store.Checks().Create(ctx, chk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chk.Status = result.status
+		chk.LatestCheck = time.Now()
+		err = store.Checks().SaveResult(ctx, chk)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := `
+# HELP statuspage_check_up Whether the check is up, only exported for checks that are up or down.
+# TYPE statuspage_check_up gauge
+statuspage_check_up{check="web",label_team="acme",type="http",workspace="acme"} 0
+statuspage_check_up{check="web",label_team="default",type="http",workspace="default"} 1
+`
+	collector, err := NewCheckCollector(store, []string{"team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testutil.CollectAndCompare(collector, strings.NewReader(expected), "statuspage_check_up")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckCollectorRejectsCollidingLabels(t *testing.T) {
+	tests := []struct {
+		labels []string
+		valid  bool
+	}{
+		{[]string{"team", "env"}, true},
+		{[]string{"app.kubernetes.io/name"}, true},
+		{[]string{"a-b", "a_b"}, false},
+		{[]string{"team", "team"}, false},
+	}
+	for _, tt := range tests {
+		_, err := NewCheckCollector(storage.NewMemoryStorage(), tt.labels)
+		if (err == nil) != tt.valid {
+			t.Errorf("NewCheckCollector(%v) error = %v, want valid %v", tt.labels, err, tt.valid)
+		}
+	}
+}
+
+func TestMetricsRequireToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Run("disabled", func(t *testing.T) {
+		t.Cleanup(viper.Reset)
+		r := gin.New()
+		err := Register(r, storage.NewMemoryStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET /metrics without metrics.token nor metrics.anonymous = %d, want 404", w.Code)
+		}
+	})
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		status        int
+	}{
+		{"anonymous", "", "", http.StatusOK},
+		{"without token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer other", http.StatusUnauthorized},
+		{"token", "secret", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/metrics", requireToken(tt.token), func(c *gin.Context) {
+				c.String(http.StatusOK, "statuspage_check_up 1")
+			})
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("GET /metrics = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@
 package models
 
 import (
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
@@ -10,43 +13,167 @@ type Check interface {
 	IsCheck()
 }
 
+type ExecutionStats interface {
+	IsExecutionStats()
+}
+
+type AddStatusPageComponentInput struct {
+	StatusPageID string  `json:"statusPageId"`
+	CheckID      string  `json:"checkId"`
+	Name         string  `json:"name"`
+	Group        *string `json:"group"`
+	Position     *int    `json:"position"`
+}
+
+type AuditEntry struct {
+	ID           string         `json:"id"`
+	Actor        string         `json:"actor"`
+	ActorID      string         `json:"actorId"`
+	ActorMethod  string         `json:"actorMethod"`
+	Operation    AuditOperation `json:"operation"`
+	ResourceType string         `json:"resourceType"`
+	ResourceID   string         `json:"resourceId"`
+	Before       *string        `json:"before"`
+	After        *string        `json:"after"`
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+type AuditEntryConnection struct {
+	Edges      []*AuditEntryEdge `json:"edges"`
+	PageInfo   *PageInfo         `json:"pageInfo"`
+	TotalCount int               `json:"totalCount"`
+}
+
+type AuditEntryEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *AuditEntry `json:"node"`
+}
+
+type AuditFilter struct {
+	ResourceType *string          `json:"resourceType"`
+	ResourceID   *string          `json:"resourceId"`
+	Actor        *string          `json:"actor"`
+	Operations   []AuditOperation `json:"operations"`
+	From         *time.Time       `json:"from"`
+	Until        *time.Time       `json:"until"`
+}
+
+type Certificate struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	DNSNames     []string  `json:"dnsNames"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	Pem          string    `json:"pem"`
+}
+
+type CheckConnection struct {
+	Edges      []*CheckEdge `json:"edges"`
+	PageInfo   *PageInfo    `json:"pageInfo"`
+	TotalCount int          `json:"totalCount"`
+}
+
+type CheckEdge struct {
+	Cursor string `json:"cursor"`
+	Node   Check  `json:"node"`
+}
+
 type CheckExecution struct {
-	ID            string    `json:"id"`
-	ExecutionTime time.Time `json:"executionTime"`
-	Message       string    `json:"message"`
-	ErrorMsg      string    `json:"errorMsg"`
-	Status        string    `json:"status"`
+	ID            string         `json:"id"`
+	ExecutionTime time.Time      `json:"executionTime"`
+	Message       string         `json:"message"`
+	ErrorMsg      string         `json:"errorMsg"`
+	Status        string         `json:"status"`
+	Latency       float64        `json:"latency"`
+	Maintenance   bool           `json:"maintenance"`
+	Stats         ExecutionStats `json:"stats"`
+}
+
+type CheckExecutionConnection struct {
+	Edges      []*CheckExecutionEdge `json:"edges"`
+	PageInfo   *PageInfo             `json:"pageInfo"`
+	TotalCount int                   `json:"totalCount"`
+}
+
+type CheckExecutionEdge struct {
+	Cursor string          `json:"cursor"`
+	Node   *CheckExecution `json:"node"`
+}
+
+type CheckFilter struct {
+	Types    []CheckType   `json:"types"`
+	Statuses []string      `json:"statuses"`
+	Owner    *string       `json:"owner"`
+	Labels   []*LabelInput `json:"labels"`
+}
+
+type CheckOrder struct {
+	Field     CheckOrderField `json:"field"`
+	Direction OrderDirection  `json:"direction"`
 }
 
 type CreateHTTPCheckInput struct {
-	ID        string `json:"id"`
-	Frecuency string `json:"frecuency"`
-	URL       string `json:"url"`
+	ID          string        `json:"id"`
+	Frecuency   string        `json:"frecuency"`
+	URL         string        `json:"url"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
 }
 
 type CreateIcmpCheckInput struct {
-	ID        string `json:"id"`
-	Frecuency string `json:"frecuency"`
-	Address   string `json:"address"`
+	ID          string        `json:"id"`
+	Frecuency   string        `json:"frecuency"`
+	Address     string        `json:"address"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
+}
+
+type CreateIncidentInput struct {
+	Title        string         `json:"title"`
+	Status       IncidentStatus `json:"status"`
+	Impact       IncidentImpact `json:"impact"`
+	Body         string         `json:"body"`
+	ComponentIds []string       `json:"componentIds"`
 }
 
 type CreateTCPCheckInput struct {
-	ID        string `json:"id"`
-	Frecuency string `json:"frecuency"`
-	Address   string `json:"address"`
+	ID          string        `json:"id"`
+	Frecuency   string        `json:"frecuency"`
+	Address     string        `json:"address"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
 }
 
 type CreateTLSCheckInput struct {
-	ID        string  `json:"id"`
-	Frecuency string  `json:"frecuency"`
-	Address   string  `json:"address"`
-	RootCAs   *string `json:"rootCAs"`
+	ID          string        `json:"id"`
+	Frecuency   string        `json:"frecuency"`
+	Address     string        `json:"address"`
+	RootCAs     *string       `json:"rootCAs"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
 }
 
 type DeleteResponse struct {
 	ID string `json:"id"`
 }
 
+type DeletedCheck struct {
+	Check              Check      `json:"check"`
+	OriginalIdentifier string     `json:"originalIdentifier"`
+	DeletedAt          time.Time  `json:"deletedAt"`
+	PurgeAt            *time.Time `json:"purgeAt"`
+}
+
+type ExecutionOrder struct {
+	Field     ExecutionOrderField `json:"field"`
+	Direction OrderDirection      `json:"direction"`
+}
+
 type HTTPCheck struct {
 	ID          string     `json:"id"`
 	Identifier  string     `json:"identifier"`
@@ -56,10 +183,27 @@ type HTTPCheck struct {
 	LatestCheck *time.Time `json:"latestCheck"`
 	Message     string     `json:"message"`
 	ErrorMsg    string     `json:"errorMsg"`
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	Labels      []*Label   `json:"labels"`
 }
 
 func (HTTPCheck) IsCheck() {}
 
+type HTTPExecutionStats struct {
+	TimeTaken     float64       `json:"timeTaken"`
+	StatusCode    int           `json:"statusCode"`
+	ContentLength int           `json:"contentLength"`
+	Headers       []*HTTPHeader `json:"headers"`
+}
+
+func (HTTPExecutionStats) IsExecutionStats() {}
+
+type HTTPHeader struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
 type IcmpCheck struct {
 	ID          string     `json:"id"`
 	Identifier  string     `json:"identifier"`
@@ -69,14 +213,170 @@ type IcmpCheck struct {
 	LatestCheck *time.Time `json:"latestCheck"`
 	Message     string     `json:"message"`
 	ErrorMsg    string     `json:"errorMsg"`
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	Labels      []*Label   `json:"labels"`
 }
 
 func (IcmpCheck) IsCheck() {}
 
+type IcmpExecutionStats struct {
+	TimeTaken   float64 `json:"timeTaken"`
+	Address     string  `json:"address"`
+	PacketsSent int     `json:"packetsSent"`
+	PacketsRecv int     `json:"packetsRecv"`
+	PacketLoss  float64 `json:"packetLoss"`
+	MinRtt      float64 `json:"minRtt"`
+	AvgRtt      float64 `json:"avgRtt"`
+	MaxRtt      float64 `json:"maxRtt"`
+	StdDevRtt   float64 `json:"stdDevRtt"`
+}
+
+func (IcmpExecutionStats) IsExecutionStats() {}
+
+type Identity struct {
+	Subject   string   `json:"subject"`
+	Name      string   `json:"name"`
+	Role      Role     `json:"role"`
+	Workspace string   `json:"workspace"`
+	Teams     []string `json:"teams"`
+}
+
+type Incident struct {
+	ID         string                 `json:"id"`
+	Title      string                 `json:"title"`
+	Status     IncidentStatus         `json:"status"`
+	Impact     IncidentImpact         `json:"impact"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	UpdatedAt  time.Time              `json:"updatedAt"`
+	ResolvedAt *time.Time             `json:"resolvedAt"`
+	Components []*StatusPageComponent `json:"components"`
+	Updates    []*IncidentUpdate      `json:"updates"`
+}
+
+type IncidentUpdate struct {
+	ID        string         `json:"id"`
+	Status    IncidentStatus `json:"status"`
+	Body      string         `json:"body"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+type Label struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type LabelInput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type MaintenanceOccurrence struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type MaintenanceWindow struct {
+	ID              string                   `json:"id"`
+	Title           string                   `json:"title"`
+	Description     string                   `json:"description"`
+	Mode            MaintenanceMode          `json:"mode"`
+	StartsAt        *time.Time               `json:"startsAt"`
+	EndsAt          *time.Time               `json:"endsAt"`
+	Schedule        string                   `json:"schedule"`
+	Duration        string                   `json:"duration"`
+	Timezone        string                   `json:"timezone"`
+	Active          bool                     `json:"active"`
+	Checks          []Check                  `json:"checks"`
+	Labels          []*Label                 `json:"labels"`
+	NextOccurrences []*MaintenanceOccurrence `json:"nextOccurrences"`
+}
+
+type MaintenanceWindowInput struct {
+	Title       string          `json:"title"`
+	Description *string         `json:"description"`
+	Mode        MaintenanceMode `json:"mode"`
+	StartsAt    *time.Time      `json:"startsAt"`
+	EndsAt      *time.Time      `json:"endsAt"`
+	Schedule    *string         `json:"schedule"`
+	Duration    *string         `json:"duration"`
+	Timezone    *string         `json:"timezone"`
+	CheckIds    []string        `json:"checkIds"`
+	Labels      []*LabelInput   `json:"labels"`
+}
+
+type MetricsBucket struct {
+	Time       time.Time `json:"time"`
+	MinLatency float64   `json:"minLatency"`
+	AvgLatency float64   `json:"avgLatency"`
+	P50        float64   `json:"p50"`
+	P95        float64   `json:"p95"`
+	P99        float64   `json:"p99"`
+	MaxLatency float64   `json:"maxLatency"`
+	Up         int       `json:"up"`
+	Down       int       `json:"down"`
+}
+
+type NotificationChannel struct {
+	ID     string                  `json:"id"`
+	Name   string                  `json:"name"`
+	Type   NotificationChannelType `json:"type"`
+	URL    string                  `json:"url"`
+	Labels []*Label                `json:"labels"`
+}
+
+type NotificationChannelInput struct {
+	Name   string                  `json:"name"`
+	Type   NotificationChannelType `json:"type"`
+	URL    string                  `json:"url"`
+	Labels []*LabelInput           `json:"labels"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
 type PollResult struct {
 	Took int `json:"took"`
 }
 
+type PostIncidentUpdateInput struct {
+	IncidentID   string          `json:"incidentId"`
+	Status       IncidentStatus  `json:"status"`
+	Body         string          `json:"body"`
+	Impact       *IncidentImpact `json:"impact"`
+	ComponentIds []string        `json:"componentIds"`
+}
+
+type StatusPage struct {
+	ID           string                 `json:"id"`
+	Slug         string                 `json:"slug"`
+	Title        string                 `json:"title"`
+	LogoURL      string                 `json:"logoUrl"`
+	Domain       string                 `json:"domain"`
+	GroupByLabel string                 `json:"groupByLabel"`
+	Components   []*StatusPageComponent `json:"components"`
+}
+
+type StatusPageComponent struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Group    string `json:"group"`
+	Position int    `json:"position"`
+	Check    Check  `json:"check"`
+}
+
+type StatusPageInput struct {
+	Slug         string  `json:"slug"`
+	Title        string  `json:"title"`
+	LogoURL      *string `json:"logoUrl"`
+	Domain       *string `json:"domain"`
+	GroupByLabel *string `json:"groupByLabel"`
+}
+
 type TCPCheck struct {
 	ID          string     `json:"id"`
 	Identifier  string     `json:"identifier"`
@@ -86,10 +386,20 @@ type TCPCheck struct {
 	LatestCheck *time.Time `json:"latestCheck"`
 	Message     string     `json:"message"`
 	ErrorMsg    string     `json:"errorMsg"`
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	Labels      []*Label   `json:"labels"`
 }
 
 func (TCPCheck) IsCheck() {}
 
+type TCPExecutionStats struct {
+	TimeTaken  float64 `json:"timeTaken"`
+	RemoteAddr string  `json:"remoteAddr"`
+}
+
+func (TCPExecutionStats) IsExecutionStats() {}
+
 type TLSCheck struct {
 	ID          string     `json:"id"`
 	Identifier  string     `json:"identifier"`
@@ -99,6 +409,512 @@ type TLSCheck struct {
 	LatestCheck *time.Time `json:"latestCheck"`
 	Message     string     `json:"message"`
 	ErrorMsg    string     `json:"errorMsg"`
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	Labels      []*Label   `json:"labels"`
 }
 
 func (TLSCheck) IsCheck() {}
+
+type TLSExecutionStats struct {
+	TimeTaken    float64        `json:"timeTaken"`
+	Certificates []*Certificate `json:"certificates"`
+}
+
+func (TLSExecutionStats) IsExecutionStats() {}
+
+type UpdateHTTPCheckInput struct {
+	ID          *string       `json:"id"`
+	Frecuency   *string       `json:"frecuency"`
+	URL         *string       `json:"url"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
+}
+
+type UpdateIcmpCheckInput struct {
+	ID          *string       `json:"id"`
+	Frecuency   *string       `json:"frecuency"`
+	Address     *string       `json:"address"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
+}
+
+type UpdateTCPCheckInput struct {
+	ID          *string       `json:"id"`
+	Frecuency   *string       `json:"frecuency"`
+	Address     *string       `json:"address"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
+}
+
+type UpdateTLSCheckInput struct {
+	ID          *string       `json:"id"`
+	Frecuency   *string       `json:"frecuency"`
+	Address     *string       `json:"address"`
+	RootCAs     *string       `json:"rootCAs"`
+	Description *string       `json:"description"`
+	Owner       *string       `json:"owner"`
+	Labels      []*LabelInput `json:"labels"`
+}
+
+type Uptime struct {
+	Up         int      `json:"up"`
+	Down       int      `json:"down"`
+	Percentage *float64 `json:"percentage"`
+}
+
+type Workspace struct {
+	ID     string           `json:"id"`
+	Name   string           `json:"name"`
+	Quotas *WorkspaceQuotas `json:"quotas"`
+	Usage  *WorkspaceQuotas `json:"usage"`
+}
+
+type WorkspaceQuotas struct {
+	Checks               int `json:"checks"`
+	StatusPages          int `json:"statusPages"`
+	NotificationChannels int `json:"notificationChannels"`
+}
+
+type AuditOperation string
+
+const (
+	AuditOperationCreate  AuditOperation = "CREATE"
+	AuditOperationUpdate  AuditOperation = "UPDATE"
+	AuditOperationDelete  AuditOperation = "DELETE"
+	AuditOperationPause   AuditOperation = "PAUSE"
+	AuditOperationResume  AuditOperation = "RESUME"
+	AuditOperationRestore AuditOperation = "RESTORE"
+	AuditOperationPurge   AuditOperation = "PURGE"
+)
+
+var AllAuditOperation = []AuditOperation{
+	AuditOperationCreate,
+	AuditOperationUpdate,
+	AuditOperationDelete,
+	AuditOperationPause,
+	AuditOperationResume,
+	AuditOperationRestore,
+	AuditOperationPurge,
+}
+
+func (e AuditOperation) IsValid() bool {
+	switch e {
+	case AuditOperationCreate, AuditOperationUpdate, AuditOperationDelete, AuditOperationPause, AuditOperationResume, AuditOperationRestore, AuditOperationPurge:
+		return true
+	}
+	return false
+}
+
+func (e AuditOperation) String() string {
+	return string(e)
+}
+
+func (e *AuditOperation) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditOperation(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditOperation", str)
+	}
+	return nil
+}
+
+func (e AuditOperation) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type CheckOrderField string
+
+const (
+	CheckOrderFieldIDEntifier  CheckOrderField = "IDENTIFIER"
+	CheckOrderFieldStatus      CheckOrderField = "STATUS"
+	CheckOrderFieldCreatedAt   CheckOrderField = "CREATED_AT"
+	CheckOrderFieldLatestCheck CheckOrderField = "LATEST_CHECK"
+)
+
+var AllCheckOrderField = []CheckOrderField{
+	CheckOrderFieldIDEntifier,
+	CheckOrderFieldStatus,
+	CheckOrderFieldCreatedAt,
+	CheckOrderFieldLatestCheck,
+}
+
+func (e CheckOrderField) IsValid() bool {
+	switch e {
+	case CheckOrderFieldIDEntifier, CheckOrderFieldStatus, CheckOrderFieldCreatedAt, CheckOrderFieldLatestCheck:
+		return true
+	}
+	return false
+}
+
+func (e CheckOrderField) String() string {
+	return string(e)
+}
+
+func (e *CheckOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CheckOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CheckOrderField", str)
+	}
+	return nil
+}
+
+func (e CheckOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type CheckType string
+
+const (
+	CheckTypeHTTP CheckType = "HTTP"
+	CheckTypeTCP  CheckType = "TCP"
+	CheckTypeTLS  CheckType = "TLS"
+	CheckTypeIcmp CheckType = "ICMP"
+)
+
+var AllCheckType = []CheckType{
+	CheckTypeHTTP,
+	CheckTypeTCP,
+	CheckTypeTLS,
+	CheckTypeIcmp,
+}
+
+func (e CheckType) IsValid() bool {
+	switch e {
+	case CheckTypeHTTP, CheckTypeTCP, CheckTypeTLS, CheckTypeIcmp:
+		return true
+	}
+	return false
+}
+
+func (e CheckType) String() string {
+	return string(e)
+}
+
+func (e *CheckType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CheckType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CheckType", str)
+	}
+	return nil
+}
+
+func (e CheckType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ExecutionOrderField string
+
+const (
+	ExecutionOrderFieldExecutionTime ExecutionOrderField = "EXECUTION_TIME"
+	ExecutionOrderFieldStatus        ExecutionOrderField = "STATUS"
+	ExecutionOrderFieldLatency       ExecutionOrderField = "LATENCY"
+)
+
+var AllExecutionOrderField = []ExecutionOrderField{
+	ExecutionOrderFieldExecutionTime,
+	ExecutionOrderFieldStatus,
+	ExecutionOrderFieldLatency,
+}
+
+func (e ExecutionOrderField) IsValid() bool {
+	switch e {
+	case ExecutionOrderFieldExecutionTime, ExecutionOrderFieldStatus, ExecutionOrderFieldLatency:
+		return true
+	}
+	return false
+}
+
+func (e ExecutionOrderField) String() string {
+	return string(e)
+}
+
+func (e *ExecutionOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ExecutionOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ExecutionOrderField", str)
+	}
+	return nil
+}
+
+func (e ExecutionOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type IncidentImpact string
+
+const (
+	IncidentImpactNone     IncidentImpact = "NONE"
+	IncidentImpactMinor    IncidentImpact = "MINOR"
+	IncidentImpactMajor    IncidentImpact = "MAJOR"
+	IncidentImpactCritical IncidentImpact = "CRITICAL"
+)
+
+var AllIncidentImpact = []IncidentImpact{
+	IncidentImpactNone,
+	IncidentImpactMinor,
+	IncidentImpactMajor,
+	IncidentImpactCritical,
+}
+
+func (e IncidentImpact) IsValid() bool {
+	switch e {
+	case IncidentImpactNone, IncidentImpactMinor, IncidentImpactMajor, IncidentImpactCritical:
+		return true
+	}
+	return false
+}
+
+func (e IncidentImpact) String() string {
+	return string(e)
+}
+
+func (e *IncidentImpact) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IncidentImpact(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IncidentImpact", str)
+	}
+	return nil
+}
+
+func (e IncidentImpact) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type IncidentStatus string
+
+const (
+	IncidentStatusInvestigating IncidentStatus = "INVESTIGATING"
+	IncidentStatusIDEntified    IncidentStatus = "IDENTIFIED"
+	IncidentStatusMonitoring    IncidentStatus = "MONITORING"
+	IncidentStatusResolved      IncidentStatus = "RESOLVED"
+)
+
+var AllIncidentStatus = []IncidentStatus{
+	IncidentStatusInvestigating,
+	IncidentStatusIDEntified,
+	IncidentStatusMonitoring,
+	IncidentStatusResolved,
+}
+
+func (e IncidentStatus) IsValid() bool {
+	switch e {
+	case IncidentStatusInvestigating, IncidentStatusIDEntified, IncidentStatusMonitoring, IncidentStatusResolved:
+		return true
+	}
+	return false
+}
+
+func (e IncidentStatus) String() string {
+	return string(e)
+}
+
+func (e *IncidentStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IncidentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IncidentStatus", str)
+	}
+	return nil
+}
+
+func (e IncidentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type MaintenanceMode string
+
+const (
+	MaintenanceModePause MaintenanceMode = "PAUSE"
+	MaintenanceModeFlag  MaintenanceMode = "FLAG"
+)
+
+var AllMaintenanceMode = []MaintenanceMode{
+	MaintenanceModePause,
+	MaintenanceModeFlag,
+}
+
+func (e MaintenanceMode) IsValid() bool {
+	switch e {
+	case MaintenanceModePause, MaintenanceModeFlag:
+		return true
+	}
+	return false
+}
+
+func (e MaintenanceMode) String() string {
+	return string(e)
+}
+
+func (e *MaintenanceMode) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MaintenanceMode(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MaintenanceMode", str)
+	}
+	return nil
+}
+
+func (e MaintenanceMode) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationChannelType string
+
+const (
+	NotificationChannelTypeSLACk   NotificationChannelType = "SLACK"
+	NotificationChannelTypeWebhook NotificationChannelType = "WEBHOOK"
+)
+
+var AllNotificationChannelType = []NotificationChannelType{
+	NotificationChannelTypeSLACk,
+	NotificationChannelTypeWebhook,
+}
+
+func (e NotificationChannelType) IsValid() bool {
+	switch e {
+	case NotificationChannelTypeSLACk, NotificationChannelTypeWebhook:
+		return true
+	}
+	return false
+}
+
+func (e NotificationChannelType) String() string {
+	return string(e)
+}
+
+func (e *NotificationChannelType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationChannelType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationChannelType", str)
+	}
+	return nil
+}
+
+func (e NotificationChannelType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+var AllOrderDirection = []OrderDirection{
+	OrderDirectionAsc,
+	OrderDirectionDesc,
+}
+
+func (e OrderDirection) IsValid() bool {
+	switch e {
+	case OrderDirectionAsc, OrderDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e OrderDirection) String() string {
+	return string(e)
+}
+
+func (e *OrderDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderDirection", str)
+	}
+	return nil
+}
+
+func (e OrderDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleViewer Role = "VIEWER"
+	RoleEditor Role = "EDITOR"
+	RoleAdmin  Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleViewer,
+	RoleEditor,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleViewer, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
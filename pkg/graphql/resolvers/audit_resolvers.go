@@ -0,0 +1,59 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+)
+
+func (q queryResolver) AuditLog(ctx context.Context, filter *models.AuditFilter, first *int, after *string, last *int, before *string) (*models.AuditEntryConnection, error) {
+	auditFilter := db.AuditFilter{}
+	if filter != nil {
+		auditFilter.ResourceType = db.ResourceType(stringValue(filter.ResourceType))
+		auditFilter.ResourceID = stringValue(filter.ResourceID)
+		auditFilter.Actor = stringValue(filter.Actor)
+		auditFilter.From = filter.From
+		auditFilter.Until = filter.Until
+		for _, operation := range filter.Operations {
+			auditFilter.Operations = append(auditFilter.Operations, string(operation))
+		}
+	}
+	page, err := q.Storage.AuditLog().List(callerContext(ctx), auditFilter, db.Pagination{First: first, After: after, Last: last, Before: before})
+	if err != nil {
+		return nil, err
+	}
+	connection := &models.AuditEntryConnection{
+		Edges:      []*models.AuditEntryEdge{},
+		PageInfo:   mapPageInfo(page.PageInfo, page.Cursors),
+		TotalCount: int(page.TotalCount),
+	}
+	for i, entry := range page.Entries {
+		connection.Edges = append(connection.Edges, &models.AuditEntryEdge{
+			Cursor: page.Cursors[i],
+			Node:   mapAuditEntry(entry),
+		})
+	}
+	return connection, nil
+}
+
+func mapAuditEntry(entry db.AuditEntry) *models.AuditEntry {
+	modelEntry := &models.AuditEntry{
+		ID:           entry.ID,
+		Actor:        entry.Actor,
+		ActorID:      entry.ActorID,
+		ActorMethod:  entry.ActorMethod,
+		Operation:    models.AuditOperation(entry.Operation),
+		ResourceType: string(entry.ResourceType),
+		ResourceID:   entry.ResourceID,
+		CreatedAt:    entry.CreatedAt,
+	}
+	if len(entry.Before) > 0 {
+		before := string(entry.Before)
+		modelEntry.Before = &before
+	}
+	if len(entry.After) > 0 {
+		after := string(entry.After)
+		modelEntry.After = &after
+	}
+	return modelEntry
+}
@@ -0,0 +1,109 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// HasRole implements the @hasRole directive
+func HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, role models.Role) (interface{}, error) {
+	err := auth.IdentityFromContext(ctx).HasRole(auth.Role(role))
+	if err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+func (q queryResolver) Me(ctx context.Context) (*models.Identity, error) {
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil {
+		return nil, nil
+	}
+	teams := identity.Teams
+	if teams == nil {
+		teams = []string{}
+	}
+	return &models.Identity{
+		Subject:   identity.Subject,
+		Name:      identity.Name,
+		Role:      models.Role(identity.Role),
+		Workspace: identity.GetWorkspace(),
+		Teams:     teams,
+	}, nil
+}
+
+// auditActor returns the actor recorded in the audit log for the changes of the identity
+func auditActor(identity *auth.Identity) db.Actor {
+	if identity == nil {
+		return db.Actor{Name: "anonymous", Method: "anonymous"}
+	}
+	return db.Actor{ID: identity.Subject, Name: identity.Name, Method: string(identity.Method)}
+}
+
+// authorizeCheck hides the checks of other teams and workspaces as if they didn't exist
+func authorizeCheck(ctx context.Context, chk db.Check) error {
+	identity := auth.IdentityFromContext(ctx)
+	if !inWorkspace(ctx, chk.WorkspaceID) || !identity.CanAccess(chk.Owner) {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// inWorkspace returns true when the row belongs to the workspace of the caller
+func inWorkspace(ctx context.Context, workspaceID string) bool {
+	if workspaceID == "" {
+		workspaceID = db.DefaultWorkspaceID
+	}
+	return workspaceID == auth.IdentityFromContext(ctx).GetWorkspace()
+}
+
+// findAuthorizedCheck returns the check, including deleted checks
+// whose history is still available
+func findAuthorizedCheck(ctx context.Context, store storage.Storage, id string) (db.Check, error) {
+	chk, err := store.Checks().GetWithDeleted(ctx, id)
+	if err != nil {
+		return db.Check{}, err
+	}
+	return *chk, authorizeCheck(ctx, *chk)
+}
+
+// authorizeOwner returns the owner of a check created or updated by the caller, checks of
+// scoped identities must be owned by one of their teams and default to their only team
+func authorizeOwner(ctx context.Context, owner *string) (*string, error) {
+	identity := auth.IdentityFromContext(ctx)
+	if !identity.Scoped() {
+		return owner, nil
+	}
+	if identity == nil {
+		return nil, errors.New("Forbidden, anonymous callers don't own checks")
+	}
+	if owner == nil || *owner == "" {
+		if len(identity.Teams) == 1 {
+			return &identity.Teams[0], nil
+		}
+		return nil, errors.Errorf("The owner is required, expected one of your teams: %s", strings.Join(identity.Teams, ", "))
+	}
+	if !identity.CanAccess(*owner) {
+		return nil, errors.Errorf("Forbidden, %s is not one of your teams", *owner)
+	}
+	return owner, nil
+}
+
+// teamsFilter returns the teams whose checks are visible, nil when every check is visible and
+// empty for anonymous callers
+func teamsFilter(ctx context.Context) []string {
+	identity := auth.IdentityFromContext(ctx)
+	if !identity.Scoped() {
+		return nil
+	}
+	if identity == nil {
+		return []string{}
+	}
+	return append([]string{}, identity.Teams...)
+}
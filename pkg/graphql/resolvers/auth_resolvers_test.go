@@ -0,0 +1,122 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/generated"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"strings"
+	"testing"
+)
+
+// newTeamChecks returns a resolver whose storage holds a check deleted and a check kept for
+// every team
+func newTeamChecks(t *testing.T, teams ...string) *Resolver {
+	t.Helper()
+	store := storage.NewMemoryStorage()
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	for _, team := range teams {
+		for _, identifier := range []string{team, team + "-deleted"} {
+			chk := &db.Check{Identifier: identifier, Type: "http", Data: []byte(`{"url":"https://example.com"}`), Frequency: "@every 1m", Owner: team}
+			err := store.Checks().Create(ctx, chk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identifier != team {
+				err = store.Checks().Delete(ctx, chk)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	return &Resolver{Storage: store}
+}
+
+func TestChecksOfOtherTeamsAreHidden(t *testing.T) {
+	r := newTeamChecks(t, "payments", "search")
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		checks   int
+	}{
+		{"anonymous", nil, 0},
+		{"viewer", &auth.Identity{Role: auth.ViewerRole, Teams: []string{"payments"}}, 1},
+		{"admin", &auth.Identity{Role: auth.AdminRole}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, tt.identity)
+			}
+			q := queryResolver{r}
+			connection, err := q.Checks(ctx, nil, nil, nil, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(connection.Edges) != tt.checks || connection.TotalCount != tt.checks {
+				t.Errorf("Checks() = %d checks, want %d", len(connection.Edges), tt.checks)
+			}
+			deletedChecks, err := q.DeletedChecks(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deletedChecks) != tt.checks {
+				t.Errorf("DeletedChecks() = %d checks, want %d", len(deletedChecks), tt.checks)
+			}
+		})
+	}
+}
+
+// newClient serves the schema with the directives and the Guard of the server
+func newClient(r *Resolver, anonymous bool) *client.Client {
+	h := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  r,
+		Directives: generated.DirectiveRoot{HasRole: HasRole},
+	}))
+	h.AddTransport(transport.POST{})
+	h.Use(auth.Guard{Anonymous: anonymous})
+	return client.New(h)
+}
+
+func asIdentity(identity *auth.Identity) client.Option {
+	return func(r *client.Request) {
+		r.HTTP = r.HTTP.WithContext(auth.WithIdentity(r.HTTP.Context(), identity))
+	}
+}
+
+func TestAnonymousCallersOnlyReadStatusPages(t *testing.T) {
+	r := newTeamChecks(t, "payments")
+	c := newClient(r, true)
+	resp := map[string]interface{}{}
+	err := c.Post(`{ statusPages { slug } me { name } }`, &resp)
+	if err != nil {
+		t.Errorf("anonymous statusPages = %v", err)
+	}
+	for _, query := range []string{
+		`{ checks { totalCount } }`,
+		`{ executions(checkId: "payments") { totalCount } }`,
+		`{ deletedChecks { purgeAt } }`,
+		`{ incidents { id } }`,
+		`{ maintenanceWindows { id } }`,
+		`{ workspace { id } }`,
+	} {
+		err := c.Post(query, &resp)
+		if err == nil || !strings.Contains(err.Error(), "Authentication required") {
+			t.Errorf("anonymous %s = %v, want authentication required", query, err)
+		}
+	}
+	err = c.Post(`{ checks { totalCount } }`, &resp, asIdentity(&auth.Identity{Role: auth.ViewerRole, Teams: []string{"payments"}}))
+	if err != nil {
+		t.Errorf("viewer checks = %v", err)
+	}
+	err = newClient(r, false).Post(`{ statusPages { slug } }`, &resp)
+	if err == nil {
+		t.Error("anonymous statusPages succeeded with anonymous access disabled")
+	}
+}
@@ -3,18 +3,33 @@ package resolvers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/auth"
 	"github.com/kfsoftware/statuspage/pkg/check"
 	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/events"
 	"github.com/kfsoftware/statuspage/pkg/graphql/generated"
 	"github.com/kfsoftware/statuspage/pkg/graphql/models"
-	"gorm.io/gorm"
+	"github.com/kfsoftware/statuspage/pkg/scheduler"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Resolver struct {
-	Db *gorm.DB
+	Storage storage.Storage
+	Bus     *events.Bus
+}
+
+// callerContext returns the context of the operation restricted to the workspace of the
+// caller, the changes made with it are recorded in the audit log as made by the caller
+func callerContext(ctx context.Context) context.Context {
+	identity := auth.IdentityFromContext(ctx)
+	ctx = db.WithWorkspace(ctx, identity.GetWorkspace())
+	return db.WithActor(ctx, auditActor(identity))
 }
 
 // Mutation returns generated.MutationResolver implementation.
@@ -23,249 +38,668 @@ func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResol
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 
 func (m mutationResolver) Poll(ctx context.Context) (*models.PollResult, error) {
 	start := time.Now()
-	db.CheckAll(m.Db)
+	// the round outlives the request when the client disconnects, so that notifications are sent
+	workspace := auth.IdentityFromContext(ctx).GetWorkspace()
+	scheduler.CheckAll(db.WithWorkspace(context.Background(), workspace), m.Storage, m.Bus)
 	end := time.Now()
 	return &models.PollResult{Took: int(end.Sub(start).Milliseconds())}, nil
 }
 
-func (m mutationResolver) CreateTCPCheck(ctx context.Context, input models.CreateTCPCheckInput) (models.Check, error) {
-	data := db.TcpCheckData{Address: input.Address}
-	jsonBytes, err := json.Marshal(data)
+func (m mutationResolver) DeleteCheck(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	chk, err := m.Storage.Checks().Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	_, err = time.ParseDuration(input.Frecuency)
+	err = authorizeCheck(ctx, *chk)
 	if err != nil {
 		return nil, err
 	}
-	result := m.Db.Create(&db.Check{
-		ID:         uuid.New().String(),
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Data:       jsonBytes,
-		Type:       check.TcpType,
-		Status:     db.Scheduled,
-	})
-	if result.Error != nil {
-		return nil, result.Error
+	err = m.Storage.Checks().Delete(ctx, chk)
+	if err != nil {
+		return nil, err
 	}
-	return models.TCPCheck{
-		ID:         input.ID,
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Address:    input.Address,
-	}, nil
+	return &models.DeleteResponse{ID: id}, nil
+}
+
+func (m mutationResolver) CreateTCPCheck(ctx context.Context, input models.CreateTCPCheckInput) (models.Check, error) {
+	data := db.TcpCheckData{Address: input.Address}
+	return m.createCheck(ctx, check.TcpType, input.ID, input.Frecuency, data, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	})
 }
 
 func (m mutationResolver) CreateTLSCheck(ctx context.Context, input models.CreateTLSCheckInput) (models.Check, error) {
 	data := db.TlsCheckData{
 		Address: input.Address,
-		RootCAs: *input.RootCAs,
 	}
+	if input.RootCAs != nil {
+		data.RootCAs = *input.RootCAs
+	}
+	return m.createCheck(ctx, check.TlsType, input.ID, input.Frecuency, data, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	})
+}
+
+func (m mutationResolver) CreateIcmpCheck(ctx context.Context, input models.CreateIcmpCheckInput) (models.Check, error) {
+	data := db.IcmpCheckData{Address: input.Address}
+	return m.createCheck(ctx, check.IcmpType, input.ID, input.Frecuency, data, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	})
+}
+
+func (m mutationResolver) CreateHTTPCheck(ctx context.Context, input models.CreateHTTPCheckInput) (models.Check, error) {
+	data := db.HttpCheckData{Url: input.URL}
+	return m.createCheck(ctx, check.HttpType, input.ID, input.Frecuency, data, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	})
+}
+
+// checkMetadata holds the optional fields shared by the inputs of every check type
+type checkMetadata struct {
+	Description *string
+	Owner       *string
+	Labels      []*models.LabelInput
+}
+
+func (m mutationResolver) createCheck(ctx context.Context, checkType check.Type, identifier string, frecuency string, data interface{}, metadata checkMetadata) (models.Check, error) {
 	jsonBytes, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
-	_, err = time.ParseDuration(input.Frecuency)
+	_, err = time.ParseDuration(frecuency)
 	if err != nil {
 		return nil, err
 	}
-	result := m.Db.Create(&db.Check{
-		ID:         uuid.New().String(),
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Data:       jsonBytes,
-		Type:       check.TlsType,
-		Status:     db.Scheduled,
-	})
-	if result.Error != nil {
-		return nil, result.Error
+	owner, err := authorizeOwner(ctx, metadata.Owner)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New().String()
+	labels, err := mapLabelsInput(metadata.Labels)
+	if err != nil {
+		return nil, err
+	}
+	chk := &db.Check{
+		ID:          id,
+		Identifier:  identifier,
+		Frequency:   frecuency,
+		Data:        jsonBytes,
+		Type:        checkType,
+		Status:      db.Scheduled,
+		Description: stringValue(metadata.Description),
+		Owner:       stringValue(owner),
+		Labels:      db.NewCheckLabels(id, labels),
+	}
+	err = m.Storage.Checks().Create(callerContext(ctx), chk)
+	if err != nil {
+		return nil, err
 	}
-	return models.TLSCheck{
-		ID:         input.ID,
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Address:    input.Address,
-	}, nil
+	return mapCheck(*chk)
 }
 
-func (m mutationResolver) CreateIcmpCheck(ctx context.Context, input models.CreateIcmpCheckInput) (models.Check, error) {
-	data := db.IcmpCheckData{Address: input.Address}
+func (m mutationResolver) UpdateHTTPCheck(ctx context.Context, id string, input models.UpdateHTTPCheckInput) (models.Check, error) {
+	return m.updateCheck(ctx, id, check.HttpType, input.ID, input.Frecuency, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	}, func(chk *db.Check) (interface{}, error) {
+		data, err := chk.GetHttpData()
+		if err != nil {
+			return nil, err
+		}
+		if input.URL != nil {
+			data.Url = *input.URL
+		}
+		return data, nil
+	})
+}
+
+func (m mutationResolver) UpdateTCPCheck(ctx context.Context, id string, input models.UpdateTCPCheckInput) (models.Check, error) {
+	return m.updateCheck(ctx, id, check.TcpType, input.ID, input.Frecuency, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	}, func(chk *db.Check) (interface{}, error) {
+		data, err := chk.GetTcpData()
+		if err != nil {
+			return nil, err
+		}
+		if input.Address != nil {
+			data.Address = *input.Address
+		}
+		return data, nil
+	})
+}
+
+func (m mutationResolver) UpdateTLSCheck(ctx context.Context, id string, input models.UpdateTLSCheckInput) (models.Check, error) {
+	return m.updateCheck(ctx, id, check.TlsType, input.ID, input.Frecuency, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	}, func(chk *db.Check) (interface{}, error) {
+		data, err := chk.GetTlsData()
+		if err != nil {
+			return nil, err
+		}
+		if input.Address != nil {
+			data.Address = *input.Address
+		}
+		if input.RootCAs != nil {
+			data.RootCAs = *input.RootCAs
+		}
+		return data, nil
+	})
+}
+
+func (m mutationResolver) UpdateIcmpCheck(ctx context.Context, id string, input models.UpdateIcmpCheckInput) (models.Check, error) {
+	return m.updateCheck(ctx, id, check.IcmpType, input.ID, input.Frecuency, checkMetadata{
+		Description: input.Description,
+		Owner:       input.Owner,
+		Labels:      input.Labels,
+	}, func(chk *db.Check) (interface{}, error) {
+		data, err := chk.GetIcmpData()
+		if err != nil {
+			return nil, err
+		}
+		if input.Address != nil {
+			data.Address = *input.Address
+		}
+		return data, nil
+	})
+}
+
+// updateCheck applies the fields common to every check type and replaces the data of the check
+// with the one returned by updateData, the history of the check is kept
+func (m mutationResolver) updateCheck(ctx context.Context, id string, checkType check.Type, identifier *string, frecuency *string, metadata checkMetadata, updateData func(chk *db.Check) (interface{}, error)) (models.Check, error) {
+	ctx = callerContext(ctx)
+	chk, err := m.Storage.Checks().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = authorizeCheck(ctx, *chk)
+	if err != nil {
+		return nil, err
+	}
+	if chk.Type != checkType {
+		return nil, errors.Errorf("Check %s is of type %s, not %s", id, chk.Type, checkType)
+	}
+	if identifier != nil {
+		chk.Identifier = *identifier
+	}
+	if frecuency != nil {
+		_, err := time.ParseDuration(*frecuency)
+		if err != nil {
+			return nil, err
+		}
+		chk.Frequency = *frecuency
+	}
+	if metadata.Description != nil {
+		chk.Description = *metadata.Description
+	}
+	if metadata.Owner != nil {
+		owner, err := authorizeOwner(ctx, metadata.Owner)
+		if err != nil {
+			return nil, err
+		}
+		chk.Owner = *owner
+	}
+	data, err := updateData(chk)
+	if err != nil {
+		return nil, err
+	}
 	jsonBytes, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
-	_, err = time.ParseDuration(input.Frecuency)
+	chk.Data = jsonBytes
+	if metadata.Labels != nil {
+		labels, err := mapLabelsInput(metadata.Labels)
+		if err != nil {
+			return nil, err
+		}
+		chk.Labels = db.NewCheckLabels(chk.ID, labels)
+	}
+	err = m.Storage.Checks().Save(ctx, db.UpdateOperation, chk)
 	if err != nil {
 		return nil, err
 	}
-	result := m.Db.Create(&db.Check{
-		ID:         uuid.New().String(),
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Data:       jsonBytes,
-		Type:       check.IcmpType,
-		Status:     db.Scheduled,
-	})
-	if result.Error != nil {
-		return nil, result.Error
+	return mapCheck(*chk)
+}
+
+func (m mutationResolver) PauseCheck(ctx context.Context, id string) (models.Check, error) {
+	ctx = callerContext(ctx)
+	chk, err := m.Storage.Checks().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = authorizeCheck(ctx, *chk)
+	if err != nil {
+		return nil, err
+	}
+	previousStatus := chk.Status
+	chk.Paused = true
+	chk.Status = db.Paused
+	err = m.Storage.Checks().Save(ctx, db.PauseOperation, chk)
+	if err != nil {
+		return nil, err
 	}
-	return models.IcmpCheck{
-		ID:         input.ID,
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Address:    input.Address,
-	}, nil
+	db.PublishStatusChange(m.Bus, *chk, previousStatus)
+	return mapCheck(*chk)
 }
 
-func (m mutationResolver) DeleteCheck(ctx context.Context, id string) (*models.DeleteResponse, error) {
-	chk := db.Check{}
-	result := m.Db.Find(&chk, "id = ?", id)
-	if result.Error != nil {
-		return nil, result.Error
+func (m mutationResolver) ResumeCheck(ctx context.Context, id string) (models.Check, error) {
+	ctx = callerContext(ctx)
+	chk, err := m.Storage.Checks().Get(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	chk.Identifier = fmt.Sprintf("%s-%s-%s", chk.Identifier, "deleted", uuid.New().String())
-	result = m.Db.Save(&chk)
-	if result.Error != nil {
-		return nil, result.Error
+	err = authorizeCheck(ctx, *chk)
+	if err != nil {
+		return nil, err
 	}
-	result = m.Db.Delete(&chk)
-	if result.Error != nil {
-		return nil, result.Error
+	if !chk.Paused {
+		return mapCheck(*chk)
 	}
-	return &models.DeleteResponse{ID: id}, nil
+	previousStatus := chk.Status
+	chk.Paused = false
+	chk.Status = db.Scheduled
+	err = m.Storage.Checks().Save(ctx, db.ResumeOperation, chk)
+	if err != nil {
+		return nil, err
+	}
+	db.PublishStatusChange(m.Bus, *chk, previousStatus)
+	return mapCheck(*chk)
 }
 
-func (m mutationResolver) CreateHTTPCheck(ctx context.Context, input models.CreateHTTPCheckInput) (models.Check, error) {
-	data := db.HttpCheckData{Url: input.URL}
-	jsonBytes, err := json.Marshal(data)
+func (m mutationResolver) RestoreCheck(ctx context.Context, id string) (models.Check, error) {
+	ctx = callerContext(ctx)
+	chk, err := m.Storage.Checks().GetWithDeleted(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	_, err = time.ParseDuration(input.Frecuency)
+	err = authorizeCheck(ctx, *chk)
 	if err != nil {
 		return nil, err
 	}
-	result := m.Db.Create(&db.Check{
-		ID:         uuid.New().String(),
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		Data:       jsonBytes,
-		Type:       check.HttpType,
-		Status:     db.Scheduled,
-	})
-	if result.Error != nil {
-		return nil, result.Error
+	err = m.Storage.Checks().Restore(ctx, chk)
+	if err != nil {
+		return nil, err
 	}
-	return models.HTTPCheck{
-		ID:         input.ID,
-		Identifier: input.ID,
-		Frecuency:  input.Frecuency,
-		URL:        input.URL,
-	}, nil
+	return mapCheck(*chk)
+}
 
+func (m mutationResolver) PurgeCheck(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	chk, err := m.Storage.Checks().GetWithDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = authorizeCheck(ctx, *chk)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.Checks().Purge(ctx, chk)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeleteResponse{ID: id}, nil
 }
 
 type queryResolver struct{ *Resolver }
 
-func (q queryResolver) Executions(ctx context.Context, checkID string, from *time.Time, until *time.Time) ([]*models.CheckExecution, error) {
-	var executions []db.CheckExecution
-	result := q.Db.Where("check_id = ? AND created_at BETWEEN ? AND ?", checkID, from, until).Find(&executions)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	var modelExecutions []*models.CheckExecution
-	for _, execution := range executions {
-		modelExecutions = append(modelExecutions, &models.CheckExecution{
-			ID:            execution.ID,
-			ExecutionTime: execution.CreatedAt,
-			Message:       execution.Message,
-			ErrorMsg:      execution.ErrorMsg,
-			Status:        string(execution.Status),
+func (q queryResolver) DeletedChecks(ctx context.Context) ([]*models.DeletedCheck, error) {
+	checks, err := q.Storage.Checks().ListDeleted(callerContext(ctx), teamsFilter(ctx))
+	if err != nil {
+		return nil, err
+	}
+	purgePeriod := viper.GetDuration("retention.deletedChecks")
+	deletedChecks := []*models.DeletedCheck{}
+	for _, chk := range checks {
+		modelCheck, err := mapCheck(chk)
+		if err != nil {
+			return nil, err
+		}
+		if modelCheck == nil {
+			continue
+		}
+		deletedCheck := &models.DeletedCheck{
+			Check:              modelCheck,
+			OriginalIdentifier: chk.OriginalIdentifier(),
+			DeletedAt:          chk.DeletedAt.Time,
+		}
+		if purgePeriod > 0 {
+			purgeAt := chk.DeletedAt.Time.Add(purgePeriod)
+			deletedCheck.PurgeAt = &purgeAt
+		}
+		deletedChecks = append(deletedChecks, deletedCheck)
+	}
+	return deletedChecks, nil
+}
+
+func (q queryResolver) Executions(ctx context.Context, checkID string, from *time.Time, until *time.Time, statuses []string, orderBy *models.ExecutionOrder, first *int, after *string, last *int, before *string) (*models.CheckExecutionConnection, error) {
+	order := db.Order{}
+	if orderBy != nil {
+		order.Direction = db.OrderDirection(orderBy.Direction)
+		switch orderBy.Field {
+		case models.ExecutionOrderFieldStatus:
+			order.Column = db.StatusColumn
+		case models.ExecutionOrderFieldLatency:
+			order.Column = db.LatencyColumn
+		default:
+			order.Column = db.CreatedAtColumn
+		}
+	}
+	ctx = callerContext(ctx)
+	chk, err := findAuthorizedCheck(ctx, q.Storage, checkID)
+	if err != nil {
+		return nil, err
+	}
+	page, err := q.Storage.Executions().List(ctx, db.ExecutionFilter{
+		CheckID:  checkID,
+		From:     from,
+		Until:    until,
+		Statuses: statuses,
+	}, order, db.Pagination{First: first, After: after, Last: last, Before: before})
+	if err != nil {
+		return nil, err
+	}
+	connection := &models.CheckExecutionConnection{
+		Edges:      []*models.CheckExecutionEdge{},
+		PageInfo:   mapPageInfo(page.PageInfo, page.Cursors),
+		TotalCount: int(page.TotalCount),
+	}
+	for i, execution := range page.Executions {
+		modelExecution, err := mapExecution(execution, chk.Type)
+		if err != nil {
+			return nil, err
+		}
+		connection.Edges = append(connection.Edges, &models.CheckExecutionEdge{
+			Cursor: page.Cursors[i],
+			Node:   modelExecution,
 		})
 	}
-	return modelExecutions, nil
+	return connection, nil
+}
 
+func (q queryResolver) Metrics(ctx context.Context, checkID string, from *time.Time, until *time.Time, bucket string) ([]*models.MetricsBucket, error) {
+	bucketDuration, err := time.ParseDuration(bucket)
+	if err != nil {
+		return nil, err
+	}
+	ctx = callerContext(ctx)
+	_, err = findAuthorizedCheck(ctx, q.Storage, checkID)
+	if err != nil {
+		return nil, err
+	}
+	fromTime, untilTime := timeRange(from, until)
+	buckets, err := q.Storage.Executions().Metrics(ctx, checkID, fromTime, untilTime, bucketDuration)
+	if err != nil {
+		return nil, err
+	}
+	var modelBuckets []*models.MetricsBucket
+	for _, b := range buckets {
+		modelBuckets = append(modelBuckets, &models.MetricsBucket{
+			Time:       b.Time,
+			MinLatency: toMilliseconds(b.MinLatency),
+			AvgLatency: toMilliseconds(b.AvgLatency),
+			P50:        toMilliseconds(b.P50),
+			P95:        toMilliseconds(b.P95),
+			P99:        toMilliseconds(b.P99),
+			MaxLatency: toMilliseconds(b.MaxLatency),
+			Up:         int(b.Up),
+			Down:       int(b.Down),
+		})
+	}
+	return modelBuckets, nil
 }
 
-func (q queryResolver) Checks(ctx context.Context) ([]models.Check, error) {
-	var checks []db.Check
-	result := q.Db.Find(&checks)
-	if result.Error != nil {
-		return nil, result.Error
+func (q queryResolver) Uptime(ctx context.Context, checkID string, from *time.Time, until *time.Time) (*models.Uptime, error) {
+	ctx = callerContext(ctx)
+	_, err := findAuthorizedCheck(ctx, q.Storage, checkID)
+	if err != nil {
+		return nil, err
 	}
-	var modelChecks []models.Check
-	for _, chk := range checks {
-		errorMsg := chk.ErrorMsg
-		msg := chk.Message
-		latestCheck := chk.LatestCheck
-		switch chk.Type {
-		case check.HttpType:
-			httpCheckData, err := chk.GetHttpData()
-			if err != nil {
-				return nil, err
-			}
-			modelChecks = append(modelChecks, models.HTTPCheck{
-				ID:          chk.ID,
-				Identifier:  chk.Identifier,
-				Frecuency:   chk.Frecuency,
-				URL:         httpCheckData.Url,
-				Status:      string(chk.Status),
-				LatestCheck: &latestCheck,
-				ErrorMsg:    errorMsg,
-				Message:     msg,
-			})
-		case check.TcpType:
-			tcpCheckData, err := chk.GetTcpData()
-			if err != nil {
-				return nil, err
-			}
-			modelChecks = append(modelChecks, models.TCPCheck{
-				ID:          chk.ID,
-				Identifier:  chk.Identifier,
-				Frecuency:   chk.Frecuency,
-				Address:     tcpCheckData.Address,
-				Status:      string(chk.Status),
-				LatestCheck: &latestCheck,
-				ErrorMsg:    errorMsg,
-				Message:     msg,
-			})
-		case check.TlsType:
-			tlsCheckData, err := chk.GetTlsData()
-			if err != nil {
-				return nil, err
-			}
-			modelChecks = append(modelChecks, models.TCPCheck{
-				ID:          chk.ID,
-				Identifier:  chk.Identifier,
-				Frecuency:   chk.Frecuency,
-				Address:     tlsCheckData.Address,
-				Status:      string(chk.Status),
-				LatestCheck: &latestCheck,
-				ErrorMsg:    errorMsg,
-				Message:     msg,
-			})
-		case check.IcmpType:
-			icmpCheckData, err := chk.GetIcmpData()
-			if err != nil {
-				return nil, err
-			}
-			modelChecks = append(modelChecks, models.TCPCheck{
-				ID:          chk.ID,
-				Identifier:  chk.Identifier,
-				Frecuency:   chk.Frecuency,
-				Address:     icmpCheckData.Address,
-				Status:      string(chk.Status),
-				LatestCheck: &latestCheck,
-				ErrorMsg:    errorMsg,
-				Message:     msg,
-			})
+	fromTime, untilTime := timeRange(from, until)
+	up, down, err := q.Storage.Executions().Uptime(ctx, checkID, fromTime, untilTime)
+	if err != nil {
+		return nil, err
+	}
+	uptime := &models.Uptime{
+		Up:   int(up),
+		Down: int(down),
+	}
+	if up+down > 0 {
+		percentage := float64(up) * 100 / float64(up+down)
+		uptime.Percentage = &percentage
+	}
+	return uptime, nil
+}
+
+// timeRange defaults to the last 24 hours
+func timeRange(from *time.Time, until *time.Time) (time.Time, time.Time) {
+	untilTime := time.Now()
+	if until != nil {
+		untilTime = *until
+	}
+	fromTime := untilTime.Add(-24 * time.Hour)
+	if from != nil {
+		fromTime = *from
+	}
+	return fromTime, untilTime
+}
+
+func toMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (q queryResolver) Checks(ctx context.Context, filter *models.CheckFilter, orderBy *models.CheckOrder, first *int, after *string, last *int, before *string) (*models.CheckConnection, error) {
+	checkFilter := db.CheckFilter{Teams: teamsFilter(ctx)}
+	if filter != nil {
+		for _, checkType := range filter.Types {
+			checkFilter.Types = append(checkFilter.Types, strings.ToLower(string(checkType)))
+		}
+		checkFilter.Statuses = filter.Statuses
+		checkFilter.Owner = stringValue(filter.Owner)
+		labels, err := mapLabelsInput(filter.Labels)
+		if err != nil {
+			return nil, err
+		}
+		checkFilter.Labels = labels
+	}
+	order := db.Order{}
+	if orderBy != nil {
+		order.Direction = db.OrderDirection(orderBy.Direction)
+		switch orderBy.Field {
+		case models.CheckOrderFieldStatus:
+			order.Column = db.StatusColumn
+		case models.CheckOrderFieldCreatedAt:
+			order.Column = db.CreatedAtColumn
+		case models.CheckOrderFieldLatestCheck:
+			order.Column = db.LatestCheckColumn
+		default:
+			order.Column = db.IdentifierColumn
+		}
+	}
+	page, err := q.Storage.Checks().List(callerContext(ctx), checkFilter, order, db.Pagination{First: first, After: after, Last: last, Before: before})
+	if err != nil {
+		return nil, err
+	}
+	connection := &models.CheckConnection{
+		Edges:      []*models.CheckEdge{},
+		PageInfo:   mapPageInfo(page.PageInfo, page.Cursors),
+		TotalCount: int(page.TotalCount),
+	}
+	for i, chk := range page.Checks {
+		modelCheck, err := mapCheck(chk)
+		if err != nil {
+			return nil, err
+		}
+		if modelCheck == nil {
+			continue
+		}
+		connection.Edges = append(connection.Edges, &models.CheckEdge{
+			Cursor: page.Cursors[i],
+			Node:   modelCheck,
+		})
+	}
+	return connection, nil
+}
+
+func (q queryResolver) Check(ctx context.Context, id string) (models.Check, error) {
+	chk, err := q.Storage.Checks().Get(callerContext(ctx), id)
+	return q.authorizedCheck(ctx, chk, err)
+}
+
+func (q queryResolver) CheckByIdentifier(ctx context.Context, identifier string) (models.Check, error) {
+	chk, err := q.Storage.Checks().GetByIdentifier(callerContext(ctx), identifier)
+	return q.authorizedCheck(ctx, chk, err)
+}
+
+// authorizedCheck returns nil when the check is not found or the caller can't read it
+func (q queryResolver) authorizedCheck(ctx context.Context, chk *db.Check, err error) (models.Check, error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorizeCheck(ctx, *chk) != nil {
+		return nil, nil
+	}
+	return mapCheck(*chk)
+}
+
+func mapPageInfo(pageInfo db.PageInfo, cursors []string) *models.PageInfo {
+	modelPageInfo := &models.PageInfo{
+		HasNextPage:     pageInfo.HasNextPage,
+		HasPreviousPage: pageInfo.HasPreviousPage,
+	}
+	if len(cursors) > 0 {
+		modelPageInfo.StartCursor = &cursors[0]
+		modelPageInfo.EndCursor = &cursors[len(cursors)-1]
+	}
+	return modelPageInfo
+}
+
+func mapLabels(labels db.Labels) []*models.Label {
+	modelLabels := []*models.Label{}
+	for name, value := range labels {
+		modelLabels = append(modelLabels, &models.Label{Name: name, Value: value})
+	}
+	sort.Slice(modelLabels, func(i, j int) bool {
+		return modelLabels[i].Name < modelLabels[j].Name
+	})
+	return modelLabels
+}
+
+func mapLabelsInput(input []*models.LabelInput) (db.Labels, error) {
+	labels := db.Labels{}
+	for _, label := range input {
+		if label.Name == "" {
+			return nil, errors.New("Label names cannot be empty")
+		}
+		if _, ok := labels[label.Name]; ok {
+			return nil, errors.Errorf("Duplicated label %s", label.Name)
 		}
+		labels[label.Name] = label.Value
+	}
+	return labels, nil
+}
 
+func mapCheck(chk db.Check) (models.Check, error) {
+	errorMsg := chk.ErrorMsg
+	msg := chk.Message
+	latestCheck := chk.LatestCheck
+	labels := mapLabels(chk.GetLabels())
+	switch chk.Type {
+	case check.HttpType:
+		httpCheckData, err := chk.GetHttpData()
+		if err != nil {
+			return nil, err
+		}
+		return models.HTTPCheck{
+			ID:          chk.ID,
+			Identifier:  chk.Identifier,
+			Frecuency:   chk.Frequency,
+			URL:         httpCheckData.Url,
+			Status:      string(chk.Status),
+			LatestCheck: &latestCheck,
+			ErrorMsg:    errorMsg,
+			Message:     msg,
+			Description: chk.Description,
+			Owner:       chk.Owner,
+			Labels:      labels,
+		}, nil
+	case check.TcpType:
+		tcpCheckData, err := chk.GetTcpData()
+		if err != nil {
+			return nil, err
+		}
+		return models.TCPCheck{
+			ID:          chk.ID,
+			Identifier:  chk.Identifier,
+			Frecuency:   chk.Frequency,
+			Address:     tcpCheckData.Address,
+			Status:      string(chk.Status),
+			LatestCheck: &latestCheck,
+			ErrorMsg:    errorMsg,
+			Message:     msg,
+			Description: chk.Description,
+			Owner:       chk.Owner,
+			Labels:      labels,
+		}, nil
+	case check.TlsType:
+		tlsCheckData, err := chk.GetTlsData()
+		if err != nil {
+			return nil, err
+		}
+		return models.TLSCheck{
+			ID:          chk.ID,
+			Identifier:  chk.Identifier,
+			Frecuency:   chk.Frequency,
+			Address:     tlsCheckData.Address,
+			Status:      string(chk.Status),
+			LatestCheck: &latestCheck,
+			ErrorMsg:    errorMsg,
+			Message:     msg,
+			Description: chk.Description,
+			Owner:       chk.Owner,
+			Labels:      labels,
+		}, nil
+	case check.IcmpType:
+		icmpCheckData, err := chk.GetIcmpData()
+		if err != nil {
+			return nil, err
+		}
+		return models.IcmpCheck{
+			ID:          chk.ID,
+			Identifier:  chk.Identifier,
+			Frecuency:   chk.Frequency,
+			Address:     icmpCheckData.Address,
+			Status:      string(chk.Status),
+			LatestCheck: &latestCheck,
+			ErrorMsg:    errorMsg,
+			Message:     msg,
+			Description: chk.Description,
+			Owner:       chk.Owner,
+			Labels:      labels,
+		}, nil
 	}
-	return modelChecks, nil
+	return nil, nil
 }
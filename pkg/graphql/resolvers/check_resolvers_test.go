@@ -0,0 +1,71 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"testing"
+)
+
+// TestChecksAreMappedByType covers the tls and icmp checks, which used to be returned as tcp checks
+func TestChecksAreMappedByType(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	tests := []struct {
+		identifier string
+		checkType  check.Type
+		data       string
+		typename   string
+		target     string
+	}{
+		{"http", check.HttpType, `{"url":"https://example.com"}`, "HttpCheck", "https://example.com"},
+		{"icmp", check.IcmpType, `{"address":"10.0.0.1"}`, "IcmpCheck", "10.0.0.1"},
+		{"tcp", check.TcpType, `{"address":"localhost:5432"}`, "TcpCheck", "localhost:5432"},
+		{"tls", check.TlsType, `{"address":"example.com:443"}`, "TlsCheck", "example.com:443"},
+	}
+	for _, tt := range tests {
+		err := store.Checks().Create(ctx, &db.Check{Identifier: tt.identifier, Type: tt.checkType, Data: []byte(tt.data), Frequency: "@every 1m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := newClient(&Resolver{Storage: store}, false)
+	var resp struct {
+		Checks struct {
+			Edges []struct {
+				Node struct {
+					Typename   string `json:"__typename"`
+					Identifier string
+					URL        string
+					Address    string
+				}
+			}
+		}
+	}
+	err := c.Post(`{ checks(first: 10) { edges { node {
+		__typename
+		identifier
+		... on HttpCheck { url }
+		... on TcpCheck { address }
+		... on TlsCheck { address }
+		... on IcmpCheck { address }
+	} } } }`, &resp, asIdentity(&auth.Identity{Role: auth.AdminRole}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Checks.Edges) != len(tests) {
+		t.Fatalf("checks returned %d checks, want %d", len(resp.Checks.Edges), len(tests))
+	}
+	for i, tt := range tests {
+		node := resp.Checks.Edges[i].Node
+		target := node.Address
+		if tt.checkType == check.HttpType {
+			target = node.URL
+		}
+		if node.Identifier != tt.identifier || node.Typename != tt.typename || target != tt.target {
+			t.Errorf("check %s = %s %s, want %s %s", tt.identifier, node.Typename, target, tt.typename, tt.target)
+		}
+	}
+}
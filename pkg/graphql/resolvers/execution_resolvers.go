@@ -0,0 +1,93 @@
+package resolvers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"sort"
+)
+
+func mapExecution(execution db.CheckExecution, checkType check.Type) (*models.CheckExecution, error) {
+	modelExecution := &models.CheckExecution{
+		ID:            execution.ID,
+		ExecutionTime: execution.CreatedAt,
+		Message:       execution.Message,
+		ErrorMsg:      execution.ErrorMsg,
+		Status:        string(execution.Status),
+		Latency:       toMilliseconds(execution.Latency),
+		Maintenance:   execution.Maintenance,
+	}
+	statistics, err := execution.GetStatistics(checkType)
+	if err != nil {
+		return nil, err
+	}
+	modelExecution.Stats = mapStatistics(statistics)
+	return modelExecution, nil
+}
+
+func mapStatistics(statistics check.Statistics) models.ExecutionStats {
+	switch statistics := statistics.(type) {
+	case check.HttpStatistics:
+		httpStats := models.HTTPExecutionStats{
+			TimeTaken:     toMilliseconds(statistics.TimeTaken),
+			StatusCode:    statistics.StatusCode,
+			ContentLength: int(statistics.ContentLength),
+			Headers:       []*models.HTTPHeader{},
+		}
+		for name, values := range statistics.Headers {
+			httpStats.Headers = append(httpStats.Headers, &models.HTTPHeader{
+				Name:   name,
+				Values: values,
+			})
+		}
+		sort.Slice(httpStats.Headers, func(i, j int) bool {
+			return httpStats.Headers[i].Name < httpStats.Headers[j].Name
+		})
+		return httpStats
+	case check.TcpStatistics:
+		return models.TCPExecutionStats{
+			TimeTaken:  toMilliseconds(statistics.TimeTaken),
+			RemoteAddr: statistics.RemoteAddr,
+		}
+	case check.TlsStatistics:
+		tlsStats := models.TLSExecutionStats{
+			TimeTaken:    toMilliseconds(statistics.TimeTaken),
+			Certificates: []*models.Certificate{},
+		}
+		for _, peerCertificate := range statistics.PeerCertificates {
+			certificate, err := x509.ParseCertificate(peerCertificate.Content)
+			if err != nil {
+				continue
+			}
+			tlsStats.Certificates = append(tlsStats.Certificates, &models.Certificate{
+				Subject:      certificate.Subject.String(),
+				Issuer:       certificate.Issuer.String(),
+				SerialNumber: certificate.SerialNumber.String(),
+				DNSNames:     append([]string{}, certificate.DNSNames...),
+				NotBefore:    certificate.NotBefore,
+				NotAfter:     certificate.NotAfter,
+				Pem:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: peerCertificate.Content})),
+			})
+		}
+		return tlsStats
+	case check.IcmpStatistics:
+		icmpStats := models.IcmpExecutionStats{
+			TimeTaken: toMilliseconds(statistics.TimeTaken),
+		}
+		if pingStatistics := statistics.PingStatistics; pingStatistics != nil {
+			icmpStats.Address = pingStatistics.Addr
+			icmpStats.PacketsSent = pingStatistics.PacketsSent
+			icmpStats.PacketsRecv = pingStatistics.PacketsRecv
+			icmpStats.PacketLoss = pingStatistics.PacketLoss
+			icmpStats.MinRtt = toMilliseconds(pingStatistics.MinRtt)
+			icmpStats.AvgRtt = toMilliseconds(pingStatistics.AvgRtt)
+			icmpStats.MaxRtt = toMilliseconds(pingStatistics.MaxRtt)
+			icmpStats.StdDevRtt = toMilliseconds(pingStatistics.StdDevRtt)
+		}
+		return icmpStats
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,144 @@
+package resolvers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/go-ping/ping"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestMapStatistics maps the statistics stored by the probe of every check type
+func TestMapStatistics(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		Issuer:       pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		checkType  check.Type
+		statistics check.Statistics
+		stats      models.ExecutionStats
+	}{
+		{
+			check.HttpType,
+			check.HttpStatistics{
+				TimeTaken:     1500 * time.Microsecond,
+				StatusCode:    200,
+				ContentLength: 512,
+				Headers:       map[string][]string{"server": {"nginx"}, "content-type": {"text/html"}},
+			},
+			models.HTTPExecutionStats{
+				TimeTaken:     1.5,
+				StatusCode:    200,
+				ContentLength: 512,
+				Headers: []*models.HTTPHeader{
+					{Name: "content-type", Values: []string{"text/html"}},
+					{Name: "server", Values: []string{"nginx"}},
+				},
+			},
+		},
+		{
+			check.TcpType,
+			check.TcpStatistics{TimeTaken: 2 * time.Millisecond, RemoteAddr: "10.0.0.1:5432"},
+			models.TCPExecutionStats{TimeTaken: 2, RemoteAddr: "10.0.0.1:5432"},
+		},
+		{
+			check.TlsType,
+			check.TlsStatistics{
+				TimeTaken:        3 * time.Millisecond,
+				PeerCertificates: []check.PeerCertificate{{Content: certificate}, {Content: []byte("invalid")}},
+			},
+			models.TLSExecutionStats{
+				TimeTaken: 3,
+				Certificates: []*models.Certificate{{
+					Subject:      "CN=example.com",
+					Issuer:       "CN=example.com",
+					SerialNumber: "42",
+					DNSNames:     []string{"example.com", "www.example.com"},
+					NotBefore:    notBefore,
+					NotAfter:     notAfter,
+					Pem:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})),
+				}},
+			},
+		},
+		{
+			check.IcmpType,
+			check.IcmpStatistics{
+				TimeTaken: 4 * time.Millisecond,
+				PingStatistics: &ping.Statistics{
+					Addr:        "10.0.0.1",
+					PacketsSent: 3,
+					PacketsRecv: 2,
+					PacketLoss:  100.0 / 3,
+					MinRtt:      time.Millisecond,
+					AvgRtt:      2 * time.Millisecond,
+					MaxRtt:      3 * time.Millisecond,
+					StdDevRtt:   500 * time.Microsecond,
+				},
+			},
+			models.IcmpExecutionStats{
+				TimeTaken:   4,
+				Address:     "10.0.0.1",
+				PacketsSent: 3,
+				PacketsRecv: 2,
+				PacketLoss:  100.0 / 3,
+				MinRtt:      1,
+				AvgRtt:      2,
+				MaxRtt:      3,
+				StdDevRtt:   0.5,
+			},
+		},
+		{
+			check.IcmpType,
+			check.IcmpStatistics{TimeTaken: 5 * time.Millisecond},
+			models.IcmpExecutionStats{TimeTaken: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.checkType), func(t *testing.T) {
+			stats, err := json.Marshal(tt.statistics)
+			if err != nil {
+				t.Fatal(err)
+			}
+			execution, err := mapExecution(db.CheckExecution{ID: "execution", Status: db.Up, Stats: stats}, tt.checkType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(execution.Stats, tt.stats) {
+				t.Errorf("stats = %+v, want %+v", execution.Stats, tt.stats)
+			}
+		})
+	}
+	execution, err := mapExecution(db.CheckExecution{ID: "execution", Status: db.Up}, check.HttpType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if execution.Stats != nil {
+		t.Errorf("stats of an execution without statistics = %+v, want nil", execution.Stats)
+	}
+}
@@ -0,0 +1,151 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/events"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"time"
+)
+
+func (m mutationResolver) CreateIncident(ctx context.Context, input models.CreateIncidentInput) (*models.Incident, error) {
+	ctx = callerContext(ctx)
+	now := time.Now()
+	incident := &db.Incident{
+		ID:     uuid.New().String(),
+		Title:  input.Title,
+		Status: db.IncidentStatus(input.Status),
+		Impact: db.Impact(input.Impact),
+		Updates: []db.IncidentUpdate{
+			{
+				ID:        uuid.New().String(),
+				Status:    db.IncidentStatus(input.Status),
+				Body:      input.Body,
+				CreatedAt: now,
+			},
+		},
+	}
+	if incident.Status == db.Resolved {
+		incident.ResolvedAt = &now
+	}
+	err := m.Storage.Incidents().Create(ctx, incident, input.ComponentIds)
+	if err != nil {
+		return nil, err
+	}
+	incident, err = m.Storage.Incidents().Get(ctx, incident.ID)
+	if err != nil {
+		return nil, err
+	}
+	m.publishIncident(*incident)
+	return mapIncident(*incident)
+}
+
+func (m mutationResolver) PostIncidentUpdate(ctx context.Context, input models.PostIncidentUpdateInput) (*models.Incident, error) {
+	ctx = callerContext(ctx)
+	incident, err := m.Storage.Incidents().Get(ctx, input.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	update := db.IncidentUpdate{
+		ID:         uuid.New().String(),
+		IncidentID: incident.ID,
+		Status:     db.IncidentStatus(input.Status),
+		Body:       input.Body,
+		CreatedAt:  now,
+	}
+	incident.Status = db.IncidentStatus(input.Status)
+	if input.Impact != nil {
+		incident.Impact = db.Impact(*input.Impact)
+	}
+	incident.ResolvedAt = nil
+	if incident.Status == db.Resolved {
+		incident.ResolvedAt = &now
+	}
+	err = m.Storage.Incidents().PostUpdate(ctx, incident, update, input.ComponentIds)
+	if err != nil {
+		return nil, err
+	}
+	incident, err = m.Storage.Incidents().Get(ctx, incident.ID)
+	if err != nil {
+		return nil, err
+	}
+	m.publishIncident(*incident)
+	return mapIncident(*incident)
+}
+
+func (m mutationResolver) DeleteIncident(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	incident, err := m.Storage.Incidents().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.Incidents().Delete(ctx, incident)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeleteResponse{ID: id}, nil
+}
+
+func (q queryResolver) Incidents(ctx context.Context, active *bool) ([]*models.Incident, error) {
+	incidents, err := q.Storage.Incidents().List(callerContext(ctx), active)
+	if err != nil {
+		return nil, err
+	}
+	var modelIncidents []*models.Incident
+	for _, incident := range incidents {
+		modelIncident, err := mapIncident(incident)
+		if err != nil {
+			return nil, err
+		}
+		modelIncidents = append(modelIncidents, modelIncident)
+	}
+	return modelIncidents, nil
+}
+
+func (q queryResolver) Incident(ctx context.Context, id string) (*models.Incident, error) {
+	incident, err := q.Storage.Incidents().Get(callerContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	return mapIncident(*incident)
+}
+
+func (m mutationResolver) publishIncident(incident db.Incident) {
+	m.Bus.Publish(events.Event{
+		Type:    events.IncidentUpdated,
+		ID:      incident.ID,
+		Payload: incident,
+	})
+}
+
+func mapIncident(incident db.Incident) (*models.Incident, error) {
+	modelIncident := &models.Incident{
+		ID:         incident.ID,
+		Title:      incident.Title,
+		Status:     models.IncidentStatus(incident.Status),
+		Impact:     models.IncidentImpact(incident.Impact),
+		CreatedAt:  incident.CreatedAt,
+		UpdatedAt:  incident.UpdatedAt,
+		ResolvedAt: incident.ResolvedAt,
+		Components: []*models.StatusPageComponent{},
+		Updates:    []*models.IncidentUpdate{},
+	}
+	for _, component := range incident.Components {
+		modelComponent, err := mapStatusPageComponent(component)
+		if err != nil {
+			return nil, err
+		}
+		modelIncident.Components = append(modelIncident.Components, modelComponent)
+	}
+	for _, update := range incident.Updates {
+		modelIncident.Updates = append(modelIncident.Updates, &models.IncidentUpdate{
+			ID:        update.ID,
+			Status:    models.IncidentStatus(update.Status),
+			Body:      update.Body,
+			CreatedAt: update.CreatedAt,
+		})
+	}
+	return modelIncident, nil
+}
@@ -0,0 +1,246 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/generated"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// MaintenanceWindow returns generated.MaintenanceWindowResolver implementation.
+func (r *Resolver) MaintenanceWindow() generated.MaintenanceWindowResolver {
+	return &maintenanceWindowResolver{r}
+}
+
+type maintenanceWindowResolver struct{ *Resolver }
+
+func (m maintenanceWindowResolver) NextOccurrences(ctx context.Context, obj *models.MaintenanceWindow, limit *int) ([]*models.MaintenanceOccurrence, error) {
+	max := 5
+	if limit != nil {
+		max = *limit
+	}
+	window := db.MaintenanceWindow{
+		StartsAt: obj.StartsAt,
+		EndsAt:   obj.EndsAt,
+		Schedule: obj.Schedule,
+		Duration: obj.Duration,
+		Timezone: obj.Timezone,
+	}
+	now := time.Now()
+	occurrences, err := window.Occurrences(now, now.AddDate(1, 0, 0), max)
+	if err != nil {
+		return nil, err
+	}
+	modelOccurrences := []*models.MaintenanceOccurrence{}
+	for _, occurrence := range occurrences {
+		modelOccurrences = append(modelOccurrences, &models.MaintenanceOccurrence{
+			Start: occurrence.Start,
+			End:   occurrence.End,
+		})
+	}
+	return modelOccurrences, nil
+}
+
+func (m mutationResolver) CreateMaintenanceWindow(ctx context.Context, input models.MaintenanceWindowInput) (*models.MaintenanceWindow, error) {
+	ctx = callerContext(ctx)
+	window := &db.MaintenanceWindow{
+		ID: uuid.New().String(),
+	}
+	err := m.setMaintenanceWindowInput(ctx, window, input)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.MaintenanceWindows().Create(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	window, err = m.Storage.MaintenanceWindows().Get(ctx, window.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mapMaintenanceWindow(*window)
+}
+
+func (m mutationResolver) UpdateMaintenanceWindow(ctx context.Context, id string, input models.MaintenanceWindowInput) (*models.MaintenanceWindow, error) {
+	ctx = callerContext(ctx)
+	window, err := m.Storage.MaintenanceWindows().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = authorizeWindow(ctx, *window)
+	if err != nil {
+		return nil, err
+	}
+	err = m.setMaintenanceWindowInput(ctx, window, input)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.MaintenanceWindows().Save(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	window, err = m.Storage.MaintenanceWindows().Get(ctx, window.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mapMaintenanceWindow(*window)
+}
+
+func (m mutationResolver) DeleteMaintenanceWindow(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	window, err := m.Storage.MaintenanceWindows().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = authorizeWindow(ctx, *window)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.MaintenanceWindows().Delete(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeleteResponse{ID: id}, nil
+}
+
+func (q queryResolver) MaintenanceWindows(ctx context.Context) ([]*models.MaintenanceWindow, error) {
+	windows, err := q.Storage.MaintenanceWindows().List(callerContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var modelWindows []*models.MaintenanceWindow
+	for _, window := range windows {
+		window, ok := visibleWindow(ctx, window)
+		if !ok {
+			continue
+		}
+		modelWindow, err := mapMaintenanceWindow(window)
+		if err != nil {
+			return nil, err
+		}
+		modelWindows = append(modelWindows, modelWindow)
+	}
+	return modelWindows, nil
+}
+
+func (m mutationResolver) setMaintenanceWindowInput(ctx context.Context, window *db.MaintenanceWindow, input models.MaintenanceWindowInput) error {
+	window.Title = input.Title
+	window.Description = stringValue(input.Description)
+	window.Mode = db.MaintenanceMode(input.Mode)
+	window.StartsAt = input.StartsAt
+	window.EndsAt = input.EndsAt
+	window.Schedule = stringValue(input.Schedule)
+	window.Duration = stringValue(input.Duration)
+	window.Timezone = stringValue(input.Timezone)
+	if window.Schedule != "" {
+		window.StartsAt = nil
+		window.EndsAt = nil
+	}
+	err := window.Validate()
+	if err != nil {
+		return err
+	}
+	checks, err := m.Storage.Checks().GetMany(ctx, input.CheckIds)
+	if err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, chk := range checks {
+		found[chk.ID] = authorizeCheck(ctx, chk) == nil
+	}
+	for _, id := range input.CheckIds {
+		if !found[id] {
+			return errors.Wrapf(storage.ErrNotFound, "Check %s", id)
+		}
+	}
+	window.Checks = checks
+	labels, err := mapLabelsInput(input.Labels)
+	if err != nil {
+		return err
+	}
+	if len(labels) > 0 && auth.IdentityFromContext(ctx).Scoped() {
+		return errors.New("Forbidden, only admins select the checks of maintenance windows by labels")
+	}
+	return window.SetLabels(labels)
+}
+
+// visibleWindow hides the checks of other teams from the window, the windows only affecting
+// checks of other teams are hidden altogether
+func visibleWindow(ctx context.Context, window db.MaintenanceWindow) (db.MaintenanceWindow, bool) {
+	var checks []db.Check
+	for _, chk := range window.Checks {
+		if authorizeCheck(ctx, chk) == nil {
+			checks = append(checks, chk)
+		}
+	}
+	visible := len(checks) > 0 || len(window.Checks) == 0
+	window.Checks = checks
+	return window, visible
+}
+
+// authorizeWindow only lets scoped identities change the windows affecting the checks of their
+// teams alone, the windows selecting checks by labels may match the checks of any team
+func authorizeWindow(ctx context.Context, window db.MaintenanceWindow) error {
+	if !auth.IdentityFromContext(ctx).Scoped() {
+		return nil
+	}
+	visible, ok := visibleWindow(ctx, window)
+	if !ok {
+		return storage.ErrNotFound
+	}
+	labels, err := window.GetLabels()
+	if err != nil {
+		return err
+	}
+	if len(labels) > 0 || len(visible.Checks) != len(window.Checks) {
+		return errors.New("Forbidden, the maintenance window affects checks of other teams")
+	}
+	return nil
+}
+
+func mapMaintenanceWindow(window db.MaintenanceWindow) (*models.MaintenanceWindow, error) {
+	active, err := window.ActiveAt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	labels, err := window.GetLabels()
+	if err != nil {
+		return nil, err
+	}
+	modelWindow := &models.MaintenanceWindow{
+		ID:          window.ID,
+		Title:       window.Title,
+		Description: window.Description,
+		Mode:        models.MaintenanceMode(window.Mode),
+		StartsAt:    window.StartsAt,
+		EndsAt:      window.EndsAt,
+		Schedule:    window.Schedule,
+		Duration:    window.Duration,
+		Timezone:    window.Timezone,
+		Active:      active,
+		Checks:      []models.Check{},
+		Labels:      mapLabels(labels),
+	}
+	for _, chk := range window.Checks {
+		modelCheck, err := mapCheck(chk)
+		if err != nil {
+			return nil, err
+		}
+		if modelCheck != nil {
+			modelWindow.Checks = append(modelWindow.Checks, modelCheck)
+		}
+	}
+	return modelWindow, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
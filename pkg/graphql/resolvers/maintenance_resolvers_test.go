@@ -0,0 +1,103 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func windowInput(checkIDs []string, labels ...*models.LabelInput) models.MaintenanceWindowInput {
+	startsAt := time.Now()
+	endsAt := startsAt.Add(time.Hour)
+	return models.MaintenanceWindowInput{
+		Title:    "Upgrade",
+		Mode:     models.MaintenanceModePause,
+		StartsAt: &startsAt,
+		EndsAt:   &endsAt,
+		CheckIds: checkIDs,
+		Labels:   labels,
+	}
+}
+
+func TestMaintenanceWindowsOfOtherTeams(t *testing.T) {
+	r := newTeamChecks(t, "payments", "search")
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	payments, err := r.Storage.Checks().GetByIdentifier(ctx, "payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	search, err := r.Storage.Checks().GetByIdentifier(ctx, "search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := mutationResolver{r}
+	q := queryResolver{r}
+	admin := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.AdminRole})
+	editor := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.EditorRole, Teams: []string{"payments"}})
+	viewer := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.ViewerRole, Teams: []string{"payments"}})
+
+	if _, err := m.CreateMaintenanceWindow(editor, windowInput([]string{payments.ID, search.ID})); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("CreateMaintenanceWindow() with the check of another team = %v, want ErrNotFound", err)
+	}
+	if _, err := m.CreateMaintenanceWindow(editor, windowInput([]string{uuid.New().String()})); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("CreateMaintenanceWindow() with an unknown check = %v, want ErrNotFound", err)
+	}
+	if _, err := m.CreateMaintenanceWindow(editor, windowInput(nil, &models.LabelInput{Name: "env", Value: "prod"})); err == nil {
+		t.Error("CreateMaintenanceWindow() selecting checks by labels as an editor succeeded")
+	}
+	own, err := m.CreateMaintenanceWindow(editor, windowInput([]string{payments.ID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.UpdateMaintenanceWindow(editor, own.ID, windowInput([]string{search.ID})); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateMaintenanceWindow() with the check of another team = %v, want ErrNotFound", err)
+	}
+	if _, err := m.CreateMaintenanceWindow(admin, windowInput(nil, &models.LabelInput{Name: "env", Value: "prod"})); err != nil {
+		t.Errorf("CreateMaintenanceWindow() selecting checks by labels as an admin = %v", err)
+	}
+	both, err := m.CreateMaintenanceWindow(admin, windowInput([]string{payments.ID, search.ID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := m.CreateMaintenanceWindow(admin, windowInput([]string{search.ID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.UpdateMaintenanceWindow(editor, both.ID, windowInput([]string{payments.ID})); err == nil {
+		t.Error("UpdateMaintenanceWindow() of a window affecting other teams as an editor succeeded")
+	}
+	if _, err := m.DeleteMaintenanceWindow(editor, other.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteMaintenanceWindow() of a window of another team = %v, want ErrNotFound", err)
+	}
+
+	windows, err := q.MaintenanceWindows(viewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := map[string][]string{}
+	for _, window := range windows {
+		checks[window.ID] = nil
+		for _, chk := range window.Checks {
+			checks[window.ID] = append(checks[window.ID], chk.(models.HTTPCheck).Identifier)
+		}
+	}
+	if _, ok := checks[other.ID]; ok {
+		t.Error("MaintenanceWindows() returned the window of another team")
+	}
+	if ids := checks[both.ID]; len(ids) != 1 || ids[0] != "payments" {
+		t.Errorf("MaintenanceWindows() = %v checks of the shared window, want only payments", ids)
+	}
+	windows, err = q.MaintenanceWindows(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 4 {
+		t.Errorf("MaintenanceWindows() as an admin = %d windows, want 4", len(windows))
+	}
+}
@@ -0,0 +1,98 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+)
+
+func (m mutationResolver) CreateNotificationChannel(ctx context.Context, input models.NotificationChannelInput) (*models.NotificationChannel, error) {
+	channel := &db.NotificationChannel{
+		ID: uuid.New().String(),
+	}
+	err := setNotificationChannelInput(channel, input)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.Channels().Create(callerContext(ctx), channel)
+	if err != nil {
+		return nil, err
+	}
+	return mapNotificationChannel(*channel)
+}
+
+func (m mutationResolver) UpdateNotificationChannel(ctx context.Context, id string, input models.NotificationChannelInput) (*models.NotificationChannel, error) {
+	ctx = callerContext(ctx)
+	channel, err := m.Storage.Channels().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = setNotificationChannelInput(channel, input)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.Channels().Save(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	return mapNotificationChannel(*channel)
+}
+
+func (m mutationResolver) DeleteNotificationChannel(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	channel, err := m.Storage.Channels().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.Channels().Delete(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeleteResponse{ID: id}, nil
+}
+
+func (q queryResolver) NotificationChannels(ctx context.Context) ([]*models.NotificationChannel, error) {
+	channels, err := q.Storage.Channels().List(callerContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var modelChannels []*models.NotificationChannel
+	for _, channel := range channels {
+		modelChannel, err := mapNotificationChannel(channel)
+		if err != nil {
+			return nil, err
+		}
+		modelChannels = append(modelChannels, modelChannel)
+	}
+	return modelChannels, nil
+}
+
+func setNotificationChannelInput(channel *db.NotificationChannel, input models.NotificationChannelInput) error {
+	channel.Name = input.Name
+	channel.Type = db.ChannelType(input.Type)
+	channel.Url = input.URL
+	err := channel.Validate()
+	if err != nil {
+		return err
+	}
+	labels, err := mapLabelsInput(input.Labels)
+	if err != nil {
+		return err
+	}
+	return channel.SetLabels(labels)
+}
+
+func mapNotificationChannel(channel db.NotificationChannel) (*models.NotificationChannel, error) {
+	labels, err := channel.GetLabels()
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationChannel{
+		ID:     channel.ID,
+		Name:   channel.Name,
+		Type:   models.NotificationChannelType(channel.Type),
+		URL:    channel.Url,
+		Labels: mapLabels(labels),
+	}, nil
+}
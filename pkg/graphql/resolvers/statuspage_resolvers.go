@@ -0,0 +1,157 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+)
+
+func (m mutationResolver) CreateStatusPage(ctx context.Context, input models.StatusPageInput) (*models.StatusPage, error) {
+	statusPage := &db.StatusPage{
+		ID: uuid.New().String(),
+	}
+	setStatusPageInput(statusPage, input)
+	err := m.Storage.StatusPages().Create(callerContext(ctx), statusPage)
+	if err != nil {
+		return nil, err
+	}
+	return mapStatusPage(*statusPage)
+}
+
+func (m mutationResolver) UpdateStatusPage(ctx context.Context, id string, input models.StatusPageInput) (*models.StatusPage, error) {
+	ctx = callerContext(ctx)
+	statusPage, err := m.Storage.StatusPages().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	setStatusPageInput(statusPage, input)
+	err = m.Storage.StatusPages().Save(ctx, statusPage)
+	if err != nil {
+		return nil, err
+	}
+	return mapStatusPage(*statusPage)
+}
+
+func (m mutationResolver) DeleteStatusPage(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	statusPage, err := m.Storage.StatusPages().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.StatusPages().Delete(ctx, statusPage)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeleteResponse{ID: id}, nil
+}
+
+func (m mutationResolver) AddStatusPageComponent(ctx context.Context, input models.AddStatusPageComponentInput) (*models.StatusPageComponent, error) {
+	ctx = callerContext(ctx)
+	statusPage, err := m.Storage.StatusPages().Get(ctx, input.StatusPageID)
+	if err != nil {
+		return nil, err
+	}
+	chk, err := m.Storage.Checks().Get(ctx, input.CheckID)
+	if err != nil {
+		return nil, err
+	}
+	component := db.StatusPageComponent{
+		ID:           uuid.New().String(),
+		StatusPageID: statusPage.ID,
+		Name:         input.Name,
+		CheckID:      chk.ID,
+	}
+	if input.Group != nil {
+		component.GroupName = *input.Group
+	}
+	if input.Position != nil {
+		component.Position = *input.Position
+	}
+	err = m.Storage.StatusPages().AddComponent(ctx, &component)
+	if err != nil {
+		return nil, err
+	}
+	component.Check = *chk
+	return mapStatusPageComponent(component)
+}
+
+func (m mutationResolver) RemoveStatusPageComponent(ctx context.Context, id string) (*models.DeleteResponse, error) {
+	ctx = callerContext(ctx)
+	component, err := m.Storage.StatusPages().GetComponent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Storage.StatusPages().RemoveComponent(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeleteResponse{ID: id}, nil
+}
+
+func (q queryResolver) StatusPages(ctx context.Context) ([]*models.StatusPage, error) {
+	statusPages, err := q.Storage.StatusPages().List(callerContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var modelStatusPages []*models.StatusPage
+	for _, statusPage := range statusPages {
+		modelStatusPage, err := mapStatusPage(statusPage)
+		if err != nil {
+			return nil, err
+		}
+		modelStatusPages = append(modelStatusPages, modelStatusPage)
+	}
+	return modelStatusPages, nil
+}
+
+func setStatusPageInput(statusPage *db.StatusPage, input models.StatusPageInput) {
+	statusPage.Slug = input.Slug
+	statusPage.Title = input.Title
+	statusPage.LogoURL = ""
+	if input.LogoURL != nil {
+		statusPage.LogoURL = *input.LogoURL
+	}
+	statusPage.Domain = ""
+	if input.Domain != nil {
+		statusPage.Domain = *input.Domain
+	}
+	statusPage.GroupByLabel = stringValue(input.GroupByLabel)
+}
+
+func mapStatusPage(statusPage db.StatusPage) (*models.StatusPage, error) {
+	modelStatusPage := &models.StatusPage{
+		ID:           statusPage.ID,
+		Slug:         statusPage.Slug,
+		Title:        statusPage.Title,
+		LogoURL:      statusPage.LogoURL,
+		Domain:       statusPage.Domain,
+		GroupByLabel: statusPage.GroupByLabel,
+		Components:   []*models.StatusPageComponent{},
+	}
+	for _, component := range statusPage.Components {
+		modelComponent, err := mapStatusPageComponent(component)
+		if err != nil {
+			return nil, err
+		}
+		modelStatusPage.Components = append(modelStatusPage.Components, modelComponent)
+	}
+	return modelStatusPage, nil
+}
+
+func mapStatusPageComponent(component db.StatusPageComponent) (*models.StatusPageComponent, error) {
+	modelComponent := &models.StatusPageComponent{
+		ID:       component.ID,
+		Name:     component.Name,
+		Group:    component.GroupName,
+		Position: component.Position,
+	}
+	if component.Check.ID != "" {
+		modelCheck, err := mapCheck(component.Check)
+		if err != nil {
+			return nil, err
+		}
+		modelComponent.Check = modelCheck
+	}
+	return modelComponent, nil
+}
@@ -0,0 +1,118 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/events"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type subscriptionResolver struct{ *Resolver }
+
+func (s subscriptionResolver) CheckStatusChanged(ctx context.Context, ids []string) (<-chan models.Check, error) {
+	filter := map[string]bool{}
+	for _, id := range ids {
+		filter[id] = true
+	}
+	ch := make(chan models.Check)
+	err := s.subscribe(ctx, events.CheckStatusChanged, func(event events.Event) {
+		if len(filter) > 0 && !filter[event.ID] {
+			return
+		}
+		chk := event.Payload.(db.Check)
+		if authorizeCheck(ctx, chk) != nil {
+			return
+		}
+		modelCheck, err := mapCheck(chk)
+		if err != nil {
+			log.Warnf("Failed to map check id=%s: %v", event.ID, err)
+			return
+		}
+		if modelCheck == nil {
+			return
+		}
+		select {
+		case ch <- modelCheck:
+		case <-ctx.Done():
+		}
+	}, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (s subscriptionResolver) ExecutionRecorded(ctx context.Context, checkID string) (<-chan *models.CheckExecution, error) {
+	chk, err := findAuthorizedCheck(callerContext(ctx), s.Storage, checkID)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *models.CheckExecution)
+	err = s.subscribe(ctx, events.ExecutionRecorded, func(event events.Event) {
+		if event.ID != checkID {
+			return
+		}
+		modelExecution, err := mapExecution(event.Payload.(db.CheckExecution), chk.Type)
+		if err != nil {
+			log.Warnf("Failed to map execution id=%s: %v", event.ID, err)
+			return
+		}
+		select {
+		case ch <- modelExecution:
+		case <-ctx.Done():
+		}
+	}, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (s subscriptionResolver) IncidentUpdated(ctx context.Context) (<-chan *models.Incident, error) {
+	ch := make(chan *models.Incident)
+	err := s.subscribe(ctx, events.IncidentUpdated, func(event events.Event) {
+		incident := event.Payload.(db.Incident)
+		if !inWorkspace(ctx, incident.WorkspaceID) {
+			return
+		}
+		modelIncident, err := mapIncident(incident)
+		if err != nil {
+			log.Warnf("Failed to map incident id=%s: %v", event.ID, err)
+			return
+		}
+		select {
+		case ch <- modelIncident:
+		case <-ctx.Done():
+		}
+	}, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// subscribe forwards the events of the given type to handle until the subscription is closed by the client
+func (s subscriptionResolver) subscribe(ctx context.Context, eventType events.Type, handle func(events.Event), done func()) error {
+	if s.Bus == nil {
+		return errors.New("Subscriptions are not available")
+	}
+	eventsCh, unsubscribe := s.Bus.Subscribe(eventType)
+	go func() {
+		defer done()
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-eventsCh:
+				if !ok {
+					return
+				}
+				handle(event)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,112 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/events"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionsOfScopedIdentities(t *testing.T) {
+	r := newTeamChecks(t, "payments", "search")
+	r.Bus = events.NewBus()
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	checks := map[string]db.Check{}
+	for _, identifier := range []string{"payments", "search"} {
+		chk, err := r.Storage.Checks().GetByIdentifier(ctx, identifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checks[identifier] = *chk
+	}
+	deleted, err := r.Storage.Checks().ListDeleted(ctx, []string{"payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks["payments-deleted"] = deleted[0]
+	s := subscriptionResolver{r}
+	viewer := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.ViewerRole, Teams: []string{"payments"}})
+
+	t.Run("checkStatusChanged", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(viewer)
+		defer cancel()
+		ch, err := s.CheckStatusChanged(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Bus.Publish(events.Event{Type: events.CheckStatusChanged, ID: checks["search"].ID, Payload: checks["search"]})
+		r.Bus.Publish(events.Event{Type: events.CheckStatusChanged, ID: checks["payments"].ID, Payload: checks["payments"]})
+		if chk := receiveCheck(t, ch); chk.Identifier != "payments" {
+			t.Errorf("checkStatusChanged received %s, want only the checks of the team", chk.Identifier)
+		}
+	})
+	t.Run("checkStatusChanged by ids", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(viewer)
+		defer cancel()
+		ch, err := s.CheckStatusChanged(ctx, []string{checks["payments"].ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Bus.Publish(events.Event{Type: events.CheckStatusChanged, ID: checks["payments-deleted"].ID, Payload: checks["payments-deleted"]})
+		r.Bus.Publish(events.Event{Type: events.CheckStatusChanged, ID: checks["payments"].ID, Payload: checks["payments"]})
+		if chk := receiveCheck(t, ch); chk.Identifier != "payments" {
+			t.Errorf("checkStatusChanged received %s, want only the checks with the ids", chk.Identifier)
+		}
+	})
+	t.Run("executionRecorded", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(viewer)
+		defer cancel()
+		if _, err := s.ExecutionRecorded(ctx, checks["search"].ID); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("executionRecorded of the check of another team = %v, want ErrNotFound", err)
+		}
+		ch, err := s.ExecutionRecorded(ctx, checks["payments"].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Bus.Publish(events.Event{Type: events.ExecutionRecorded, ID: checks["search"].ID, Payload: db.CheckExecution{ID: "search", CheckID: checks["search"].ID, Status: db.Up}})
+		r.Bus.Publish(events.Event{Type: events.ExecutionRecorded, ID: checks["payments"].ID, Payload: db.CheckExecution{ID: "payments", CheckID: checks["payments"].ID, Status: db.Up}})
+		select {
+		case execution := <-ch:
+			if execution.ID != "payments" {
+				t.Errorf("executionRecorded received %s, want only the executions of the check", execution.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("executionRecorded received no execution")
+		}
+	})
+	t.Run("unsubscribe", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(viewer)
+		ch, err := s.CheckStatusChanged(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Error("checkStatusChanged received a check after the subscription was closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("checkStatusChanged wasn't closed with the subscription")
+		}
+	})
+	if _, err := (subscriptionResolver{&Resolver{Storage: r.Storage}}).CheckStatusChanged(viewer, nil); err == nil {
+		t.Error("checkStatusChanged without a bus succeeded")
+	}
+}
+
+func receiveCheck(t *testing.T, ch <-chan models.Check) models.HTTPCheck {
+	t.Helper()
+	select {
+	case chk := <-ch:
+		return chk.(models.HTTPCheck)
+	case <-time.After(time.Second):
+		t.Fatal("checkStatusChanged received no check")
+		return models.HTTPCheck{}
+	}
+}
@@ -0,0 +1,33 @@
+package resolvers
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/auth"
+	"github.com/kfsoftware/statuspage/pkg/graphql/models"
+)
+
+func (q queryResolver) Workspace(ctx context.Context) (*models.Workspace, error) {
+	workspaceID := auth.IdentityFromContext(ctx).GetWorkspace()
+	workspace, err := q.Storage.Workspaces().Get(callerContext(ctx), workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := q.Storage.Workspaces().Usage(callerContext(ctx), workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Workspace{
+		ID:   workspace.ID,
+		Name: workspace.Name,
+		Quotas: &models.WorkspaceQuotas{
+			Checks:               workspace.MaxChecks,
+			StatusPages:          workspace.MaxStatusPages,
+			NotificationChannels: workspace.MaxNotificationChannels,
+		},
+		Usage: &models.WorkspaceQuotas{
+			Checks:               int(usage.Checks),
+			StatusPages:          int(usage.StatusPages),
+			NotificationChannels: int(usage.NotificationChannels),
+		},
+	}, nil
+}
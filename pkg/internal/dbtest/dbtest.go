@@ -0,0 +1,42 @@
+// Package dbtest opens the SQLite databases of the tests
+package dbtest
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"path/filepath"
+	"testing"
+)
+
+// Open returns a new SQLite database in the temporary directory of the test, closed when the test
+// ends. The plugins are used before the database is migrated, migrate may be nil to keep it empty.
+// pkg/db isn't imported so that its own tests can use this package, the callers pass db.SQLite
+// and db.Migrate
+func Open(t *testing.T, dialector func(path string) gorm.Dialector, migrate func(db *gorm.DB) error, plugins ...gorm.Plugin) *gorm.DB {
+	t.Helper()
+	dbClient, err := gorm.Open(dialector(filepath.Join(t.TempDir(), "statuspage.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, err := dbClient.DB()
+		if err == nil {
+			sqlDB.Close()
+		}
+	})
+	for _, plugin := range plugins {
+		err = dbClient.Use(plugin)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if migrate != nil {
+		err = migrate(dbClient)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dbClient
+}
@@ -0,0 +1,232 @@
+package manifest
+
+import (
+	"encoding/json"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// Manifest describes the desired checks, notification channels and status pages,
+// JSON manifests are accepted as they are valid YAML
+type Manifest struct {
+	Checks               []Check               `yaml:"checks"`
+	NotificationChannels []NotificationChannel `yaml:"notificationChannels"`
+	StatusPages          []StatusPage          `yaml:"statusPages"`
+}
+
+// Check is identified by its id, the identifier of the check in the API
+type Check struct {
+	ID        string     `yaml:"id"`
+	Type      check.Type `yaml:"type"`
+	Frequency string     `yaml:"frequency"`
+	// Frecuency is the deprecated spelling of frequency, still accepted
+	Frecuency   string            `yaml:"frecuency,omitempty"`
+	Url         string            `yaml:"url"`
+	Address     string            `yaml:"address"`
+	RootCAs     string            `yaml:"rootCAs"`
+	Description string            `yaml:"description"`
+	Owner       string            `yaml:"owner"`
+	Labels      map[string]string `yaml:"labels"`
+	// Assertions are only verified by statuspage run, except the status code the server expects too
+	Assertions check.Assertions `yaml:"assertions"`
+}
+
+// NotificationChannel is identified by its name
+type NotificationChannel struct {
+	Name   string            `yaml:"name"`
+	Type   db.ChannelType    `yaml:"type"`
+	Url    string            `yaml:"url"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// StatusPage is identified by its slug, its components are identified by their name
+type StatusPage struct {
+	Slug         string      `yaml:"slug"`
+	Title        string      `yaml:"title"`
+	LogoURL      string      `yaml:"logoUrl"`
+	Domain       string      `yaml:"domain"`
+	GroupByLabel string      `yaml:"groupByLabel"`
+	Components   []Component `yaml:"components"`
+}
+
+// Component references the check it publishes by its id
+type Component struct {
+	Name     string `yaml:"name"`
+	Check    string `yaml:"check"`
+	Group    string `yaml:"group"`
+	Position int    `yaml:"position"`
+}
+
+// Load reads the manifest from the file, or from the standard input when the file is -
+func Load(file string) (*Manifest, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	err := yaml.UnmarshalStrict(data, m)
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.Checks {
+		err = m.Checks[i].migrateFrecuency()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = m.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate verifies the manifest can be executed by run, the frequencies are only required to
+// schedule the checks, see ValidateFrequencies
+func (m Manifest) Validate() error {
+	checks := map[string]bool{}
+	for _, chk := range m.Checks {
+		err := chk.Validate()
+		if err != nil {
+			return err
+		}
+		if checks[chk.ID] {
+			return errors.Errorf("Duplicated check %s", chk.ID)
+		}
+		checks[chk.ID] = true
+	}
+	channels := map[string]bool{}
+	for _, channel := range m.NotificationChannels {
+		if channel.Name == "" {
+			return errors.New("Notification channels require a name")
+		}
+		if channels[channel.Name] {
+			return errors.Errorf("Duplicated notification channel %s", channel.Name)
+		}
+		channels[channel.Name] = true
+		err := db.NotificationChannel{Type: channel.Type, Url: channel.Url}.Validate()
+		if err != nil {
+			return errors.Wrapf(err, "Invalid notification channel %s", channel.Name)
+		}
+	}
+	statusPages := map[string]bool{}
+	for _, statusPage := range m.StatusPages {
+		if statusPage.Slug == "" {
+			return errors.New("Status pages require a slug")
+		}
+		if statusPages[statusPage.Slug] {
+			return errors.Errorf("Duplicated status page %s", statusPage.Slug)
+		}
+		statusPages[statusPage.Slug] = true
+		components := map[string]bool{}
+		for _, component := range statusPage.Components {
+			if component.Name == "" {
+				return errors.Errorf("Components of status page %s require a name", statusPage.Slug)
+			}
+			if components[component.Name] {
+				return errors.Errorf("Duplicated component %s in status page %s", component.Name, statusPage.Slug)
+			}
+			components[component.Name] = true
+			if component.Check == "" {
+				return errors.Errorf("Component %s of status page %s requires a check", component.Name, statusPage.Slug)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateFrequencies verifies every check has a frequency, which is required to apply the manifest
+func (m Manifest) ValidateFrequencies() error {
+	for _, chk := range m.Checks {
+		_, err := time.ParseDuration(chk.Frequency)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid frequency of check %s", chk.ID)
+		}
+	}
+	return nil
+}
+
+// migrateFrecuency moves the frequency set with the deprecated frecuency key to Frequency
+func (c *Check) migrateFrecuency() error {
+	if c.Frecuency == "" {
+		return nil
+	}
+	if c.Frequency != "" && c.Frequency != c.Frecuency {
+		return errors.Errorf("Check %s sets both frequency and frecuency, remove the deprecated frecuency", c.ID)
+	}
+	log.Warnf("Check %s uses the deprecated key frecuency, rename it to frequency", c.ID)
+	c.Frequency = c.Frecuency
+	c.Frecuency = ""
+	return nil
+}
+
+// Validate verifies the check can be executed, the frequency is verified by ValidateFrequencies
+func (c Check) Validate() error {
+	if c.ID == "" {
+		return errors.New("Checks require an id")
+	}
+	_, err := c.data()
+	return err
+}
+
+// Model returns the check stored in the database for the manifest check
+func (c Check) Model(id string) (*db.Check, error) {
+	data, err := c.data()
+	if err != nil {
+		return nil, err
+	}
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &db.Check{
+		ID:          id,
+		Identifier:  c.ID,
+		Type:        c.Type,
+		Data:        jsonBytes,
+		Frequency:   c.Frequency,
+		Description: c.Description,
+		Owner:       c.Owner,
+		Labels:      db.NewCheckLabels(id, c.Labels),
+		Status:      db.Scheduled,
+	}, nil
+}
+
+// data returns the data stored for the check depending on its type
+func (c Check) data() (interface{}, error) {
+	var data interface{}
+	target := c.Address
+	switch c.Type {
+	case check.HttpType:
+		data = db.HttpCheckData{Url: c.Url, StatusCode: c.Assertions.StatusCode}
+		target = c.Url
+	case check.TcpType:
+		data = db.TcpCheckData{Address: c.Address}
+	case check.TlsType:
+		data = db.TlsCheckData{Address: c.Address, RootCAs: c.RootCAs}
+	case check.IcmpType:
+		data = db.IcmpCheckData{Address: c.Address}
+	default:
+		return nil, errors.Errorf("Invalid type %s of check %s", c.Type, c.ID)
+	}
+	if target == "" {
+		return nil, errors.Errorf("Check %s requires an url or an address", c.ID)
+	}
+	return data, nil
+}
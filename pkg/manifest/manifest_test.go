@@ -0,0 +1,63 @@
+package manifest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const unscheduledManifest = `
+checks:
+  - id: api
+    type: tcp
+    address: localhost:8080
+`
+
+func TestFrequencyIsOnlyRequiredToApply(t *testing.T) {
+	m, err := Parse([]byte(unscheduledManifest))
+	if err != nil {
+		t.Fatalf("Parse() of a check without frequency = %v", err)
+	}
+	_, err = Plan(context.Background(), nil, m, false)
+	if err == nil || !strings.Contains(err.Error(), "Invalid frequency of check api") {
+		t.Errorf("Plan() of a check without frequency = %v, want an invalid frequency error", err)
+	}
+}
+
+func TestFrecuencyIsADeprecatedAlias(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      string
+		frequency string
+		err       string
+	}{
+		{"frequency", "frequency: 1m", "1m", ""},
+		{"frecuency", "frecuency: 30s", "30s", ""},
+		{"both", "frequency: 1m\n    frecuency: 1m", "1m", ""},
+		{"both different", "frequency: 1m\n    frecuency: 30s", "", "Check api sets both frequency and frecuency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(unscheduledManifest + "    " + tt.keys + "\n"))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Parse() = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Checks[0].Frequency != tt.frequency {
+				t.Errorf("Frequency = %s, want %s", m.Checks[0].Frequency, tt.frequency)
+			}
+			model, err := m.Checks[0].Model("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if model.Frequency != tt.frequency {
+				t.Errorf("Frequency of the model = %s, want %s", model.Frequency, tt.frequency)
+			}
+		})
+	}
+}
@@ -0,0 +1,462 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+)
+
+type Action string
+
+const (
+	Create Action = "create"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// pageSize is the number of checks read at once when planning
+const pageSize = 500
+
+// Change is a single operation converging the storage to the manifest
+type Change struct {
+	Action Action
+	Kind   string
+	Name   string
+	// Fields holds the fields modified by an update
+	Fields []string
+	apply  func(ctx context.Context, store storage.Storage) error
+}
+
+func (c Change) String() string {
+	symbol := "+"
+	switch c.Action {
+	case Update:
+		symbol = "~"
+	case Delete:
+		symbol = "-"
+	}
+	change := fmt.Sprintf("%s %s %s", symbol, c.Kind, c.Name)
+	if len(c.Fields) > 0 {
+		change = fmt.Sprintf("%s (%s)", change, strings.Join(c.Fields, ", "))
+	}
+	return change
+}
+
+// Plan compares the manifest with the workspace of the context, nothing missing from the
+// manifest is deleted unless prune is set
+func Plan(ctx context.Context, store storage.Storage, m *Manifest, prune bool) ([]Change, error) {
+	err := m.ValidateFrequencies()
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	channelChanges, err := planNotificationChannels(ctx, store, m, prune)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, channelChanges...)
+	checkChanges, checkIDs, err := planChecks(ctx, store, m, prune)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, checkChanges...)
+	statusPageChanges, err := planStatusPages(ctx, store, m, checkIDs, prune)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, statusPageChanges...)
+	return changes, nil
+}
+
+// Apply executes the changes in a single transaction, the actor of the audit log is the one
+// of the context
+func Apply(ctx context.Context, store storage.Storage, changes []Change) error {
+	return store.Transaction(ctx, func(tx storage.Storage) error {
+		for _, change := range changes {
+			err := change.apply(ctx, tx)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to %s %s %s", change.Action, change.Kind, change.Name)
+			}
+		}
+		return nil
+	})
+}
+
+// listChecks returns every check of the workspace sorted by identifier
+func listChecks(ctx context.Context, store storage.Storage) ([]db.Check, error) {
+	var checks []db.Check
+	first := pageSize
+	p := db.Pagination{First: &first}
+	for {
+		page, err := store.Checks().List(ctx, db.CheckFilter{}, db.Order{Column: db.IdentifierColumn, Direction: db.Asc}, p)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, page.Checks...)
+		if !page.PageInfo.HasNextPage || len(page.Cursors) == 0 {
+			return checks, nil
+		}
+		p.After = &page.Cursors[len(page.Cursors)-1]
+	}
+}
+
+// planChecks returns the changes of the checks and the ids of the checks that can
+// be referenced by the components, indexed by identifier
+func planChecks(ctx context.Context, store storage.Storage, m *Manifest, prune bool) ([]Change, map[string]string, error) {
+	existingChecks, err := listChecks(ctx, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := map[string]db.Check{}
+	checkIDs := map[string]string{}
+	for _, chk := range existingChecks {
+		existing[chk.Identifier] = chk
+		checkIDs[chk.Identifier] = chk.ID
+	}
+	var changes []Change
+	desired := map[string]bool{}
+	for _, manifestCheck := range m.Checks {
+		desired[manifestCheck.ID] = true
+		labels := db.Labels(manifestCheck.Labels)
+		chk, ok := existing[manifestCheck.ID]
+		if !ok {
+			newCheck, err := manifestCheck.Model(uuid.New().String())
+			if err != nil {
+				return nil, nil, err
+			}
+			checkIDs[manifestCheck.ID] = newCheck.ID
+			changes = append(changes, Change{
+				Action: Create,
+				Kind:   "check",
+				Name:   manifestCheck.ID,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.Checks().Create(ctx, newCheck)
+				},
+			})
+			continue
+		}
+		data, err := manifestCheck.data()
+		if err != nil {
+			return nil, nil, err
+		}
+		jsonBytes, err := json.Marshal(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		existingData, err := chk.GetData()
+		if err != nil {
+			return nil, nil, err
+		}
+		var fields []string
+		if chk.Type != manifestCheck.Type {
+			fields = append(fields, "type")
+		}
+		if chk.Frequency != manifestCheck.Frequency {
+			fields = append(fields, "frequency")
+		}
+		if !reflect.DeepEqual(existingData, data) {
+			fields = append(fields, "data")
+		}
+		if chk.Description != manifestCheck.Description {
+			fields = append(fields, "description")
+		}
+		if chk.Owner != manifestCheck.Owner {
+			fields = append(fields, "owner")
+		}
+		if !chk.GetLabels().Equal(labels) {
+			fields = append(fields, "labels")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		chk.Type = manifestCheck.Type
+		chk.Frequency = manifestCheck.Frequency
+		chk.Data = jsonBytes
+		chk.Description = manifestCheck.Description
+		chk.Owner = manifestCheck.Owner
+		chk.Labels = db.NewCheckLabels(chk.ID, labels)
+		changes = append(changes, Change{
+			Action: Update,
+			Kind:   "check",
+			Name:   manifestCheck.ID,
+			Fields: fields,
+			apply: func(ctx context.Context, store storage.Storage) error {
+				return store.Checks().Save(ctx, db.UpdateOperation, &chk)
+			},
+		})
+	}
+	if prune {
+		for _, chk := range existingChecks {
+			if desired[chk.Identifier] {
+				continue
+			}
+			delete(checkIDs, chk.Identifier)
+			chk := chk
+			changes = append(changes, Change{
+				Action: Delete,
+				Kind:   "check",
+				Name:   chk.Identifier,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.Checks().Delete(ctx, &chk)
+				},
+			})
+		}
+	}
+	return changes, checkIDs, nil
+}
+
+func planNotificationChannels(ctx context.Context, store storage.Storage, m *Manifest, prune bool) ([]Change, error) {
+	existingChannels, err := store.Channels().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]db.NotificationChannel{}
+	for _, channel := range existingChannels {
+		existing[channel.Name] = channel
+	}
+	var changes []Change
+	desired := map[string]bool{}
+	for _, manifestChannel := range m.NotificationChannels {
+		desired[manifestChannel.Name] = true
+		labels := db.Labels(manifestChannel.Labels)
+		channel, ok := existing[manifestChannel.Name]
+		if !ok {
+			channel = db.NotificationChannel{
+				ID:   uuid.New().String(),
+				Name: manifestChannel.Name,
+				Type: manifestChannel.Type,
+				Url:  manifestChannel.Url,
+			}
+			err := channel.SetLabels(labels)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{
+				Action: Create,
+				Kind:   "notification channel",
+				Name:   manifestChannel.Name,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.Channels().Create(ctx, &channel)
+				},
+			})
+			continue
+		}
+		existingLabels, err := channel.GetLabels()
+		if err != nil {
+			return nil, err
+		}
+		var fields []string
+		if channel.Type != manifestChannel.Type {
+			fields = append(fields, "type")
+		}
+		if channel.Url != manifestChannel.Url {
+			fields = append(fields, "url")
+		}
+		if !existingLabels.Equal(labels) {
+			fields = append(fields, "labels")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		channel.Type = manifestChannel.Type
+		channel.Url = manifestChannel.Url
+		err = channel.SetLabels(labels)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{
+			Action: Update,
+			Kind:   "notification channel",
+			Name:   manifestChannel.Name,
+			Fields: fields,
+			apply: func(ctx context.Context, store storage.Storage) error {
+				return store.Channels().Save(ctx, &channel)
+			},
+		})
+	}
+	if prune {
+		for _, channel := range existingChannels {
+			if desired[channel.Name] {
+				continue
+			}
+			channel := channel
+			changes = append(changes, Change{
+				Action: Delete,
+				Kind:   "notification channel",
+				Name:   channel.Name,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.Channels().Delete(ctx, &channel)
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func planStatusPages(ctx context.Context, store storage.Storage, m *Manifest, checkIDs map[string]string, prune bool) ([]Change, error) {
+	existingStatusPages, err := store.StatusPages().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]db.StatusPage{}
+	for _, statusPage := range existingStatusPages {
+		existing[statusPage.Slug] = statusPage
+	}
+	var changes []Change
+	desired := map[string]bool{}
+	for _, manifestStatusPage := range m.StatusPages {
+		desired[manifestStatusPage.Slug] = true
+		statusPage, ok := existing[manifestStatusPage.Slug]
+		var fields []string
+		if !ok {
+			statusPage = db.StatusPage{
+				ID:   uuid.New().String(),
+				Slug: manifestStatusPage.Slug,
+			}
+		} else {
+			if statusPage.Title != manifestStatusPage.Title {
+				fields = append(fields, "title")
+			}
+			if statusPage.LogoURL != manifestStatusPage.LogoURL {
+				fields = append(fields, "logoUrl")
+			}
+			if statusPage.Domain != manifestStatusPage.Domain {
+				fields = append(fields, "domain")
+			}
+			if statusPage.GroupByLabel != manifestStatusPage.GroupByLabel {
+				fields = append(fields, "groupByLabel")
+			}
+		}
+		existingComponents := statusPage.Components
+		statusPage.Title = manifestStatusPage.Title
+		statusPage.LogoURL = manifestStatusPage.LogoURL
+		statusPage.Domain = manifestStatusPage.Domain
+		statusPage.GroupByLabel = manifestStatusPage.GroupByLabel
+		statusPage.Components = nil
+		pageToSave := statusPage
+		if !ok {
+			changes = append(changes, Change{
+				Action: Create,
+				Kind:   "status page",
+				Name:   manifestStatusPage.Slug,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.StatusPages().Create(ctx, &pageToSave)
+				},
+			})
+		} else if len(fields) > 0 {
+			changes = append(changes, Change{
+				Action: Update,
+				Kind:   "status page",
+				Name:   manifestStatusPage.Slug,
+				Fields: fields,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.StatusPages().Save(ctx, &pageToSave)
+				},
+			})
+		}
+		componentChanges, err := planComponents(statusPage, existingComponents, manifestStatusPage.Components, checkIDs, prune)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, componentChanges...)
+	}
+	if prune {
+		for _, statusPage := range existingStatusPages {
+			if desired[statusPage.Slug] {
+				continue
+			}
+			statusPage := statusPage
+			changes = append(changes, Change{
+				Action: Delete,
+				Kind:   "status page",
+				Name:   statusPage.Slug,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.StatusPages().Delete(ctx, &statusPage)
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func planComponents(statusPage db.StatusPage, existingComponents []db.StatusPageComponent, manifestComponents []Component, checkIDs map[string]string, prune bool) ([]Change, error) {
+	existing := map[string]db.StatusPageComponent{}
+	for _, component := range existingComponents {
+		existing[component.Name] = component
+	}
+	var changes []Change
+	desired := map[string]bool{}
+	for _, manifestComponent := range manifestComponents {
+		desired[manifestComponent.Name] = true
+		name := fmt.Sprintf("%s/%s", statusPage.Slug, manifestComponent.Name)
+		checkID, ok := checkIDs[manifestComponent.Check]
+		if !ok {
+			return nil, errors.Errorf("Component %s references the unknown check %s", name, manifestComponent.Check)
+		}
+		component, ok := existing[manifestComponent.Name]
+		var fields []string
+		if !ok {
+			component = db.StatusPageComponent{
+				ID:           uuid.New().String(),
+				StatusPageID: statusPage.ID,
+				Name:         manifestComponent.Name,
+			}
+		} else {
+			if component.CheckID != checkID {
+				fields = append(fields, "check")
+			}
+			if component.GroupName != manifestComponent.Group {
+				fields = append(fields, "group")
+			}
+			if component.Position != manifestComponent.Position {
+				fields = append(fields, "position")
+			}
+		}
+		component.CheckID = checkID
+		component.GroupName = manifestComponent.Group
+		component.Position = manifestComponent.Position
+		if !ok {
+			changes = append(changes, Change{
+				Action: Create,
+				Kind:   "component",
+				Name:   name,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.StatusPages().AddComponent(ctx, &component)
+				},
+			})
+		} else if len(fields) > 0 {
+			changes = append(changes, Change{
+				Action: Update,
+				Kind:   "component",
+				Name:   name,
+				Fields: fields,
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.StatusPages().SaveComponent(ctx, &component)
+				},
+			})
+		}
+	}
+	if prune {
+		for _, component := range existingComponents {
+			if desired[component.Name] {
+				continue
+			}
+			component := component
+			changes = append(changes, Change{
+				Action: Delete,
+				Kind:   "component",
+				Name:   fmt.Sprintf("%s/%s", statusPage.Slug, component.Name),
+				apply: func(ctx context.Context, store storage.Storage) error {
+					return store.StatusPages().RemoveComponent(ctx, &component)
+				},
+			})
+		}
+	}
+	return changes, nil
+}
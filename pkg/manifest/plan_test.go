@@ -0,0 +1,240 @@
+package manifest
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"reflect"
+	"testing"
+)
+
+const appliedManifest = `
+checks:
+  - id: api
+    type: tcp
+    frequency: 30s
+    address: localhost:8080
+    labels:
+      team: core
+  - id: web
+    type: http
+    frequency: 1m
+    url: https://example.com
+notificationChannels:
+  - name: ops
+    type: WEBHOOK
+    url: https://hooks.example.com/ops
+statusPages:
+  - slug: acme
+    title: Acme
+    components:
+      - name: API
+        check: api
+        group: Backend
+      - name: Website
+        check: web
+`
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		manifest string
+		prune    bool
+		want     []string
+	}{
+		{
+			name:     "create",
+			manifest: appliedManifest,
+			want: []string{
+				"+ notification channel ops",
+				"+ check api",
+				"+ check web",
+				"+ status page acme",
+				"+ component acme/API",
+				"+ component acme/Website",
+			},
+		},
+		{
+			name:     "unchanged",
+			existing: appliedManifest,
+			manifest: appliedManifest,
+		},
+		{
+			name:     "update",
+			existing: appliedManifest,
+			manifest: `
+checks:
+  - id: api
+    type: tcp
+    frequency: 1m
+    address: localhost:8080
+    labels:
+      team: platform
+  - id: web
+    type: http
+    frequency: 1m
+    url: https://example.com
+notificationChannels:
+  - name: ops
+    type: WEBHOOK
+    url: https://hooks.example.com/oncall
+statusPages:
+  - slug: acme
+    title: Acme Inc
+    components:
+      - name: API
+        check: web
+        group: Public
+      - name: Website
+        check: web
+`,
+			want: []string{
+				"~ notification channel ops (url)",
+				"~ check api (frequency, labels)",
+				"~ status page acme (title)",
+				"~ component acme/API (check, group)",
+			},
+		},
+		{
+			name:     "delete without prune",
+			existing: appliedManifest,
+			manifest: `
+checks:
+  - id: api
+    type: tcp
+    frequency: 30s
+    address: localhost:8080
+    labels:
+      team: core
+`,
+		},
+		{
+			name:     "delete with prune",
+			existing: appliedManifest,
+			manifest: `
+checks:
+  - id: api
+    type: tcp
+    frequency: 30s
+    address: localhost:8080
+    labels:
+      team: core
+statusPages:
+  - slug: acme
+    title: Acme
+    components:
+      - name: API
+        check: api
+        group: Backend
+`,
+			prune: true,
+			want: []string{
+				"- notification channel ops",
+				"- check web",
+				"- component acme/Website",
+			},
+		},
+		{
+			name:     "prune everything",
+			existing: appliedManifest,
+			manifest: `{}`,
+			prune:    true,
+			want: []string{
+				"- notification channel ops",
+				"- check api",
+				"- check web",
+				"- status page acme",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+			store := storage.NewMemoryStorage()
+			if tt.existing != "" {
+				mustApply(t, ctx, store, tt.existing)
+			}
+			m, err := Parse([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes, err := Plan(ctx, store, m, tt.prune)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changeStrings(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Plan() = %q, want %q", got, tt.want)
+			}
+			err = Apply(ctx, store, changes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes, err = Plan(ctx, store, m, tt.prune)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) > 0 {
+				t.Errorf("Plan() after Apply() = %q, want no changes", changeStrings(changes))
+			}
+		})
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	store := storage.NewMemoryStorage()
+	store.SaveWorkspace(db.Workspace{ID: db.DefaultWorkspaceID, Name: "Default", MaxStatusPages: 1})
+	err := store.StatusPages().Create(ctx, &db.StatusPage{Slug: "other", Title: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse([]byte(appliedManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := Plan(ctx, store, m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Apply(ctx, store, changes)
+	if err == nil {
+		t.Fatal("Apply() over the quota of status pages succeeded")
+	}
+	checks, err := listChecks(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels, err := store.Channels().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 0 || len(channels) != 0 {
+		t.Errorf("Apply() failed keeping %d checks and %d notification channels, want none", len(checks), len(channels))
+	}
+}
+
+// mustApply converges the storage to the manifest
+func mustApply(t *testing.T, ctx context.Context, store storage.Storage, manifest string) {
+	t.Helper()
+	m, err := Parse([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := Plan(ctx, store, m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Apply(ctx, store, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func changeStrings(changes []Change) []string {
+	var lines []string
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	return lines
+}
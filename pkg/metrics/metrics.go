@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Registry holds the metrics exported by the server
+var Registry = prometheus.NewRegistry()
+
+var (
+	RoundDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "statuspage",
+		Name:      "round_duration_seconds",
+		Help:      "Time taken to execute every check.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+	QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "statuspage",
+		Name:      "queue_depth",
+		Help:      "Checks waiting to be executed in the current round.",
+	})
+	ProbeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "statuspage",
+		Name:      "probe_errors_total",
+		Help:      "Probes that could not be executed or whose execution could not be stored.",
+	}, []string{"type"})
+	NotificationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "statuspage",
+		Name:      "notification_failures_total",
+		Help:      "Notifications that could not be delivered.",
+	}, []string{"channel"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		RoundDuration,
+		QueueDepth,
+		ProbeErrors,
+		NotificationFailures,
+	)
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type jsonReport struct {
+	Passed  int          `json:"passed"`
+	Failed  int          `json:"failed"`
+	Results []jsonResult `json:"results"`
+}
+
+// jsonResult expresses the latency in milliseconds like the API
+type jsonResult struct {
+	Result
+	Latency float64 `json:"latency"`
+}
+
+func WriteJSON(w io.Writer, results []Result) error {
+	report := jsonReport{Results: []jsonResult{}}
+	for _, result := range results {
+		report.Results = append(report.Results, jsonResult{
+			Result:  result,
+			Latency: float64(result.Latency) / float64(time.Millisecond),
+		})
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit test suite, every check being a test case
+func WriteJUnit(w io.Writer, results []Result) error {
+	suite := junitTestSuite{
+		Name:      "statuspage",
+		Tests:     len(results),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	var total time.Duration
+	for _, result := range results {
+		total += result.Latency
+		testCase := junitTestCase{
+			Name:      result.Name,
+			ClassName: fmt.Sprintf("statuspage.%s", result.Type),
+			Time:      formatSeconds(result.Latency),
+		}
+		if result.Passed {
+			testCase.SystemOut = result.Message
+		} else {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message:  result.Failures[0],
+				Contents: strings.Join(result.Failures, "\n"),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Time = formatSeconds(total)
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(junitTestSuites{TestSuites: []junitTestSuite{suite}})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
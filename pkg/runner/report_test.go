@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var reportResults = []Result{
+	{Name: "api", Type: "http", Target: "https://example.com", Passed: true, Message: "Status code: 200", Latency: 1500 * time.Millisecond},
+	{Name: "postgres", Type: "tcp", Target: "localhost:5432", Message: "connection refused", Failures: []string{"connection refused", "Latency 3s exceeds 1s"}, Latency: 3 * time.Second},
+}
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []Result
+		passed    int
+		failed    int
+		latencies []float64
+		failures  [][]string
+	}{
+		{"no results", nil, 0, 0, []float64{}, [][]string{}},
+		{"passed and failed", reportResults, 1, 1, []float64{1500, 3000}, [][]string{nil, {"connection refused", "Latency 3s exceeds 1s"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := WriteJSON(out, tt.results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var report struct {
+				Passed  int
+				Failed  int
+				Results []struct {
+					Name     string
+					Latency  float64
+					Failures []string
+				}
+			}
+			err = json.Unmarshal(out.Bytes(), &report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Passed != tt.passed || report.Failed != tt.failed {
+				t.Errorf("report = %d passed, %d failed, want %d passed, %d failed", report.Passed, report.Failed, tt.passed, tt.failed)
+			}
+			if report.Results == nil {
+				t.Fatal("results = null, want a list")
+			}
+			latencies := []float64{}
+			failures := [][]string{}
+			for _, result := range report.Results {
+				latencies = append(latencies, result.Latency)
+				failures = append(failures, result.Failures)
+			}
+			if !reflect.DeepEqual(latencies, tt.latencies) {
+				t.Errorf("latencies = %v, want %v in milliseconds", latencies, tt.latencies)
+			}
+			if !reflect.DeepEqual(failures, tt.failures) {
+				t.Errorf("failures = %q, want %q", failures, tt.failures)
+			}
+		})
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []Result
+		tests    int
+		failures int
+		time     string
+		cases    []junitTestCase
+	}{
+		{"no results", nil, 0, 0, "0.000", nil},
+		{"passed and failed", reportResults, 2, 1, "4.500", []junitTestCase{
+			{Name: "api", ClassName: "statuspage.http", Time: "1.500", SystemOut: "Status code: 200"},
+			{Name: "postgres", ClassName: "statuspage.tcp", Time: "3.000", Failure: &junitFailure{
+				Message:  "connection refused",
+				Contents: "connection refused\nLatency 3s exceeds 1s",
+			}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := WriteJUnit(out, tt.results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out.String(), xml.Header) {
+				t.Errorf("report doesn't start with the XML header:\n%s", out)
+			}
+			report := junitTestSuites{}
+			err = xml.Unmarshal(out.Bytes(), &report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.TestSuites) != 1 {
+				t.Fatalf("report has %d test suites, want 1", len(report.TestSuites))
+			}
+			suite := report.TestSuites[0]
+			if suite.Name != "statuspage" || suite.Tests != tt.tests || suite.Failures != tt.failures || suite.Time != tt.time {
+				t.Errorf("suite = %s %d tests, %d failures in %s, want statuspage %d tests, %d failures in %s", suite.Name, suite.Tests, suite.Failures, suite.Time, tt.tests, tt.failures, tt.time)
+			}
+			if _, err := time.Parse(time.RFC3339, suite.Timestamp); err != nil {
+				t.Errorf("timestamp = %s, want RFC 3339", suite.Timestamp)
+			}
+			if !reflect.DeepEqual(suite.TestCases, tt.cases) {
+				t.Errorf("test cases = %+v, want %+v", suite.TestCases, tt.cases)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/manifest"
+	"github.com/kfsoftware/statuspage/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"time"
+)
+
+// Result of the execution of a check along with its assertions
+type Result struct {
+	Name      string        `json:"name"`
+	Type      check.Type    `json:"type"`
+	Target    string        `json:"target"`
+	Passed    bool          `json:"passed"`
+	Message   string        `json:"message"`
+	Failures  []string      `json:"failures,omitempty"`
+	Latency   time.Duration `json:"-"`
+	StartedAt time.Time     `json:"startedAt"`
+}
+
+// Run executes the checks concurrently without storing their results,
+// the results are returned in the order of the checks
+func Run(checks []manifest.Check) []Result {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, chk := range checks {
+		go func(i int, chk manifest.Check) {
+			defer wg.Done()
+			results[i] = runCheck(chk)
+		}(i, chk)
+	}
+	wg.Wait()
+	return results
+}
+
+func runCheck(chk manifest.Check) Result {
+	result := Result{
+		Name:      chk.ID,
+		Type:      chk.Type,
+		Target:    chk.Url,
+		StartedAt: time.Now(),
+	}
+	if chk.Type != check.HttpType {
+		result.Target = chk.Address
+	}
+	probe, err := newProbe(chk)
+	if err != nil {
+		result.Message = err.Error()
+		result.Failures = []string{err.Error()}
+		return result
+	}
+	probeResult := tracing.Probe(context.Background(), probe, attribute.String("check.identifier", chk.ID))
+	result.Message = probeResult.Message
+	if probeResult.Statistics != nil {
+		result.Latency = probeResult.Statistics.GetTimeTaken()
+	}
+	if probeResult.Error != nil {
+		result.Failures = append(result.Failures, probeResult.Error.Error())
+	}
+	result.Failures = append(result.Failures, chk.Assertions.Verify(probeResult)...)
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// newProbe returns the probe used by the server, which expects the asserted status code too
+func newProbe(chk manifest.Check) (check.Check, error) {
+	model, err := chk.Model("")
+	if err != nil {
+		return nil, err
+	}
+	return model.HealthCheck()
+}
@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/manifest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestStatusCodeIsExpectedByTheServer runs the probe of the server, the manifest must
+// pass statuspage run only when the server considers the check up
+func TestStatusCodeIsExpectedByTheServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	tests := []struct {
+		name       string
+		statusCode int
+		passed     bool
+	}{
+		{"default", 0, false},
+		{"asserted", http.StatusAccepted, true},
+		{"other status code", http.StatusCreated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chk := manifest.Check{ID: "api", Type: check.HttpType, Url: server.URL, Assertions: check.Assertions{StatusCode: tt.statusCode}}
+			model, err := chk.Model("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			probe, err := model.HealthCheck()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if up := probe.Check(context.Background()).Error == nil; up != tt.passed {
+				t.Errorf("server probe up = %v, want %v", up, tt.passed)
+			}
+			if result := Run([]manifest.Check{chk})[0]; result.Passed != tt.passed {
+				t.Errorf("run passed = %v, want %v, failures: %v", result.Passed, tt.passed, result.Failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	uuid "github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/events"
+	"github.com/kfsoftware/statuspage/pkg/metrics"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/kfsoftware/statuspage/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"time"
+)
+
+// CheckAll probes every check that isn't paused, the round is restricted to the workspace of
+// the context when it holds one
+func CheckAll(ctx context.Context, store storage.Storage, bus *events.Bus) {
+	start := time.Now()
+	err := checkAll(ctx, store, bus)
+	end := time.Now()
+	took := end.Sub(start)
+	metrics.RoundDuration.Observe(took.Seconds())
+	if err != nil {
+		log.Warnf("Failed checking the endpoints: %v", err)
+	} else {
+		log.Infof("Check executed successfully in %s", took)
+	}
+	checkUrl := viper.GetString("check.url")
+	if checkUrl != "" {
+		_, err := http.Get(checkUrl)
+		if err != nil {
+			log.Errorf("Failed invoking url: %s", checkUrl)
+		}
+	}
+}
+
+func checkAll(ctx context.Context, store storage.Storage, bus *events.Bus) error {
+	checks, err := store.Checks().ListScheduled(ctx)
+	if err != nil {
+		return err
+	}
+	maintenanceWindows, err := store.MaintenanceWindows().Active(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	metrics.QueueDepth.Set(float64(len(checks)))
+	defer metrics.QueueDepth.Set(0)
+	// the checks are loaded at the start of the round, SaveResult only writes the outcome of the
+	// probes so that the changes made by the mutations meanwhile are kept
+	for _, chk := range checks {
+		metrics.QueueDepth.Dec()
+		chk := chk
+		previousStatus := chk.Status
+		maintenanceWindow, underMaintenance := maintenanceWindows[chk.ID]
+		if underMaintenance && maintenanceWindow.Mode == db.PauseMode {
+			chk.Status = db.Maintenance
+			chk.Message = maintenanceWindow.Title
+			err := store.Checks().SaveResult(ctx, &chk)
+			if err != nil {
+				log.Errorf("Failed to save check id=%s type=%s err=%v", chk.ID, chk.Type, err)
+			}
+			db.PublishStatusChange(bus, chk, previousStatus)
+			continue
+		}
+		chk.Status = db.Checking
+		store.Checks().SaveResult(ctx, &chk)
+		healthChk, err := chk.HealthCheck()
+		if err != nil {
+			metrics.ProbeErrors.WithLabelValues(string(chk.Type)).Inc()
+			log.Errorf("Failed to get the probe of check id=%s type=%s err=%v", chk.ID, chk.Type, err)
+			continue
+		}
+		if healthChk == nil {
+			log.Warnf("No healthcheck found for id=%s type=%s", chk.ID, string(chk.Type))
+			continue
+		}
+		result := tracing.Probe(
+			ctx,
+			healthChk,
+			attribute.String("check.id", chk.ID),
+			attribute.String("check.identifier", chk.Identifier),
+		)
+		var status db.Status
+		if result.Error != nil {
+			status = db.Down
+		} else {
+			status = db.Up
+		}
+		statsBytes, err := json.Marshal(result.Statistics)
+		if err != nil {
+			metrics.ProbeErrors.WithLabelValues(string(chk.Type)).Inc()
+			log.Errorf("Health check failed id=%s type=%s err=%v", chk.ID, chk.Type, err)
+			continue
+		}
+		var latency time.Duration
+		if result.Statistics != nil {
+			latency = result.Statistics.GetTimeTaken()
+		}
+		chkExecution := db.CheckExecution{
+			ID:          uuid.New().String(),
+			Status:      status,
+			Message:     result.Message,
+			Latency:     latency,
+			Maintenance: underMaintenance,
+			Stats:       statsBytes,
+			CheckID:     chk.ID,
+		}
+		if result.Error != nil {
+			chkExecution.ErrorMsg = result.Error.Error()
+		}
+		err = store.Executions().Create(ctx, &chkExecution)
+		if err != nil {
+			metrics.ProbeErrors.WithLabelValues(string(chk.Type)).Inc()
+			log.Errorf("Health check failed id=%s type=%s err=%v", chk.ID, chk.Type, err)
+		} else {
+			bus.Publish(events.Event{
+				Type:    events.ExecutionRecorded,
+				ID:      chk.ID,
+				Payload: chkExecution,
+			})
+		}
+		chk.Status = status
+		if result.Error != nil {
+			chk.ErrorMsg = result.Error.Error()
+		}
+		chk.Message = result.Message
+		chk.LatestCheck = time.Now()
+		chk.Latency = latency
+		if tlsStatistics, ok := result.Statistics.(check.TlsStatistics); ok {
+			chk.CertExpiresAt = tlsStatistics.CertificateExpiry()
+		}
+		err = store.Checks().SaveResult(ctx, &chk)
+		if err != nil {
+			log.Errorf("Failed to save check id=%s type=%s err=%v", chk.ID, chk.Type, err)
+		}
+		db.PublishStatusChange(bus, chk, previousStatus)
+		if chk.Status == db.Down && !underMaintenance {
+			go notifyEndpointDown(ctx, store, chk)
+		}
+	}
+	return nil
+}
+
+func notifyEndpointDown(ctx context.Context, store storage.Storage, chk db.Check) {
+	channels, err := store.Channels().ForCheck(ctx, chk)
+	if err != nil {
+		log.Warnf("Error getting the notification channels of check %s:%v", chk.ID, err)
+	}
+	db.NotifyEndpointDown(channels, chk)
+}
+
+// Compact rolls up and prunes the executions according to the retention policy of the configuration
+func Compact(ctx context.Context, store storage.Storage) {
+	start := time.Now()
+	err := store.Executions().Compact(ctx, db.GetRetentionPolicy(), start)
+	if err != nil {
+		log.Warnf("Failed compacting the executions: %v", err)
+	} else {
+		log.Infof("Compaction executed successfully in %s", time.Since(start))
+	}
+}
+
+// PurgeDeletedChecks purges the checks deleted for longer than the `retention.deletedChecks`
+// period, deleted checks are kept until they are purged by hand when it is not set
+func PurgeDeletedChecks(ctx context.Context, store storage.Storage) {
+	period := viper.GetDuration("retention.deletedChecks")
+	if period <= 0 {
+		return
+	}
+	checks, err := store.Checks().ListDeleted(ctx, nil)
+	if err != nil {
+		log.Warnf("Failed finding the deleted checks to purge: %v", err)
+		return
+	}
+	cutoff := time.Now().Add(-period)
+	for _, chk := range checks {
+		chk := chk
+		if !chk.DeletedAt.Time.Before(cutoff) {
+			continue
+		}
+		err := store.Checks().Purge(ctx, &chk)
+		if err != nil {
+			log.Warnf("Failed purging check id=%s: %v", chk.ID, err)
+			continue
+		}
+		log.Infof("Purged check %s deleted at %s", chk.OriginalIdentifier(), chk.DeletedAt.Time)
+	}
+}
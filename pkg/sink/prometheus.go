@@ -0,0 +1,313 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/snappy"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the series written for every execution, they are named apart from the gauges of the exporter
+// so that both can be ingested by the same Prometheus
+const (
+	latencyMetric = "statuspage_execution_latency_seconds"
+	// upMetric and downMetric are 1 when the execution has the status, 0 otherwise, they are
+	// labeled with whether the check was under maintenance
+	upMetric   = "statuspage_execution_up"
+	downMetric = "statuspage_execution_down"
+)
+
+// bucketQuery computes a field of the buckets, the query is formatted with the selector of the
+// series and the range of the bucket
+type bucketQuery struct {
+	metric string
+	query  string
+	set    func(b *db.MetricsBucket, value float64)
+}
+
+// bucketQueries compute the same fields as the queries of the database, the percentiles are
+// interpolated by Prometheus instead of using the nearest rank
+var bucketQueries = []bucketQuery{
+	{latencyMetric, "count_over_time(%s[%s])", func(b *db.MetricsBucket, value float64) { b.Count = int64(value) }},
+	{latencyMetric, "min_over_time(%s[%s])", func(b *db.MetricsBucket, value float64) { b.MinLatency = seconds(value) }},
+	{latencyMetric, "avg_over_time(%s[%s])", func(b *db.MetricsBucket, value float64) { b.AvgLatency = seconds(value) }},
+	{latencyMetric, "quantile_over_time(0.5, %s[%s])", func(b *db.MetricsBucket, value float64) { b.P50 = seconds(value) }},
+	{latencyMetric, "quantile_over_time(0.95, %s[%s])", func(b *db.MetricsBucket, value float64) { b.P95 = seconds(value) }},
+	{latencyMetric, "quantile_over_time(0.99, %s[%s])", func(b *db.MetricsBucket, value float64) { b.P99 = seconds(value) }},
+	{latencyMetric, "max_over_time(%s[%s])", func(b *db.MetricsBucket, value float64) { b.MaxLatency = seconds(value) }},
+	{upMetric, "sum_over_time(%s[%s])", func(b *db.MetricsBucket, value float64) { b.Up = int64(value) }},
+	{downMetric, "sum_over_time(%s[%s])", func(b *db.MetricsBucket, value float64) { b.Down = int64(value) }},
+}
+
+// Prometheus sends the executions to a remote write endpoint and queries them with the HTTP API
+// of Prometheus, or of any backend compatible with both such as Thanos, Cortex or Mimir. The
+// executions can't be listed, they are listed from the database when kept there
+type Prometheus struct {
+	// RemoteWriteUrl receives the samples, e.g. http://prometheus:9090/api/v1/write
+	RemoteWriteUrl string
+	// QueryUrl is the base url of the query API, e.g. http://prometheus:9090
+	QueryUrl   string
+	HTTPClient *http.Client
+}
+
+var _ storage.ExecutionSink = &Prometheus{}
+
+func NewPrometheus(remoteWriteUrl string, queryUrl string) *Prometheus {
+	return &Prometheus{
+		RemoteWriteUrl: remoteWriteUrl,
+		QueryUrl:       strings.TrimSuffix(queryUrl, "/"),
+		HTTPClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Write sends the samples of the execution in a remote write request, the protobuf is encoded
+// by hand to avoid depending on Prometheus for a single message
+func (p *Prometheus) Write(ctx context.Context, execution db.CheckExecution) error {
+	timestamp := execution.CreatedAt.UnixNano() / int64(time.Millisecond)
+	maintenance := strconv.FormatBool(execution.Maintenance)
+	var request []byte
+	request = appendTimeSeries(request, []string{"__name__", latencyMetric, "check_id", execution.CheckID}, execution.Latency.Seconds(), timestamp)
+	request = appendTimeSeries(request, []string{"__name__", upMetric, "check_id", execution.CheckID, "maintenance", maintenance}, boolValue(execution.Status == db.Up), timestamp)
+	request = appendTimeSeries(request, []string{"__name__", downMetric, "check_id", execution.CheckID, "maintenance", maintenance}, boolValue(execution.Status == db.Down), timestamp)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.RemoteWriteUrl, bytes.NewReader(snappy.Encode(nil, request)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("Unexpected status %s from %s", resp.Status, p.RemoteWriteUrl)
+	}
+	return nil
+}
+
+// Metrics evaluates the queries once per bucket, right before its end so that the range of the
+// evaluation matches the bucket. Buckets aren't trimmed to from and until, they always span their
+// whole interval
+func (p *Prometheus) Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error) {
+	if bucket < time.Second {
+		return nil, errors.Errorf("Bucket must be at least one second, got %s", bucket)
+	}
+	if !from.Before(until) {
+		return nil, nil
+	}
+	start := db.BucketStart(from, bucket).Add(bucket - time.Millisecond)
+	end := db.BucketStart(until.Add(-time.Millisecond), bucket).Add(bucket - time.Millisecond)
+	buckets := map[int64]*db.MetricsBucket{}
+	for _, q := range bucketQueries {
+		query := fmt.Sprintf(q.query, selector(q.metric, checkID), promDuration(bucket))
+		result, err := p.query(ctx, "/api/v1/query_range", url.Values{
+			"query": {query},
+			"start": {promTime(start)},
+			"end":   {promTime(end)},
+			"step":  {promDuration(bucket)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, series := range result {
+			for _, point := range series.Values {
+				t, value, err := parsePoint(point)
+				if err != nil {
+					return nil, err
+				}
+				bucketTime := t.Add(time.Millisecond - bucket)
+				b, ok := buckets[bucketTime.Unix()]
+				if !ok {
+					b = &db.MetricsBucket{Time: bucketTime}
+					buckets[bucketTime.Unix()] = b
+				}
+				q.set(b, value)
+			}
+		}
+	}
+	var metrics []db.MetricsBucket
+	for _, b := range buckets {
+		if b.Count == 0 {
+			continue
+		}
+		metrics = append(metrics, *b)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Time.Before(metrics[j].Time)
+	})
+	return metrics, nil
+}
+
+// Uptime sums the up and down samples between from and until, evaluated at a single instant
+func (p *Prometheus) Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (int64, int64, error) {
+	if !from.Before(until) {
+		return 0, 0, nil
+	}
+	counts := map[string]int64{}
+	for _, metric := range []string{upMetric, downMetric} {
+		query := fmt.Sprintf("sum_over_time(%s[%s])", selector(metric, checkID), promDuration(until.Sub(from)))
+		result, err := p.query(ctx, "/api/v1/query", url.Values{
+			"query": {query},
+			"time":  {promTime(until.Add(-time.Millisecond))},
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, series := range result {
+			_, value, err := parsePoint(series.Value)
+			if err != nil {
+				return 0, 0, err
+			}
+			counts[metric] += int64(value)
+		}
+	}
+	return counts[upMetric], counts[downMetric], nil
+}
+
+// Delete removes the series of the check with the admin API of Prometheus, which must be enabled
+// with --web.enable-admin-api. The samples are dropped from disk by the next compaction
+func (p *Prometheus) Delete(ctx context.Context, checkID string) error {
+	params := url.Values{}
+	for _, metric := range []string{latencyMetric, upMetric, downMetric} {
+		params.Add("match[]", fmt.Sprintf("%s{check_id=%s}", metric, strconv.Quote(checkID)))
+	}
+	endpoint := p.QueryUrl + "/api/v1/admin/tsdb/delete_series"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("Unexpected status %s from %s", resp.Status, endpoint)
+	}
+	return nil
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []querySeries `json:"result"`
+	} `json:"data"`
+}
+
+// querySeries is a series of a matrix, with Values, or of a vector, with Value
+type querySeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+	Values [][]interface{}   `json:"values"`
+}
+
+func (p *Prometheus) query(ctx context.Context, path string, params url.Values) ([]querySeries, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.QueryUrl+path, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	r := queryResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid response from %s, status %s", p.QueryUrl+path, resp.Status)
+	}
+	if r.Status != "success" {
+		return nil, errors.Errorf("Query %s failed: %s", params.Get("query"), r.Error)
+	}
+	return r.Data.Result, nil
+}
+
+// parsePoint decodes the [timestamp, "value"] pairs of the query API
+func parsePoint(point []interface{}) (time.Time, float64, error) {
+	if len(point) != 2 {
+		return time.Time{}, 0, errors.Errorf("Invalid point %v", point)
+	}
+	timestamp, ok := point[0].(float64)
+	if !ok {
+		return time.Time{}, 0, errors.Errorf("Invalid timestamp %v", point[0])
+	}
+	value, ok := point[1].(string)
+	if !ok {
+		return time.Time{}, 0, errors.Errorf("Invalid value %v", point[1])
+	}
+	parsedValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	t := time.Unix(0, int64(math.Round(timestamp*1000))*int64(time.Millisecond))
+	return t, parsedValue, nil
+}
+
+// selector matches the series of the check, the up and down series exclude the maintenance
+// executions like db.MetricsBucket
+func selector(metric string, checkID string) string {
+	if metric == latencyMetric {
+		return fmt.Sprintf("%s{check_id=%s}", metric, strconv.Quote(checkID))
+	}
+	return fmt.Sprintf(`%s{check_id=%s,maintenance="false"}`, metric, strconv.Quote(checkID))
+}
+
+func promDuration(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+func promTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano()/int64(time.Millisecond))/1000, 'f', 3, 64)
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// appendTimeSeries appends a prometheus.TimeSeries with a single sample to a WriteRequest, the
+// labels are name and value pairs sorted by name
+func appendTimeSeries(request []byte, labels []string, value float64, timestamp int64) []byte {
+	var series []byte
+	for i := 0; i < len(labels); i += 2 {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, labels[i])
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, labels[i+1])
+		series = protowire.AppendTag(series, 1, protowire.BytesType)
+		series = protowire.AppendBytes(series, label)
+	}
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp))
+	series = protowire.AppendTag(series, 2, protowire.BytesType)
+	series = protowire.AppendBytes(series, sample)
+	request = protowire.AppendTag(request, 1, protowire.BytesType)
+	return protowire.AppendBytes(request, series)
+}
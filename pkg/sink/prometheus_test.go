@@ -0,0 +1,398 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/snappy"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"google.golang.org/protobuf/encoding/protowire"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sample struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// receiver is a local remote write endpoint, it answers the queries of the sink with the
+// samples it received, only the functions used by the sink are supported
+type receiver struct {
+	mu      sync.Mutex
+	samples []sample
+}
+
+var queryExpr = regexp.MustCompile(`^(\w+)\((?:([0-9.]+), )?(\w+)\{(.*)\}\[(\d+)ms\]\)$`)
+
+func (r *receiver) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/write", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Content-Encoding") != "snappy" || req.Header.Get("X-Prometheus-Remote-Write-Version") == "" {
+			t.Errorf("remote write headers = %v", req.Header)
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request, err := snappy.Decode(nil, body)
+		if err != nil {
+			t.Errorf("snappy.Decode() = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		samples, err := decodeWriteRequest(request)
+		if err != nil {
+			t.Errorf("invalid write request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.mu.Lock()
+		r.samples = append(r.samples, samples...)
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v1/query_range", func(w http.ResponseWriter, req *http.Request) {
+		start, _ := strconv.ParseFloat(req.FormValue("start"), 64)
+		end, _ := strconv.ParseFloat(req.FormValue("end"), 64)
+		step, _ := time.ParseDuration(req.FormValue("step"))
+		var values [][]interface{}
+		for ts := int64(math.Round(start * 1000)); ts <= int64(math.Round(end*1000)); ts += step.Milliseconds() {
+			value, ok := r.eval(t, req.FormValue("query"), ts)
+			if ok {
+				values = append(values, []interface{}{float64(ts) / 1000, strconv.FormatFloat(value, 'f', -1, 64)})
+			}
+		}
+		var result []interface{}
+		if len(values) > 0 {
+			result = append(result, map[string]interface{}{"metric": map[string]string{}, "values": values})
+		}
+		writeResult(w, "matrix", result)
+	})
+	mux.HandleFunc("/api/v1/admin/tsdb/delete_series", func(w http.ResponseWriter, req *http.Request) {
+		err := req.ParseForm()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var deleted []map[string]string
+		for _, match := range req.PostForm["match[]"] {
+			deleted = append(deleted, parseSelector(t, match))
+		}
+		r.mu.Lock()
+		var samples []sample
+		for _, s := range r.samples {
+			keep := true
+			for _, matchers := range deleted {
+				if s.matches(matchers) {
+					keep = false
+				}
+			}
+			if keep {
+				samples = append(samples, s)
+			}
+		}
+		r.samples = samples
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, req *http.Request) {
+		at, _ := strconv.ParseFloat(req.FormValue("time"), 64)
+		ts := int64(math.Round(at * 1000))
+		var result []interface{}
+		value, ok := r.eval(t, req.FormValue("query"), ts)
+		if ok {
+			result = append(result, map[string]interface{}{
+				"metric": map[string]string{},
+				"value":  []interface{}{float64(ts) / 1000, strconv.FormatFloat(value, 'f', -1, 64)},
+			})
+		}
+		writeResult(w, "vector", result)
+	})
+	return mux
+}
+
+func writeResult(w http.ResponseWriter, resultType string, result []interface{}) {
+	if result == nil {
+		result = []interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": resultType, "result": result},
+	})
+}
+
+// eval evaluates a range function over the samples of the range (ts - range, ts]
+func (r *receiver) eval(t *testing.T, query string, ts int64) (float64, bool) {
+	match := queryExpr.FindStringSubmatch(query)
+	if match == nil {
+		t.Fatalf("unsupported query %s", query)
+	}
+	matchers := parseSelector(t, fmt.Sprintf("%s{%s}", match[3], match[4]))
+	window, _ := strconv.ParseInt(match[5], 10, 64)
+	r.mu.Lock()
+	var values []float64
+	for _, s := range r.samples {
+		if s.timestamp <= ts-window || s.timestamp > ts {
+			continue
+		}
+		if s.matches(matchers) {
+			values = append(values, s.value)
+		}
+	}
+	r.mu.Unlock()
+	if len(values) == 0 {
+		return 0, false
+	}
+	sort.Float64s(values)
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	switch match[1] {
+	case "count_over_time":
+		return float64(len(values)), true
+	case "sum_over_time":
+		return sum, true
+	case "avg_over_time":
+		return sum / float64(len(values)), true
+	case "min_over_time":
+		return values[0], true
+	case "max_over_time":
+		return values[len(values)-1], true
+	case "quantile_over_time":
+		rank, _ := strconv.ParseFloat(match[2], 64)
+		return values[int(math.Ceil(rank*float64(len(values))))-1], true
+	}
+	t.Fatalf("unsupported function %s", match[1])
+	return 0, false
+}
+
+var selectorExpr = regexp.MustCompile(`^(\w+)\{(.*)\}$`)
+
+// parseSelector returns the labels matched by a selector with equality matchers only
+func parseSelector(t *testing.T, selector string) map[string]string {
+	match := selectorExpr.FindStringSubmatch(selector)
+	if match == nil {
+		t.Fatalf("unsupported selector %s", selector)
+	}
+	matchers := map[string]string{"__name__": match[1]}
+	for _, matcher := range strings.Split(match[2], ",") {
+		parts := strings.SplitN(matcher, "=", 2)
+		value, err := strconv.Unquote(parts[1])
+		if err != nil {
+			t.Fatalf("invalid matcher %s", matcher)
+		}
+		matchers[parts[0]] = value
+	}
+	return matchers
+}
+
+func (s sample) matches(matchers map[string]string) bool {
+	for name, value := range matchers {
+		if s.labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeWriteRequest decodes the fields of prometheus.WriteRequest written by the sink
+func decodeWriteRequest(b []byte) ([]sample, error) {
+	var samples []sample
+	err := consumeMessages(b, func(field protowire.Number, series []byte) error {
+		labels := map[string]string{}
+		var seriesSamples []sample
+		err := consumeMessages(series, func(field protowire.Number, value []byte) error {
+			switch field {
+			case 1:
+				var name string
+				return consumeMessages(value, func(field protowire.Number, value []byte) error {
+					if field == 1 {
+						name = string(value)
+					} else {
+						labels[name] = string(value)
+					}
+					return nil
+				})
+			case 2:
+				s := sample{}
+				for len(value) > 0 {
+					num, typ, n := protowire.ConsumeTag(value)
+					value = value[n:]
+					switch {
+					case num == 1 && typ == protowire.Fixed64Type:
+						v, n := protowire.ConsumeFixed64(value)
+						s.value = math.Float64frombits(v)
+						value = value[n:]
+					case num == 2 && typ == protowire.VarintType:
+						v, n := protowire.ConsumeVarint(value)
+						s.timestamp = int64(v)
+						value = value[n:]
+					default:
+						return protowire.ParseError(-1)
+					}
+				}
+				seriesSamples = append(seriesSamples, s)
+			}
+			return nil
+		})
+		for _, s := range seriesSamples {
+			s.labels = labels
+			samples = append(samples, s)
+		}
+		return err
+	})
+	return samples, err
+}
+
+// consumeMessages calls fn with the length delimited fields of the message
+func consumeMessages(b []byte, fn func(field protowire.Number, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || typ != protowire.BytesType {
+			return protowire.ParseError(-1)
+		}
+		b = b[n:]
+		value, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		err := fn(num, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPrometheusSink(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r.handler(t))
+	defer server.Close()
+	sink := NewPrometheus(server.URL+"/api/v1/write", server.URL)
+	memory := storage.NewMemoryStorage()
+	store := storage.WithExecutionSink(memory, sink, false)
+	ctx := context.Background()
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	executions := []db.CheckExecution{
+		{CheckID: "api", Status: db.Up, Latency: 100 * time.Millisecond, CreatedAt: day},
+		{CheckID: "api", Status: db.Up, Latency: 300 * time.Millisecond, CreatedAt: day.Add(time.Hour)},
+		{CheckID: "api", Status: db.Down, Latency: 200 * time.Millisecond, CreatedAt: day.Add(2 * time.Hour)},
+		// maintenance executions don't count towards the uptime
+		{CheckID: "api", Status: db.Down, Latency: time.Second, Maintenance: true, CreatedAt: day.Add(3 * time.Hour)},
+		{CheckID: "api", Status: db.Up, Latency: 400 * time.Millisecond, CreatedAt: day.Add(24 * time.Hour)},
+		{CheckID: "web", Status: db.Down, Latency: 50 * time.Millisecond, CreatedAt: day.Add(time.Hour)},
+	}
+	for i := range executions {
+		err := store.Executions().Create(ctx, &executions[i])
+		if err != nil {
+			t.Fatalf("Create() = %v", err)
+		}
+	}
+	if len(r.samples) != 3*len(executions) {
+		t.Fatalf("receiver got %d samples, want %d", len(r.samples), 3*len(executions))
+	}
+	page, err := memory.Executions().List(ctx, db.ExecutionFilter{CheckID: "api"}, db.Order{}, db.Pagination{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 0 {
+		t.Errorf("%d executions in the database, want them only in the sink", page.TotalCount)
+	}
+
+	buckets, err := store.Executions().Metrics(ctx, "api", day, day.Add(48*time.Hour), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Metrics() = %v", err)
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("Metrics() = %+v, want 2 daily buckets", buckets)
+	}
+	first := buckets[0]
+	if !first.Time.Equal(day) || first.Count != 4 || first.Up != 2 || first.Down != 1 {
+		t.Errorf("first bucket = %+v, want 4 executions, 2 up and 1 down", first)
+	}
+	if first.MinLatency != 100*time.Millisecond || first.MaxLatency != time.Second || first.AvgLatency != 400*time.Millisecond {
+		t.Errorf("first bucket latencies = %+v", first)
+	}
+	if !buckets[1].Time.Equal(day.Add(24*time.Hour)) || buckets[1].Count != 1 || buckets[1].Up != 1 {
+		t.Errorf("second bucket = %+v, want a single up execution", buckets[1])
+	}
+
+	up, down, err := store.Executions().Uptime(ctx, "api", day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Uptime() = %v", err)
+	}
+	if up != 2 || down != 1 {
+		t.Errorf("Uptime() = %d, %d, want 2, 1", up, down)
+	}
+}
+
+func TestPrometheusSinkWriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	sink := NewPrometheus(server.URL+"/api/v1/write", server.URL)
+	err := sink.Write(context.Background(), db.CheckExecution{CheckID: "api", Status: db.Up, CreatedAt: time.Now()})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Write() = %v, want the status of the receiver", err)
+	}
+}
+
+func TestPrometheusSinkPurge(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r.handler(t))
+	defer server.Close()
+	store := storage.WithExecutionSink(storage.NewMemoryStorage(), NewPrometheus(server.URL+"/api/v1/write", server.URL), false)
+	ctx := context.Background()
+	var checks []*db.Check
+	for _, identifier := range []string{"api", "web"} {
+		chk := &db.Check{Identifier: identifier, Type: "http", Frequency: "@every 1m"}
+		err := store.Checks().Create(ctx, chk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = store.Executions().Create(ctx, &db.CheckExecution{CheckID: chk.ID, Status: db.Up})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checks = append(checks, chk)
+	}
+	api := checks[0]
+	if err := store.Checks().Purge(ctx, api); err == nil {
+		t.Error("Purge() of a check that isn't deleted succeeded")
+	}
+	if len(r.samples) != 6 {
+		t.Fatalf("receiver holds %d samples after a failed purge, want 6", len(r.samples))
+	}
+	err := store.Checks().Delete(ctx, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Checks().Purge(ctx, api)
+	if err != nil {
+		t.Fatalf("Purge() = %v", err)
+	}
+	if len(r.samples) != 3 {
+		t.Fatalf("receiver holds %d samples, want the 3 of web", len(r.samples))
+	}
+	for _, s := range r.samples {
+		if s.labels["check_id"] != checks[1].ID {
+			t.Errorf("sample %+v of a purged check", s)
+		}
+	}
+}
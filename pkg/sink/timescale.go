@@ -0,0 +1,151 @@
+package sink
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// timescaleSchema creates the hypertable of the executions, the primary key includes the time
+// column as required by TimescaleDB and the checks live in another database so there is no
+// foreign key
+var timescaleSchema = []string{
+	`CREATE TABLE IF NOT EXISTS "check_execution" (
+    "id" text NOT NULL,
+    "status" text,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz,
+    "error_msg" text,
+    "message" text,
+    "latency" bigint,
+    "maintenance" boolean NOT NULL DEFAULT false,
+    "stats" JSONB,
+    "check_id" text NOT NULL,
+    PRIMARY KEY ("id", "created_at")
+)`,
+	`SELECT create_hypertable('check_execution', 'created_at', if_not_exists => TRUE)`,
+	`CREATE INDEX IF NOT EXISTS "idx_check_execution_check_id_created_at" ON "check_execution" ("check_id", "created_at" DESC)`,
+}
+
+// timescaleMetricsQuery aligns the buckets to the epoch like the queries of the database so
+// that the daily buckets of the status pages start at midnight UTC
+const timescaleMetricsQuery = `
+SELECT EXTRACT(EPOCH FROM time_bucket(@bucket * INTERVAL '1 second', created_at, TIMESTAMPTZ '1970-01-01 00:00:00+00')) AS bucket,
+       COUNT(*) AS count,
+       MIN(latency) AS min_latency,
+       AVG(latency) AS avg_latency,
+       PERCENTILE_DISC(0.5) WITHIN GROUP (ORDER BY latency) AS p50,
+       PERCENTILE_DISC(0.95) WITHIN GROUP (ORDER BY latency) AS p95,
+       PERCENTILE_DISC(0.99) WITHIN GROUP (ORDER BY latency) AS p99,
+       MAX(latency) AS max_latency,
+       SUM(CASE WHEN status = @up AND maintenance = @maintenance THEN 1 ELSE 0 END) AS up,
+       SUM(CASE WHEN status = @down AND maintenance = @maintenance THEN 1 ELSE 0 END) AS down
+FROM check_execution
+WHERE check_id = @check AND created_at >= @from AND created_at < @until
+GROUP BY 1
+ORDER BY 1`
+
+type timescaleMetricsRow struct {
+	Bucket     float64
+	Count      int64
+	MinLatency float64
+	AvgLatency float64
+	P50        float64
+	P95        float64
+	P99        float64
+	MaxLatency float64
+	Up         int64
+	Down       int64
+}
+
+// Timescale stores the executions in a TimescaleDB hypertable, the whole history is kept raw
+// since TimescaleDB compresses and drops the chunks according to its own policies
+type Timescale struct {
+	db *gorm.DB
+}
+
+var _ storage.ExecutionSink = &Timescale{}
+var _ storage.ExecutionLister = &Timescale{}
+
+// NewTimescale creates the hypertable of the executions in the database unless it exists, the
+// timescaledb extension must be installed
+func NewTimescale(dbClient *gorm.DB) (*Timescale, error) {
+	if dbClient.Dialector.Name() != "postgres" {
+		return nil, errors.Errorf("TimescaleDB requires a postgres connection, got %s", dbClient.Dialector.Name())
+	}
+	for _, statement := range timescaleSchema {
+		err := dbClient.Exec(statement).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed creating the executions hypertable")
+		}
+	}
+	return &Timescale{db: dbClient}, nil
+}
+
+func (t *Timescale) Write(ctx context.Context, execution db.CheckExecution) error {
+	return t.db.WithContext(ctx).Create(&execution).Error
+}
+
+func (t *Timescale) Delete(ctx context.Context, checkID string) error {
+	return t.db.WithContext(ctx).Where("check_id = ?", checkID).Delete(&db.CheckExecution{}).Error
+}
+
+func (t *Timescale) List(ctx context.Context, filter db.ExecutionFilter, order db.Order, p db.Pagination) (*db.ExecutionPage, error) {
+	return db.ListExecutions(t.db.WithContext(ctx), filter, order, p)
+}
+
+func (t *Timescale) Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error) {
+	if bucket < time.Second {
+		return nil, errors.Errorf("Bucket must be at least one second, got %s", bucket)
+	}
+	var rows []timescaleMetricsRow
+	result := t.db.WithContext(ctx).Raw(timescaleMetricsQuery, map[string]interface{}{
+		"bucket":      int64(bucket / time.Second),
+		"check":       checkID,
+		"from":        from,
+		"until":       until,
+		"up":          db.Up,
+		"down":        db.Down,
+		"maintenance": false,
+	}).Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var buckets []db.MetricsBucket
+	for _, row := range rows {
+		buckets = append(buckets, db.MetricsBucket{
+			Time:       time.Unix(int64(row.Bucket), 0),
+			Count:      row.Count,
+			MinLatency: time.Duration(row.MinLatency),
+			AvgLatency: time.Duration(row.AvgLatency),
+			P50:        time.Duration(row.P50),
+			P95:        time.Duration(row.P95),
+			P99:        time.Duration(row.P99),
+			MaxLatency: time.Duration(row.MaxLatency),
+			Up:         row.Up,
+			Down:       row.Down,
+		})
+	}
+	return buckets, nil
+}
+
+func (t *Timescale) Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (int64, int64, error) {
+	bucket := until.Sub(from)
+	if bucket < time.Second {
+		bucket = time.Second
+	}
+	// a single bucket may still be split in two when from isn't aligned to its size
+	buckets, err := t.Metrics(ctx, checkID, from, until, bucket)
+	if err != nil {
+		return 0, 0, err
+	}
+	var up, down int64
+	for _, b := range buckets {
+		up += b.Up
+		down += b.Down
+	}
+	return up, down, nil
+}
@@ -0,0 +1,299 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statement is a statement executed by the sink against the mocked Postgres
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+// response is the result of the queries matching the pattern
+type response struct {
+	pattern string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// mockPostgres records the statements and answers the queries with the first matching response,
+// the queries without a response return no rows
+type mockPostgres struct {
+	mu         sync.Mutex
+	statements []statement
+	responses  []response
+}
+
+var (
+	mocksMu sync.Mutex
+	mocks   = map[string]*mockPostgres{}
+)
+
+func init() {
+	sql.Register("mockpostgres", mockDriver{})
+}
+
+// openMockPostgres returns a connection with the postgres dialect whose statements are recorded
+// by the mock
+func openMockPostgres(t *testing.T, responses ...response) (*gorm.DB, *mockPostgres) {
+	mock := &mockPostgres{responses: responses}
+	mocksMu.Lock()
+	mocks[t.Name()] = mock
+	mocksMu.Unlock()
+	dbClient, err := gorm.Open(postgres.New(postgres.Config{DriverName: "mockpostgres", DSN: t.Name()}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dbClient, mock
+}
+
+func (m *mockPostgres) record(query string, args []driver.Value) response {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statements = append(m.statements, statement{query: query, args: args})
+	for _, r := range m.responses {
+		if regexp.MustCompile(r.pattern).MatchString(query) {
+			return r
+		}
+	}
+	return response{}
+}
+
+type mockDriver struct{}
+
+func (mockDriver) Open(name string) (driver.Conn, error) {
+	mocksMu.Lock()
+	defer mocksMu.Unlock()
+	mock, ok := mocks[name]
+	if !ok {
+		return nil, fmt.Errorf("no mock named %s", name)
+	}
+	return mockConn{mock}, nil
+}
+
+type mockConn struct{ mock *mockPostgres }
+
+func (c mockConn) Prepare(query string) (driver.Stmt, error) {
+	return mockStmt{mock: c.mock, query: query}, nil
+}
+func (c mockConn) Close() error              { return nil }
+func (c mockConn) Begin() (driver.Tx, error) { return mockTx{}, nil }
+
+type mockTx struct{}
+
+func (mockTx) Commit() error   { return nil }
+func (mockTx) Rollback() error { return nil }
+
+type mockStmt struct {
+	mock  *mockPostgres
+	query string
+}
+
+func (s mockStmt) Close() error  { return nil }
+func (s mockStmt) NumInput() int { return -1 }
+
+func (s mockStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.mock.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s mockStmt) Query(args []driver.Value) (driver.Rows, error) {
+	r := s.mock.record(s.query, args)
+	return &mockRows{columns: r.columns, rows: r.rows}, nil
+}
+
+type mockRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *mockRows) Columns() []string { return r.columns }
+func (r *mockRows) Close() error      { return nil }
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var metricsColumns = []string{"bucket", "count", "min_latency", "avg_latency", "p50", "p95", "p99", "max_latency", "up", "down"}
+
+// whitespace collapses the indentation of the queries so that they can be matched on one line
+var whitespace = regexp.MustCompile(`\s+`)
+
+func TestTimescaleSink(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	ms := int64(time.Millisecond)
+	tests := []struct {
+		name      string
+		responses []response
+		call      func(t *testing.T, sink *Timescale)
+		// statements are the patterns of the statements executed, in order, with their arguments
+		statements []string
+		args       [][]driver.Value
+	}{
+		{
+			name: "write",
+			call: func(t *testing.T, sink *Timescale) {
+				err := sink.Write(context.Background(), db.CheckExecution{ID: "e1", CheckID: "api", Status: db.Down, Latency: time.Second, Maintenance: true, CreatedAt: day})
+				if err != nil {
+					t.Fatalf("Write() = %v", err)
+				}
+			},
+			statements: []string{`^INSERT INTO "check_execution" \("id","status","created_at","updated_at","error_msg","message","latency","maintenance","stats","check_id"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10\)`},
+		},
+		{
+			name: "list",
+			responses: []response{
+				{pattern: `^SELECT count\(1\)`, columns: []string{"count"}, rows: [][]driver.Value{{int64(1)}}},
+				{pattern: `^SELECT \* FROM "check_execution"`, columns: []string{"id", "check_id", "status", "latency", "created_at"}, rows: [][]driver.Value{
+					{"e1", "api", "UP", int64(time.Second), day},
+				}},
+			},
+			call: func(t *testing.T, sink *Timescale) {
+				page, err := sink.List(context.Background(), db.ExecutionFilter{CheckID: "api"}, db.Order{}, db.Pagination{})
+				if err != nil {
+					t.Fatalf("List() = %v", err)
+				}
+				if page.TotalCount != 1 || len(page.Executions) != 1 || page.Executions[0].Latency != time.Second || !page.Executions[0].CreatedAt.Equal(day) {
+					t.Errorf("List() = %+v, want the execution of the hypertable", page)
+				}
+			},
+			statements: []string{
+				`^SELECT count\(1\) FROM "check_execution" WHERE check_id = \$1$`,
+				`^SELECT \* FROM "check_execution" WHERE check_id = \$1 ORDER BY created_at ASC,id ASC LIMIT 51$`,
+			},
+			args: [][]driver.Value{{"api"}, {"api"}},
+		},
+		{
+			name: "metrics",
+			responses: []response{{pattern: `time_bucket`, columns: metricsColumns, rows: [][]driver.Value{
+				{float64(day.Unix()), int64(4), float64(100 * ms), float64(400 * ms), float64(200 * ms), float64(time.Second), float64(time.Second), float64(time.Second), int64(2), int64(1)},
+				{float64(day.Add(24 * time.Hour).Unix()), int64(1), float64(400 * ms), float64(400 * ms), float64(400 * ms), float64(400 * ms), float64(400 * ms), float64(400 * ms), int64(1), int64(0)},
+			}}},
+			call: func(t *testing.T, sink *Timescale) {
+				buckets, err := sink.Metrics(context.Background(), "api", day, day.Add(48*time.Hour), 24*time.Hour)
+				if err != nil {
+					t.Fatalf("Metrics() = %v", err)
+				}
+				want := []db.MetricsBucket{
+					{Time: day, Count: 4, MinLatency: 100 * time.Millisecond, AvgLatency: 400 * time.Millisecond, P50: 200 * time.Millisecond, P95: time.Second, P99: time.Second, MaxLatency: time.Second, Up: 2, Down: 1},
+					{Time: day.Add(24 * time.Hour), Count: 1, MinLatency: 400 * time.Millisecond, AvgLatency: 400 * time.Millisecond, P50: 400 * time.Millisecond, P95: 400 * time.Millisecond, P99: 400 * time.Millisecond, MaxLatency: 400 * time.Millisecond, Up: 1},
+				}
+				if len(buckets) != len(want) {
+					t.Fatalf("Metrics() = %+v, want %+v", buckets, want)
+				}
+				for i := range want {
+					if !buckets[i].Time.Equal(want[i].Time) {
+						t.Errorf("bucket %d starts at %s, want %s", i, buckets[i].Time, want[i].Time)
+					}
+					buckets[i].Time = want[i].Time
+					if buckets[i] != want[i] {
+						t.Errorf("bucket %d = %+v, want %+v", i, buckets[i], want[i])
+					}
+				}
+			},
+			// the maintenance executions are excluded from up and down but not from the latencies
+			statements: []string{`time_bucket\(\$1 \* INTERVAL '1 second', created_at, TIMESTAMPTZ '1970-01-01 00:00:00\+00'\)\) AS bucket, COUNT\(\*\) AS count, .* SUM\(CASE WHEN status = \$2 AND maintenance = \$3 THEN 1 ELSE 0 END\) AS up, SUM\(CASE WHEN status = \$4 AND maintenance = \$5 THEN 1 ELSE 0 END\) AS down FROM check_execution WHERE check_id = \$6 AND created_at >= \$7 AND created_at < \$8 GROUP BY 1 ORDER BY 1$`},
+			args:       [][]driver.Value{{int64(86400), "UP", false, "DOWN", false, "api", day, day.Add(48 * time.Hour)}},
+		},
+		{
+			name: "uptime",
+			responses: []response{{pattern: `time_bucket`, columns: metricsColumns, rows: [][]driver.Value{
+				{float64(day.Add(-12 * time.Hour).Unix()), int64(3), float64(0), float64(0), float64(0), float64(0), float64(0), float64(0), int64(2), int64(0)},
+				{float64(day.Add(12 * time.Hour).Unix()), int64(2), float64(0), float64(0), float64(0), float64(0), float64(0), float64(0), int64(0), int64(1)},
+			}}},
+			call: func(t *testing.T, sink *Timescale) {
+				up, down, err := sink.Uptime(context.Background(), "api", day.Add(-6*time.Hour), day.Add(18*time.Hour))
+				if err != nil {
+					t.Fatalf("Uptime() = %v", err)
+				}
+				if up != 2 || down != 1 {
+					t.Errorf("Uptime() = %d, %d, want the buckets summed to 2, 1", up, down)
+				}
+			},
+			statements: []string{`AND maintenance = \$3 THEN 1 ELSE 0 END\) AS up`},
+			args:       [][]driver.Value{{int64(86400), "UP", false, "DOWN", false, "api", day.Add(-6 * time.Hour), day.Add(18 * time.Hour)}},
+		},
+		{
+			name: "delete",
+			call: func(t *testing.T, sink *Timescale) {
+				err := sink.Delete(context.Background(), "api")
+				if err != nil {
+					t.Fatalf("Delete() = %v", err)
+				}
+			},
+			statements: []string{`^DELETE FROM "check_execution" WHERE check_id = \$1$`},
+			args:       [][]driver.Value{{"api"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbClient, mock := openMockPostgres(t, tt.responses...)
+			sink, err := NewTimescale(dbClient)
+			if err != nil {
+				t.Fatalf("NewTimescale() = %v", err)
+			}
+			mock.statements = nil
+			tt.call(t, sink)
+			if len(mock.statements) != len(tt.statements) {
+				t.Fatalf("executed %d statements, want %d: %+v", len(mock.statements), len(tt.statements), mock.statements)
+			}
+			for i, pattern := range tt.statements {
+				query := strings.TrimSpace(whitespace.ReplaceAllString(mock.statements[i].query, " "))
+				if !regexp.MustCompile(pattern).MatchString(query) {
+					t.Errorf("statement %d = %s, want %s", i, query, pattern)
+				}
+				if i < len(tt.args) && !reflect.DeepEqual(mock.statements[i].args, tt.args[i]) {
+					t.Errorf("arguments of statement %d = %v, want %v", i, mock.statements[i].args, tt.args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewTimescale(t *testing.T) {
+	dbClient, mock := openMockPostgres(t)
+	_, err := NewTimescale(dbClient)
+	if err != nil {
+		t.Fatalf("NewTimescale() = %v", err)
+	}
+	if len(mock.statements) != len(timescaleSchema) {
+		t.Fatalf("executed %d statements, want the %d of the schema", len(mock.statements), len(timescaleSchema))
+	}
+	for i, statement := range timescaleSchema {
+		if mock.statements[i].query != statement {
+			t.Errorf("statement %d = %s, want %s", i, mock.statements[i].query, statement)
+		}
+	}
+
+	sqlite, err := gorm.Open(db.SQLite(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewTimescale(sqlite)
+	if err == nil || !strings.Contains(err.Error(), "requires a postgres connection") {
+		t.Errorf("NewTimescale() of sqlite = %v, want a postgres connection required", err)
+	}
+}
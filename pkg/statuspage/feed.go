@@ -0,0 +1,85 @@
+package statuspage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const feedIncidents = 50
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    AtomLink    `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type AtomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Link      AtomLink    `xml:"link"`
+	Content   AtomContent `xml:"content"`
+}
+
+// BuildFeed returns the latest incidents of the status page as an atom feed,
+// every entry contains the whole timeline of the incident
+func BuildFeed(dbClient *gorm.DB, statusPage *db.StatusPage, baseURL string) (*AtomFeed, error) {
+	incidents, err := db.GetStatusPageIncidents(dbClient, statusPage, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(incidents) > feedIncidents {
+		incidents = incidents[:feedIncidents]
+	}
+	feed := &AtomFeed{
+		ID:    baseURL,
+		Title: fmt.Sprintf("%s status history", statusPage.Title),
+		Link:  AtomLink{Href: baseURL},
+	}
+	updated := statusPage.UpdatedAt
+	for _, dbIncident := range incidents {
+		incident, err := buildIncident(dbIncident)
+		if err != nil {
+			return nil, err
+		}
+		if dbIncident.UpdatedAt.After(updated) {
+			updated = dbIncident.UpdatedAt
+		}
+		var content strings.Builder
+		for _, update := range incident.Updates {
+			content.WriteString(fmt.Sprintf(
+				"<p><strong>%s</strong> - %s</p>%s",
+				update.Label(),
+				update.CreatedAt.UTC().Format("2006-01-02 15:04 MST"),
+				update.Body,
+			))
+		}
+		feed.Entries = append(feed.Entries, AtomEntry{
+			ID:        fmt.Sprintf("%s#incident-%s", baseURL, incident.ID),
+			Title:     incident.Title,
+			Published: incident.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   dbIncident.UpdatedAt.UTC().Format(time.RFC3339),
+			Link:      AtomLink{Href: fmt.Sprintf("%s#incident-%s", baseURL, incident.ID)},
+			Content:   AtomContent{Type: "html", Body: content.String()},
+		})
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return feed, nil
+}
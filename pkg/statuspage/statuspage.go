@@ -0,0 +1,468 @@
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/yuin/goldmark"
+	"gorm.io/gorm"
+	"html/template"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var pageTemplate = template.Must(template.New("").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}).ParseFS(templatesFS, "templates/*.html"))
+
+const uptimeDays = 90
+
+type ComponentStatus string
+
+const (
+	Operational      ComponentStatus = "operational"
+	Degraded         ComponentStatus = "degraded"
+	Outage           ComponentStatus = "outage"
+	UnderMaintenance ComponentStatus = "maintenance"
+	Unknown          ComponentStatus = "unknown"
+)
+
+// upcomingMaintenancePeriod is how far in advance maintenance windows are announced
+const upcomingMaintenancePeriod = 7 * 24 * time.Hour
+
+// pastIncidentsPeriod is how long resolved incidents are displayed
+const pastIncidentsPeriod = 7 * 24 * time.Hour
+
+type UptimeDay struct {
+	Date time.Time
+	Up   int64
+	Down int64
+}
+
+// Class returns the css class of the uptime bar
+func (d UptimeDay) Class() string {
+	switch {
+	case d.Up+d.Down == 0:
+		return "none"
+	case d.Down == 0:
+		return "up"
+	case d.Up == 0:
+		return "down"
+	default:
+		return "degraded"
+	}
+}
+
+func (d UptimeDay) Percentage() float64 {
+	if d.Up+d.Down == 0 {
+		return 0
+	}
+	return float64(d.Up) * 100 / float64(d.Up+d.Down)
+}
+
+type Component struct {
+	Name        string
+	Status      ComponentStatus
+	LatestCheck time.Time
+	Days        []UptimeDay
+	HasData     bool
+	Uptime      float64
+}
+
+type Group struct {
+	Name       string
+	Components []Component
+}
+
+// outageMessage is displayed for the checks that are down, the errors of the probes are only
+// available through the API since they reveal the internal hosts and addresses
+const outageMessage = "Service unavailable"
+
+// CheckOutage is reported automatically for every component whose check is down
+type CheckOutage struct {
+	Component string
+	Message   string
+	Since     time.Time
+}
+
+type IncidentUpdate struct {
+	Status    db.IncidentStatus
+	Body      template.HTML
+	CreatedAt time.Time
+}
+
+func (u IncidentUpdate) Label() string {
+	switch u.Status {
+	case db.Investigating:
+		return "Investigating"
+	case db.Identified:
+		return "Identified"
+	case db.Monitoring:
+		return "Monitoring"
+	case db.Resolved:
+		return "Resolved"
+	default:
+		return string(u.Status)
+	}
+}
+
+type Maintenance struct {
+	Title       string
+	Description string
+	Components  []string
+	Start       time.Time
+	End         time.Time
+	InProgress  bool
+}
+
+type Incident struct {
+	ID         string
+	Title      string
+	Status     db.IncidentStatus
+	Impact     db.Impact
+	Components []string
+	Updates    []IncidentUpdate
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+}
+
+type Page struct {
+	Slug          string
+	Title         string
+	LogoURL       string
+	Status        ComponentStatus
+	Groups        []Group
+	Outages       []CheckOutage
+	Incidents     []Incident
+	PastIncidents []Incident
+	Maintenances  []Maintenance
+	UpdatedAt     time.Time
+	// FeedURL is the path of the feed, the page is served under several routes
+	FeedURL string
+}
+
+type Handler struct {
+	Db *gorm.DB
+	// Executions answers the uptime of the components, see storage.WithExecutionSink
+	Executions storage.ExecutionRepository
+}
+
+// Register adds the public status page routes, the root path is resolved
+// using the domain of the request. Slugs are unique within a workspace, /status/:slug serves
+// the pages of the default workspace
+func (h Handler) Register(r gin.IRouter) {
+	r.GET("/", func(c *gin.Context) {
+		statusPage, err := h.getByDomain(c)
+		h.render(c, statusPage, err)
+	})
+	r.GET("/history.atom", func(c *gin.Context) {
+		statusPage, err := h.getByDomain(c)
+		h.renderFeed(c, statusPage, err)
+	})
+	r.GET("/status/:slug", func(c *gin.Context) {
+		statusPage, err := db.GetStatusPageBySlug(h.Db, db.DefaultWorkspaceID, c.Param("slug"))
+		h.render(c, statusPage, err)
+	})
+	r.GET("/status/:slug/history.atom", func(c *gin.Context) {
+		statusPage, err := db.GetStatusPageBySlug(h.Db, db.DefaultWorkspaceID, c.Param("slug"))
+		h.renderFeed(c, statusPage, err)
+	})
+	r.GET("/workspaces/:workspace/status/:slug", func(c *gin.Context) {
+		statusPage, err := db.GetStatusPageBySlug(h.Db, c.Param("workspace"), c.Param("slug"))
+		h.render(c, statusPage, err)
+	})
+	r.GET("/workspaces/:workspace/status/:slug/history.atom", func(c *gin.Context) {
+		statusPage, err := db.GetStatusPageBySlug(h.Db, c.Param("workspace"), c.Param("slug"))
+		h.renderFeed(c, statusPage, err)
+	})
+}
+
+func (h Handler) getByDomain(c *gin.Context) (*db.StatusPage, error) {
+	host := c.Request.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return db.GetStatusPageByDomain(h.Db, host)
+}
+
+func (h Handler) render(c *gin.Context, statusPage *db.StatusPage, err error) {
+	if !handleError(c, err) {
+		return
+	}
+	page, err := BuildPage(h.Db, h.Executions, statusPage, time.Now())
+	if err != nil {
+		log.Errorf("Failed to build status page %s: %v", statusPage.Slug, err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	page.FeedURL = strings.TrimSuffix(c.Request.URL.Path, "/") + "/history.atom"
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err = pageTemplate.ExecuteTemplate(c.Writer, "page.html", page)
+	if err != nil {
+		log.Errorf("Failed to render status page %s: %v", statusPage.Slug, err)
+	}
+}
+
+func (h Handler) renderFeed(c *gin.Context, statusPage *db.StatusPage, err error) {
+	if !handleError(c, err) {
+		return
+	}
+	feed, err := BuildFeed(h.Db, statusPage, baseURL(c))
+	if err != nil {
+		log.Errorf("Failed to build feed of status page %s: %v", statusPage.Slug, err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	c.Header("Content-Type", "application/atom+xml; charset=utf-8")
+	c.Status(http.StatusOK)
+	_, _ = c.Writer.WriteString(xml.Header)
+	err = xml.NewEncoder(c.Writer).Encode(feed)
+	if err != nil {
+		log.Errorf("Failed to render feed of status page %s: %v", statusPage.Slug, err)
+	}
+}
+
+// handleError writes the error response, it returns false when the request has been answered
+func handleError(c *gin.Context, err error) bool {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.String(http.StatusNotFound, "Status page not found")
+		return false
+	}
+	if err != nil {
+		log.Errorf("Failed to get status page: %v", err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return false
+	}
+	return true
+}
+
+// baseURL returns the url of the status page being requested, without the feed suffix
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	path := strings.TrimSuffix(c.Request.URL.Path, "/history.atom")
+	return fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, path)
+}
+
+func BuildPage(dbClient *gorm.DB, executions storage.ExecutionRepository, statusPage *db.StatusPage, now time.Time) (*Page, error) {
+	page := &Page{
+		Slug:      statusPage.Slug,
+		Title:     statusPage.Title,
+		LogoURL:   statusPage.LogoURL,
+		Status:    Operational,
+		UpdatedAt: now,
+	}
+	maintenances, checksUnderMaintenance, err := buildMaintenances(dbClient, statusPage, now)
+	if err != nil {
+		return nil, err
+	}
+	page.Maintenances = maintenances
+	today := now.Truncate(24 * time.Hour)
+	from := today.Add(-(uptimeDays - 1) * 24 * time.Hour)
+	for _, statusPageComponent := range statusPage.GroupedComponents() {
+		chk := statusPageComponent.Check
+		if chk.ID == "" {
+			// the check has been deleted
+			continue
+		}
+		component, err := buildComponent(executions, statusPageComponent, from, now)
+		if err != nil {
+			return nil, err
+		}
+		if checksUnderMaintenance[chk.ID] {
+			component.Status = UnderMaintenance
+		}
+		if component.Status == Outage {
+			page.Status = Outage
+			page.Outages = append(page.Outages, CheckOutage{
+				Component: component.Name,
+				Message:   outageMessage,
+				Since:     chk.LatestCheck,
+			})
+		}
+		groupName := statusPageComponent.Group(statusPage.GroupByLabel)
+		if len(page.Groups) == 0 || page.Groups[len(page.Groups)-1].Name != groupName {
+			page.Groups = append(page.Groups, Group{Name: groupName})
+		}
+		group := &page.Groups[len(page.Groups)-1]
+		group.Components = append(group.Components, *component)
+	}
+	incidents, err := db.GetStatusPageIncidents(dbClient, statusPage, now.Add(-pastIncidentsPeriod))
+	if err != nil {
+		return nil, err
+	}
+	for _, dbIncident := range incidents {
+		incident, err := buildIncident(dbIncident)
+		if err != nil {
+			return nil, err
+		}
+		if incident.Status == db.Resolved {
+			page.PastIncidents = append(page.PastIncidents, *incident)
+			continue
+		}
+		page.Incidents = append(page.Incidents, *incident)
+		switch incident.Impact {
+		case db.MajorImpact, db.CriticalImpact:
+			page.Status = Outage
+		case db.MinorImpact:
+			if page.Status == Operational {
+				page.Status = Degraded
+			}
+		}
+	}
+	return page, nil
+}
+
+func buildIncident(dbIncident db.Incident) (*Incident, error) {
+	incident := &Incident{
+		ID:         dbIncident.ID,
+		Title:      dbIncident.Title,
+		Status:     dbIncident.Status,
+		Impact:     dbIncident.Impact,
+		CreatedAt:  dbIncident.CreatedAt,
+		ResolvedAt: dbIncident.ResolvedAt,
+	}
+	for _, component := range dbIncident.Components {
+		incident.Components = append(incident.Components, component.Name)
+	}
+	for _, update := range dbIncident.Updates {
+		body, err := renderMarkdown(update.Body)
+		if err != nil {
+			return nil, err
+		}
+		incident.Updates = append(incident.Updates, IncidentUpdate{
+			Status:    update.Status,
+			Body:      body,
+			CreatedAt: update.CreatedAt,
+		})
+	}
+	return incident, nil
+}
+
+// renderMarkdown converts the markdown to html, raw html in the source is omitted
+func renderMarkdown(source string) (template.HTML, error) {
+	var buf bytes.Buffer
+	err := goldmark.Convert([]byte(source), &buf)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// buildMaintenances returns the maintenance occurrences of the status page that are in progress or upcoming,
+// along with the checks currently under maintenance
+func buildMaintenances(dbClient *gorm.DB, statusPage *db.StatusPage, now time.Time) ([]Maintenance, map[string]bool, error) {
+	componentNames := map[string][]string{}
+	var checkIDs []string
+	for _, component := range statusPage.Components {
+		if component.Check.ID == "" {
+			continue
+		}
+		if _, ok := componentNames[component.CheckID]; !ok {
+			checkIDs = append(checkIDs, component.CheckID)
+		}
+		componentNames[component.CheckID] = append(componentNames[component.CheckID], component.Name)
+	}
+	windows, err := db.GetCheckMaintenanceWindows(dbClient, checkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var maintenances []Maintenance
+	checksUnderMaintenance := map[string]bool{}
+	for _, window := range windows {
+		occurrences, err := window.Occurrences(now, now.Add(upcomingMaintenancePeriod), 3)
+		if err != nil {
+			return nil, nil, err
+		}
+		var components []string
+		for _, chk := range window.Checks {
+			components = append(components, componentNames[chk.ID]...)
+		}
+		for _, occurrence := range occurrences {
+			inProgress := !occurrence.Start.After(now)
+			if inProgress {
+				for _, chk := range window.Checks {
+					checksUnderMaintenance[chk.ID] = true
+				}
+			}
+			maintenances = append(maintenances, Maintenance{
+				Title:       window.Title,
+				Description: window.Description,
+				Components:  components,
+				Start:       occurrence.Start,
+				End:         occurrence.End,
+				InProgress:  inProgress,
+			})
+		}
+	}
+	sort.Slice(maintenances, func(i, j int) bool {
+		return maintenances[i].Start.Before(maintenances[j].Start)
+	})
+	return maintenances, checksUnderMaintenance, nil
+}
+
+func buildComponent(executions storage.ExecutionRepository, statusPageComponent db.StatusPageComponent, from time.Time, now time.Time) (*Component, error) {
+	chk := statusPageComponent.Check
+	component := &Component{
+		Name:        statusPageComponent.Name,
+		Status:      componentStatus(chk.Status),
+		LatestCheck: chk.LatestCheck,
+	}
+	if component.Name == "" {
+		component.Name = chk.Identifier
+	}
+	buckets, err := executions.Metrics(context.Background(), chk.ID, from, now, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	bucketsByDay := map[int64]db.MetricsBucket{}
+	for _, bucket := range buckets {
+		bucketsByDay[bucket.Time.Unix()] = bucket
+	}
+	var up, down int64
+	for day := from; day.Before(now); day = day.Add(24 * time.Hour) {
+		bucket := bucketsByDay[day.Unix()]
+		component.Days = append(component.Days, UptimeDay{
+			Date: day,
+			Up:   bucket.Up,
+			Down: bucket.Down,
+		})
+		up += bucket.Up
+		down += bucket.Down
+	}
+	if up+down > 0 {
+		component.HasData = true
+		component.Uptime = float64(up) * 100 / float64(up+down)
+	}
+	return component, nil
+}
+
+func componentStatus(status db.Status) ComponentStatus {
+	switch status {
+	case db.Up:
+		return Operational
+	case db.Down:
+		return Outage
+	case db.Maintenance:
+		return UnderMaintenance
+	default:
+		return Unknown
+	}
+}
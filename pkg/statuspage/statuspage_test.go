@@ -0,0 +1,292 @@
+package statuspage
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/internal/dbtest"
+	"github.com/kfsoftware/statuspage/pkg/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const probeError = "dial tcp 10.0.3.17:5432: connect: connection refused"
+
+// newTestHandler returns the handler of a database holding the acme status page of the
+// default workspace, served under status.acme.com, and the acme status page of the other
+// workspace. The executions are recorded until noon of today, the time the page is built at
+func newTestHandler(t *testing.T) (Handler, time.Time) {
+	t.Helper()
+	dbClient := dbtest.Open(t, db.SQLite, db.Migrate, db.WorkspacePlugin{})
+	err := db.SaveWorkspace(dbClient, &db.Workspace{ID: "other", Name: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewGormStorage(dbClient)
+	ctx := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+	now := time.Now().UTC().Truncate(24 * time.Hour).Add(12 * time.Hour)
+	today := now.Truncate(24 * time.Hour)
+
+	checks := map[string]*db.Check{}
+	for _, chk := range []struct {
+		identifier string
+		team       string
+		status     db.Status
+	}{
+		{"api", "backend", db.Up},
+		{"postgres", "backend", db.Down},
+		{"web", "frontend", db.Up},
+	} {
+		checks[chk.identifier] = &db.Check{
+			Identifier: chk.identifier,
+			Type:       "tcp",
+			Frequency:  "@every 1m",
+			Status:     chk.status,
+			Labels:     db.NewCheckLabels("", db.Labels{"team": chk.team}),
+		}
+		if chk.status == db.Down {
+			checks[chk.identifier].ErrorMsg = probeError
+			checks[chk.identifier].Message = probeError
+		}
+		err := store.Checks().Create(ctx, checks[chk.identifier])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, execution := range []struct {
+		check  string
+		status db.Status
+		at     time.Time
+	}{
+		{"api", db.Up, today.Add(time.Hour)},
+		{"api", db.Up, today.Add(2 * time.Hour)},
+		{"api", db.Up, today.Add(3 * time.Hour)},
+		{"api", db.Up, today.Add(-2*24*time.Hour + time.Hour)},
+		{"api", db.Down, today.Add(-2*24*time.Hour + 2*time.Hour)},
+		{"postgres", db.Down, today.Add(time.Hour)},
+	} {
+		err := store.Executions().Create(ctx, &db.CheckExecution{
+			CheckID:   checks[execution.check].ID,
+			Status:    execution.status,
+			CreatedAt: execution.at,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statusPage := &db.StatusPage{Slug: "acme", Title: "Acme", Domain: "status.acme.com", GroupByLabel: "team"}
+	err = store.StatusPages().Create(ctx, statusPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var componentIDs []string
+	for i, component := range []struct {
+		name  string
+		check string
+	}{
+		{"Website", "web"},
+		{"API", "api"},
+		{"Database", "postgres"},
+	} {
+		statusPageComponent := &db.StatusPageComponent{
+			StatusPageID: statusPage.ID,
+			Name:         component.name,
+			Position:     i,
+			CheckID:      checks[component.check].ID,
+		}
+		err := store.StatusPages().AddComponent(ctx, statusPageComponent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		componentIDs = append(componentIDs, statusPageComponent.ID)
+	}
+	err = store.Incidents().Create(ctx, &db.Incident{
+		Title:  "Database outage",
+		Status: db.Identified,
+		Impact: db.MajorImpact,
+		Updates: []db.IncidentUpdate{
+			{Status: db.Identified, Body: "The **primary** is down", CreatedAt: now.Add(-time.Hour)},
+		},
+	}, componentIDs[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := db.WithWorkspace(context.Background(), "other")
+	err = store.StatusPages().Create(other, &db.StatusPage{Slug: "acme", Title: "Other Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Handler{Db: dbClient, Executions: store.Executions()}, now
+}
+
+func TestBuildPage(t *testing.T) {
+	h, now := newTestHandler(t)
+	statusPage, err := db.GetStatusPageBySlug(h.Db, db.DefaultWorkspaceID, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := BuildPage(h.Db, h.Executions, statusPage, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Status != Outage {
+		t.Errorf("Status = %s, want outage", page.Status)
+	}
+	if len(page.Outages) != 1 || page.Outages[0].Component != "Database" || page.Outages[0].Message != outageMessage {
+		t.Errorf("Outages = %+v, want the database without the error of the probe", page.Outages)
+	}
+	if len(page.Incidents) != 1 || page.Incidents[0].Components[0] != "Database" {
+		t.Errorf("Incidents = %+v, want the database outage", page.Incidents)
+	}
+
+	var groups []string
+	components := map[string]Component{}
+	for _, group := range page.Groups {
+		var names []string
+		for _, component := range group.Components {
+			names = append(names, component.Name)
+			components[component.Name] = component
+		}
+		groups = append(groups, group.Name+": "+strings.Join(names, ", "))
+	}
+	if strings.Join(groups, "; ") != "backend: API, Database; frontend: Website" {
+		t.Errorf("Groups = %v, want the components grouped by team", groups)
+	}
+
+	api := components["API"]
+	if len(api.Days) != uptimeDays {
+		t.Fatalf("API has %d days, want %d", len(api.Days), uptimeDays)
+	}
+	today := now.Truncate(24 * time.Hour)
+	if !api.Days[uptimeDays-1].Date.Equal(today) {
+		t.Errorf("last day = %v, want %v", api.Days[uptimeDays-1].Date, today)
+	}
+	tests := []struct {
+		component string
+		day       int
+		up        int64
+		down      int64
+		class     string
+	}{
+		{"API", uptimeDays - 1, 3, 0, "up"},
+		{"API", uptimeDays - 2, 0, 0, "none"},
+		{"API", uptimeDays - 3, 1, 1, "degraded"},
+		{"Database", uptimeDays - 1, 0, 1, "down"},
+		{"Website", uptimeDays - 1, 0, 0, "none"},
+	}
+	for _, tt := range tests {
+		day := components[tt.component].Days[tt.day]
+		if day.Up != tt.up || day.Down != tt.down || day.Class() != tt.class {
+			t.Errorf("%s %s = %d up, %d down, %s, want %d up, %d down, %s", tt.component, day.Date.Format("2006-01-02"), day.Up, day.Down, day.Class(), tt.up, tt.down, tt.class)
+		}
+	}
+	if !api.HasData || api.Uptime != 80 {
+		t.Errorf("API uptime = %v, want 80", api.Uptime)
+	}
+	if components["Website"].HasData {
+		t.Error("Website has data without executions")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestHandler(t)
+	r := gin.New()
+	h.Register(r)
+	tests := []struct {
+		name   string
+		host   string
+		path   string
+		status int
+		title  string
+		feed   string
+	}{
+		{"slug", "example.com", "/status/acme", http.StatusOK, "Acme", "/status/acme/history.atom"},
+		{"domain", "status.acme.com:8080", "/", http.StatusOK, "Acme", "/history.atom"},
+		{"workspace", "example.com", "/workspaces/other/status/acme", http.StatusOK, "Other Acme", "/workspaces/other/status/acme/history.atom"},
+		{"unknown slug", "example.com", "/status/missing", http.StatusNotFound, "", ""},
+		{"unknown workspace", "example.com", "/workspaces/missing/status/acme", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("GET %s = %d, want %d", tt.path, w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			body := w.Body.String()
+			if !strings.Contains(body, "<title>"+tt.title) {
+				t.Errorf("GET %s doesn't render the page %s", tt.path, tt.title)
+			}
+			if !strings.Contains(body, `href="`+tt.feed+`"`) {
+				t.Errorf("GET %s doesn't link the feed %s", tt.path, tt.feed)
+			}
+			if strings.Contains(body, "10.0.3.17") {
+				t.Errorf("GET %s reveals the error of the probe", tt.path)
+			}
+		})
+	}
+}
+
+func TestFeedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestHandler(t)
+	r := gin.New()
+	h.Register(r)
+	tests := []struct {
+		name    string
+		host    string
+		path    string
+		link    string
+		entries []string
+	}{
+		{"slug", "example.com", "/status/acme/history.atom", "http://example.com/status/acme", []string{"Database outage"}},
+		{"domain", "status.acme.com", "/history.atom", "http://status.acme.com", []string{"Database outage"}},
+		{"workspace", "example.com", "/workspaces/other/status/acme/history.atom", "http://example.com/workspaces/other/status/acme", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want 200", tt.path, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/atom+xml") {
+				t.Errorf("Content-Type = %s, want atom", contentType)
+			}
+			feed := AtomFeed{}
+			err := xml.Unmarshal(w.Body.Bytes(), &feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if feed.Link.Href != tt.link {
+				t.Errorf("link = %s, want %s", feed.Link.Href, tt.link)
+			}
+			var titles []string
+			for _, entry := range feed.Entries {
+				titles = append(titles, entry.Title)
+				if !strings.HasPrefix(entry.ID, tt.link+"#incident-") {
+					t.Errorf("entry id = %s, want an anchor of %s", entry.ID, tt.link)
+				}
+				if !strings.Contains(entry.Content.Body, "<strong>primary</strong>") {
+					t.Errorf("entry content = %s, want the rendered markdown of the updates", entry.Content.Body)
+				}
+			}
+			if strings.Join(titles, ", ") != strings.Join(tt.entries, ", ") {
+				t.Errorf("entries = %v, want %v", titles, tt.entries)
+			}
+		})
+	}
+}
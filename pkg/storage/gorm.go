@@ -0,0 +1,558 @@
+package storage
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// NewGormStorage returns the storage of the database, the connection must use db.WorkspacePlugin
+// so that the statements are restricted to the workspace of their context
+func NewGormStorage(dbClient *gorm.DB) Storage {
+	return gormStorage{db: dbClient}
+}
+
+type gormStorage struct {
+	db *gorm.DB
+}
+
+func (s gormStorage) Checks() CheckRepository           { return gormChecks(s) }
+func (s gormStorage) Executions() ExecutionRepository   { return gormExecutions(s) }
+func (s gormStorage) Incidents() IncidentRepository     { return gormIncidents(s) }
+func (s gormStorage) Channels() ChannelRepository       { return gormChannels(s) }
+func (s gormStorage) StatusPages() StatusPageRepository { return gormStatusPages(s) }
+func (s gormStorage) MaintenanceWindows() MaintenanceWindowRepository {
+	return gormMaintenanceWindows(s)
+}
+func (s gormStorage) Workspaces() WorkspaceRepository { return gormWorkspaces(s) }
+func (s gormStorage) AuditLog() AuditRepository       { return gormAuditLog(s) }
+
+func (s gormStorage) Transaction(ctx context.Context, fn func(store Storage) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(gormStorage{db: tx})
+	})
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// audit records the change in the workspace of the row, which is the one of the context unless
+// the change is made by the scheduler, empty for the rows without workspace
+func audit(tx *gorm.DB, workspaceID string, operation db.AuditOperation, resourceType db.ResourceType, resourceID string, before interface{}, after interface{}) error {
+	if workspaceID != "" {
+		tx = tx.WithContext(db.WithWorkspace(tx.Statement.Context, workspaceID))
+	}
+	return db.RecordAudit(tx, operation, resourceType, resourceID, before, after)
+}
+
+type gormChecks gormStorage
+
+func (r gormChecks) Get(ctx context.Context, id string) (*db.Check, error) {
+	return r.first(r.db.WithContext(ctx), "id = ?", id)
+}
+
+func (r gormChecks) GetWithDeleted(ctx context.Context, id string) (*db.Check, error) {
+	return r.first(r.db.WithContext(ctx).Unscoped(), "id = ?", id)
+}
+
+func (r gormChecks) GetByIdentifier(ctx context.Context, identifier string) (*db.Check, error) {
+	return r.first(r.db.WithContext(ctx), "identifier = ?", identifier)
+}
+
+func (r gormChecks) first(tx *gorm.DB, query string, args ...interface{}) (*db.Check, error) {
+	chk := &db.Check{}
+	result := tx.Preload("Labels").Where(query, args...).First(chk)
+	if result.Error != nil {
+		return nil, notFound(result.Error)
+	}
+	return chk, nil
+}
+
+func (r gormChecks) GetMany(ctx context.Context, ids []string) ([]db.Check, error) {
+	var checks []db.Check
+	if len(ids) == 0 {
+		return checks, nil
+	}
+	result := r.db.WithContext(ctx).Preload("Labels").Where("id IN ?", ids).Find(&checks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return checks, nil
+}
+
+func (r gormChecks) List(ctx context.Context, filter db.CheckFilter, order db.Order, p db.Pagination) (*db.CheckPage, error) {
+	return db.ListChecks(r.db.WithContext(ctx), filter, order, p)
+}
+
+func (r gormChecks) ListDeleted(ctx context.Context, teams []string) ([]db.Check, error) {
+	return db.GetDeletedChecks(r.db.WithContext(ctx), teams)
+}
+
+func (r gormChecks) ListScheduled(ctx context.Context) ([]db.Check, error) {
+	var checks []db.Check
+	result := r.db.WithContext(ctx).Preload("Labels").Where("paused = ?", false).Find(&checks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return checks, nil
+}
+
+func (r gormChecks) Create(ctx context.Context, chk *db.Check) error {
+	newID(&chk.ID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(chk)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, chk.WorkspaceID, db.CreateOperation, db.CheckResource, chk.ID, nil, chk)
+	})
+}
+
+func (r gormChecks) Save(ctx context.Context, operation db.AuditOperation, chk *db.Check) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := r.first(tx, "id = ?", chk.ID)
+		if err != nil {
+			return err
+		}
+		result := tx.Omit("Labels").Save(chk)
+		if result.Error != nil {
+			return result.Error
+		}
+		err = db.SetCheckLabels(tx, chk.ID, chk.GetLabels())
+		if err != nil {
+			return err
+		}
+		return audit(tx, chk.WorkspaceID, operation, db.CheckResource, chk.ID, before, chk)
+	})
+}
+
+func (r gormChecks) SaveResult(ctx context.Context, chk *db.Check) error {
+	return r.db.WithContext(ctx).
+		Model(chk).
+		Where("paused = ?", false).
+		Select("status", "message", "error_msg", "latest_check", "latency", "cert_expires_at").
+		Updates(chk).Error
+}
+
+func (r gormChecks) Delete(ctx context.Context, chk *db.Check) error {
+	before := *chk
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := db.DeleteCheck(tx, chk)
+		if err != nil {
+			return err
+		}
+		return audit(tx, chk.WorkspaceID, db.DeleteOperation, db.CheckResource, chk.ID, before, nil)
+	})
+}
+
+func (r gormChecks) Restore(ctx context.Context, chk *db.Check) error {
+	before := *chk
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := db.RestoreCheck(tx, chk)
+		if err != nil {
+			return err
+		}
+		return audit(tx, chk.WorkspaceID, db.RestoreOperation, db.CheckResource, chk.ID, before, chk)
+	})
+}
+
+func (r gormChecks) Purge(ctx context.Context, chk *db.Check) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := db.PurgeCheck(tx, chk)
+		if err != nil {
+			return err
+		}
+		return audit(tx, chk.WorkspaceID, db.PurgeOperation, db.CheckResource, chk.ID, chk, nil)
+	})
+}
+
+type gormExecutions gormStorage
+
+func (r gormExecutions) Create(ctx context.Context, execution *db.CheckExecution) error {
+	newID(&execution.ID)
+	return r.db.WithContext(ctx).Create(execution).Error
+}
+
+func (r gormExecutions) List(ctx context.Context, filter db.ExecutionFilter, order db.Order, p db.Pagination) (*db.ExecutionPage, error) {
+	return db.ListExecutions(r.db.WithContext(ctx), filter, order, p)
+}
+
+func (r gormExecutions) Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error) {
+	return db.GetMetrics(r.db.WithContext(ctx), checkID, from, until, bucket)
+}
+
+func (r gormExecutions) Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (int64, int64, error) {
+	return db.GetUptime(r.db.WithContext(ctx), checkID, from, until)
+}
+
+func (r gormExecutions) Compact(ctx context.Context, policy db.RetentionPolicy, now time.Time) error {
+	return db.Compact(r.db.WithContext(ctx), policy, now)
+}
+
+type gormIncidents gormStorage
+
+func (r gormIncidents) Get(ctx context.Context, id string) (*db.Incident, error) {
+	incident, err := db.GetIncident(r.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return incident, nil
+}
+
+func (r gormIncidents) List(ctx context.Context, active *bool) ([]db.Incident, error) {
+	var incidents []db.Incident
+	query := r.db.WithContext(ctx).Preload("Components.Check.Labels").Preload("Updates", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("created_at desc")
+	})
+	if active != nil {
+		if *active {
+			query = query.Where("status <> ?", db.Resolved)
+		} else {
+			query = query.Where("status = ?", db.Resolved)
+		}
+	}
+	result := query.Order("created_at desc").Find(&incidents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return incidents, nil
+}
+
+// components returns the components with the ids, they are only found through the status
+// pages of the workspace
+func (r gormIncidents) components(tx *gorm.DB, ids []string) ([]db.StatusPageComponent, error) {
+	var components []db.StatusPageComponent
+	if len(ids) == 0 {
+		return components, nil
+	}
+	result := tx.
+		Where("id IN ? AND status_page_id IN (?)", ids, tx.Model(&db.StatusPage{}).Select("id")).
+		Find(&components)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	found := map[string]bool{}
+	for _, component := range components {
+		found[component.ID] = true
+	}
+	return components, missingComponent(ids, found)
+}
+
+func (r gormIncidents) Create(ctx context.Context, incident *db.Incident, componentIDs []string) error {
+	newID(&incident.ID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		components, err := r.components(tx, componentIDs)
+		if err != nil {
+			return err
+		}
+		incident.Components = components
+		result := tx.Omit("Components.*").Create(incident)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, incident.WorkspaceID, db.CreateOperation, db.IncidentResource, incident.ID, nil, incident)
+	})
+}
+
+func (r gormIncidents) PostUpdate(ctx context.Context, incident *db.Incident, update db.IncidentUpdate, componentIDs []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := db.GetIncident(tx, incident.ID)
+		if err != nil {
+			return notFound(err)
+		}
+		update.IncidentID = incident.ID
+		result := tx.Create(&update)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Omit("Components", "Updates").Save(incident)
+		if result.Error != nil {
+			return result.Error
+		}
+		if componentIDs != nil {
+			components, err := r.components(tx, componentIDs)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(incident).Omit("Components.*").Association("Components").Replace(components)
+			if err != nil {
+				return err
+			}
+		}
+		after, err := db.GetIncident(tx, incident.ID)
+		if err != nil {
+			return err
+		}
+		return audit(tx, incident.WorkspaceID, db.UpdateOperation, db.IncidentResource, incident.ID, before, after)
+	})
+}
+
+func (r gormIncidents) Delete(ctx context.Context, incident *db.Incident) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(incident)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, incident.WorkspaceID, db.DeleteOperation, db.IncidentResource, incident.ID, incident, nil)
+	})
+}
+
+type gormChannels gormStorage
+
+func (r gormChannels) Get(ctx context.Context, id string) (*db.NotificationChannel, error) {
+	channel := &db.NotificationChannel{}
+	result := r.db.WithContext(ctx).First(channel, "id = ?", id)
+	if result.Error != nil {
+		return nil, notFound(result.Error)
+	}
+	return channel, nil
+}
+
+func (r gormChannels) List(ctx context.Context) ([]db.NotificationChannel, error) {
+	var channels []db.NotificationChannel
+	result := r.db.WithContext(ctx).Order("name").Find(&channels)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return channels, nil
+}
+
+func (r gormChannels) ForCheck(ctx context.Context, chk db.Check) ([]db.NotificationChannel, error) {
+	return db.GetCheckNotificationChannels(r.db.WithContext(ctx), chk)
+}
+
+func (r gormChannels) Create(ctx context.Context, channel *db.NotificationChannel) error {
+	newID(&channel.ID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(channel)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, channel.WorkspaceID, db.CreateOperation, db.NotificationChannelResource, channel.ID, nil, channel)
+	})
+}
+
+func (r gormChannels) Save(ctx context.Context, channel *db.NotificationChannel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before := db.NotificationChannel{}
+		result := tx.First(&before, "id = ?", channel.ID)
+		if result.Error != nil {
+			return notFound(result.Error)
+		}
+		result = tx.Save(channel)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, channel.WorkspaceID, db.UpdateOperation, db.NotificationChannelResource, channel.ID, before, channel)
+	})
+}
+
+func (r gormChannels) Delete(ctx context.Context, channel *db.NotificationChannel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(channel)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, channel.WorkspaceID, db.DeleteOperation, db.NotificationChannelResource, channel.ID, channel, nil)
+	})
+}
+
+type gormStatusPages gormStorage
+
+func (r gormStatusPages) Get(ctx context.Context, id string) (*db.StatusPage, error) {
+	statusPage, err := db.GetStatusPage(r.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return statusPage, nil
+}
+
+func (r gormStatusPages) List(ctx context.Context) ([]db.StatusPage, error) {
+	return db.GetStatusPages(r.db.WithContext(ctx))
+}
+
+func (r gormStatusPages) Create(ctx context.Context, statusPage *db.StatusPage) error {
+	newID(&statusPage.ID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := r.checkDomain(tx, statusPage)
+		if err != nil {
+			return err
+		}
+		result := tx.Omit("Components").Create(statusPage)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, statusPage.WorkspaceID, db.CreateOperation, db.StatusPageResource, statusPage.ID, nil, statusPage)
+	})
+}
+
+func (r gormStatusPages) Save(ctx context.Context, statusPage *db.StatusPage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before := db.StatusPage{}
+		result := tx.First(&before, "id = ?", statusPage.ID)
+		if result.Error != nil {
+			return notFound(result.Error)
+		}
+		err := r.checkDomain(tx, statusPage)
+		if err != nil {
+			return err
+		}
+		result = tx.Omit("Components").Save(statusPage)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, statusPage.WorkspaceID, db.UpdateOperation, db.StatusPageResource, statusPage.ID, before, statusPage)
+	})
+}
+
+// checkDomain rejects the status page whose domain is used by another status page of any workspace
+func (r gormStatusPages) checkDomain(tx *gorm.DB, statusPage *db.StatusPage) error {
+	taken, err := db.DomainTaken(tx, statusPage)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errDomainTaken(statusPage)
+	}
+	return nil
+}
+
+func (r gormStatusPages) Delete(ctx context.Context, statusPage *db.StatusPage) error {
+	before := *statusPage
+	before.Components = nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := db.DeleteStatusPage(tx, statusPage)
+		if err != nil {
+			return err
+		}
+		return audit(tx, statusPage.WorkspaceID, db.DeleteOperation, db.StatusPageResource, statusPage.ID, before, nil)
+	})
+}
+
+func (r gormStatusPages) GetComponent(ctx context.Context, id string) (*db.StatusPageComponent, error) {
+	tx := r.db.WithContext(ctx)
+	component := &db.StatusPageComponent{}
+	result := tx.First(component, "id = ? AND status_page_id IN (?)", id, tx.Model(&db.StatusPage{}).Select("id"))
+	if result.Error != nil {
+		return nil, notFound(result.Error)
+	}
+	return component, nil
+}
+
+func (r gormStatusPages) AddComponent(ctx context.Context, component *db.StatusPageComponent) error {
+	newID(&component.ID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Check").Create(component)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, "", db.CreateOperation, db.StatusPageComponentResource, component.ID, nil, component)
+	})
+}
+
+func (r gormStatusPages) SaveComponent(ctx context.Context, component *db.StatusPageComponent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before := &db.StatusPageComponent{}
+		result := tx.First(before, "id = ? AND status_page_id IN (?)", component.ID, tx.Model(&db.StatusPage{}).Select("id"))
+		if result.Error != nil {
+			return notFound(result.Error)
+		}
+		component.StatusPageID = before.StatusPageID
+		component.CreatedAt = before.CreatedAt
+		result = tx.Omit("Check").Save(component)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, "", db.UpdateOperation, db.StatusPageComponentResource, component.ID, before, component)
+	})
+}
+
+func (r gormStatusPages) RemoveComponent(ctx context.Context, component *db.StatusPageComponent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(component)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, "", db.DeleteOperation, db.StatusPageComponentResource, component.ID, component, nil)
+	})
+}
+
+type gormMaintenanceWindows gormStorage
+
+func (r gormMaintenanceWindows) Get(ctx context.Context, id string) (*db.MaintenanceWindow, error) {
+	window, err := db.GetMaintenanceWindow(r.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return window, nil
+}
+
+func (r gormMaintenanceWindows) List(ctx context.Context) ([]db.MaintenanceWindow, error) {
+	return db.GetMaintenanceWindows(r.db.WithContext(ctx))
+}
+
+func (r gormMaintenanceWindows) Active(ctx context.Context, now time.Time) (map[string]db.MaintenanceWindow, error) {
+	return db.GetActiveMaintenanceWindows(r.db.WithContext(ctx), now)
+}
+
+func (r gormMaintenanceWindows) Create(ctx context.Context, window *db.MaintenanceWindow) error {
+	newID(&window.ID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Checks.*").Create(window)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, window.WorkspaceID, db.CreateOperation, db.MaintenanceWindowResource, window.ID, nil, window)
+	})
+}
+
+func (r gormMaintenanceWindows) Save(ctx context.Context, window *db.MaintenanceWindow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before := db.MaintenanceWindow{}
+		result := tx.Preload("Checks").First(&before, "id = ?", window.ID)
+		if result.Error != nil {
+			return notFound(result.Error)
+		}
+		result = tx.Omit("Checks").Save(window)
+		if result.Error != nil {
+			return result.Error
+		}
+		err := tx.Model(window).Omit("Checks.*").Association("Checks").Replace(window.Checks)
+		if err != nil {
+			return err
+		}
+		return audit(tx, window.WorkspaceID, db.UpdateOperation, db.MaintenanceWindowResource, window.ID, before, window)
+	})
+}
+
+func (r gormMaintenanceWindows) Delete(ctx context.Context, window *db.MaintenanceWindow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(window)
+		if result.Error != nil {
+			return result.Error
+		}
+		return audit(tx, window.WorkspaceID, db.DeleteOperation, db.MaintenanceWindowResource, window.ID, window, nil)
+	})
+}
+
+type gormWorkspaces gormStorage
+
+func (r gormWorkspaces) Get(ctx context.Context, id string) (*db.Workspace, error) {
+	return db.GetWorkspace(r.db.WithContext(ctx), id)
+}
+
+func (r gormWorkspaces) Usage(ctx context.Context, id string) (*db.WorkspaceUsage, error) {
+	return db.GetWorkspaceUsage(r.db.WithContext(ctx), id)
+}
+
+type gormAuditLog gormStorage
+
+func (r gormAuditLog) List(ctx context.Context, filter db.AuditFilter, p db.Pagination) (*db.AuditPage, error) {
+	return db.ListAuditEntries(r.db.WithContext(ctx), filter, p)
+}
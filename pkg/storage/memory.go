@@ -0,0 +1,1531 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryStorage keeps everything in memory, it behaves like the database for the workspaces,
+// the quotas and the audit log and is meant for tests
+type MemoryStorage struct {
+	mu                 sync.Mutex
+	workspaces         map[string]db.Workspace
+	checks             map[string]db.Check
+	executions         []db.CheckExecution
+	incidents          map[string]db.Incident
+	incidentComponents map[string][]string
+	incidentUpdates    map[string][]db.IncidentUpdate
+	channels           map[string]db.NotificationChannel
+	statusPages        map[string]db.StatusPage
+	components         map[string]db.StatusPageComponent
+	windows            map[string]db.MaintenanceWindow
+	windowChecks       map[string][]string
+	auditEntries       []db.AuditEntry
+}
+
+// NewMemoryStorage returns an empty storage holding the default workspace
+func NewMemoryStorage() *MemoryStorage {
+	s := &MemoryStorage{
+		workspaces:         map[string]db.Workspace{},
+		checks:             map[string]db.Check{},
+		incidents:          map[string]db.Incident{},
+		incidentComponents: map[string][]string{},
+		incidentUpdates:    map[string][]db.IncidentUpdate{},
+		channels:           map[string]db.NotificationChannel{},
+		statusPages:        map[string]db.StatusPage{},
+		components:         map[string]db.StatusPageComponent{},
+		windows:            map[string]db.MaintenanceWindow{},
+		windowChecks:       map[string][]string{},
+	}
+	s.SaveWorkspace(db.Workspace{ID: db.DefaultWorkspaceID, Name: "Default"})
+	return s
+}
+
+// SaveWorkspace creates or updates the workspace
+func (s *MemoryStorage) SaveWorkspace(workspace db.Workspace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if existing, ok := s.workspaces[workspace.ID]; ok {
+		workspace.CreatedAt = existing.CreatedAt
+	} else {
+		workspace.CreatedAt = now
+	}
+	workspace.UpdatedAt = now
+	s.workspaces[workspace.ID] = workspace
+}
+
+func (s *MemoryStorage) Checks() CheckRepository         { return memoryChecks{s} }
+func (s *MemoryStorage) Executions() ExecutionRepository { return memoryExecutions{s} }
+func (s *MemoryStorage) Incidents() IncidentRepository   { return memoryIncidents{s} }
+func (s *MemoryStorage) Channels() ChannelRepository     { return memoryChannels{s} }
+func (s *MemoryStorage) StatusPages() StatusPageRepository {
+	return memoryStatusPages{s}
+}
+func (s *MemoryStorage) MaintenanceWindows() MaintenanceWindowRepository {
+	return memoryMaintenanceWindows{s}
+}
+func (s *MemoryStorage) Workspaces() WorkspaceRepository { return memoryWorkspaces{s} }
+func (s *MemoryStorage) AuditLog() AuditRepository       { return memoryAuditLog{s} }
+
+// Transaction restores the content of the storage when fn fails, the changes made meanwhile
+// by other callers are reverted as well
+func (s *MemoryStorage) Transaction(ctx context.Context, fn func(store Storage) error) error {
+	s.mu.Lock()
+	snapshot := s.snapshot()
+	s.mu.Unlock()
+	err := fn(s)
+	if err != nil {
+		s.mu.Lock()
+		s.restore(snapshot)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// snapshot copies the maps of the storage, the rows are replaced rather than modified so that
+// they can be shared with the copy
+func (s *MemoryStorage) snapshot() *MemoryStorage {
+	snapshot := &MemoryStorage{
+		workspaces:         map[string]db.Workspace{},
+		checks:             map[string]db.Check{},
+		executions:         append([]db.CheckExecution(nil), s.executions...),
+		incidents:          map[string]db.Incident{},
+		incidentComponents: map[string][]string{},
+		incidentUpdates:    map[string][]db.IncidentUpdate{},
+		channels:           map[string]db.NotificationChannel{},
+		statusPages:        map[string]db.StatusPage{},
+		components:         map[string]db.StatusPageComponent{},
+		windows:            map[string]db.MaintenanceWindow{},
+		windowChecks:       map[string][]string{},
+		auditEntries:       append([]db.AuditEntry(nil), s.auditEntries...),
+	}
+	for k, v := range s.workspaces {
+		snapshot.workspaces[k] = v
+	}
+	for k, v := range s.checks {
+		snapshot.checks[k] = v
+	}
+	for k, v := range s.incidents {
+		snapshot.incidents[k] = v
+	}
+	for k, v := range s.incidentComponents {
+		snapshot.incidentComponents[k] = append([]string(nil), v...)
+	}
+	for k, v := range s.incidentUpdates {
+		snapshot.incidentUpdates[k] = append([]db.IncidentUpdate(nil), v...)
+	}
+	for k, v := range s.channels {
+		snapshot.channels[k] = v
+	}
+	for k, v := range s.statusPages {
+		snapshot.statusPages[k] = v
+	}
+	for k, v := range s.components {
+		snapshot.components[k] = v
+	}
+	for k, v := range s.windows {
+		snapshot.windows[k] = v
+	}
+	for k, v := range s.windowChecks {
+		snapshot.windowChecks[k] = append([]string(nil), v...)
+	}
+	return snapshot
+}
+
+func (s *MemoryStorage) restore(snapshot *MemoryStorage) {
+	s.workspaces = snapshot.workspaces
+	s.checks = snapshot.checks
+	s.executions = snapshot.executions
+	s.incidents = snapshot.incidents
+	s.incidentComponents = snapshot.incidentComponents
+	s.incidentUpdates = snapshot.incidentUpdates
+	s.channels = snapshot.channels
+	s.statusPages = snapshot.statusPages
+	s.components = snapshot.components
+	s.windows = snapshot.windows
+	s.windowChecks = snapshot.windowChecks
+	s.auditEntries = snapshot.auditEntries
+}
+
+func workspaceOrDefault(workspaceID string) string {
+	if workspaceID == "" {
+		return db.DefaultWorkspaceID
+	}
+	return workspaceID
+}
+
+// visible tells whether a row of the workspace can be accessed with the context
+func visible(ctx context.Context, workspaceID string) bool {
+	contextWorkspace, ok := db.WorkspaceFromContext(ctx)
+	return !ok || contextWorkspace == workspaceOrDefault(workspaceID)
+}
+
+// assignWorkspace sets the workspace of a created row the same way db.WorkspacePlugin does
+func assignWorkspace(ctx context.Context, workspaceID *string) {
+	if contextWorkspace, ok := db.WorkspaceFromContext(ctx); ok {
+		*workspaceID = contextWorkspace
+		return
+	}
+	*workspaceID = workspaceOrDefault(*workspaceID)
+}
+
+// checkQuota fails when the workspace holds count resources or more, see db.Workspace
+func (s *MemoryStorage) checkQuota(workspaceID string, count int, resource string, quota func(db.Workspace) int) error {
+	workspace, ok := s.workspaces[workspaceID]
+	if !ok {
+		return errors.Errorf("Workspace %s not found", workspaceID)
+	}
+	max := quota(workspace)
+	if max > 0 && count >= max {
+		return errors.Errorf("Quota exceeded, workspace %s is limited to %d %s", workspaceID, max, resource)
+	}
+	return nil
+}
+
+// audit appends the change to the audit log in the workspace of the row, or the one of the
+// context when workspaceID is empty
+func (s *MemoryStorage) audit(ctx context.Context, workspaceID string, operation db.AuditOperation, resourceType db.ResourceType, resourceID string, before interface{}, after interface{}) error {
+	entry, err := db.NewAuditEntry(ctx, operation, resourceType, resourceID, before, after)
+	if err != nil {
+		return err
+	}
+	if workspaceID != "" {
+		entry.WorkspaceID = workspaceID
+	} else {
+		assignWorkspace(ctx, &entry.WorkspaceID)
+	}
+	entry.CreatedAt = time.Now()
+	s.auditEntries = append(s.auditEntries, *entry)
+	return nil
+}
+
+// row is an item sorted by the value of the order column, ties are broken by id
+type row struct {
+	value interface{}
+	id    string
+	index int
+}
+
+func compareValues(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		b := b.(time.Time)
+		if a.Before(b) {
+			return -1
+		}
+		if a.After(b) {
+			return 1
+		}
+		return 0
+	case int64:
+		var other int64
+		switch b := b.(type) {
+		case int64:
+			other = b
+		case string:
+			// cursors hold the values as text
+			other, _ = strconv.ParseInt(b, 10, 64)
+		}
+		if a < other {
+			return -1
+		}
+		if a > other {
+			return 1
+		}
+		return 0
+	default:
+		x, y := fmt.Sprint(a), fmt.Sprint(b)
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+		return 0
+	}
+}
+
+func compareRows(a row, value interface{}, id string) int {
+	c := compareValues(a.value, value)
+	if c != 0 {
+		return c
+	}
+	if a.id < id {
+		return -1
+	}
+	if a.id > id {
+		return 1
+	}
+	return 0
+}
+
+// paginate returns the indexes of the rows of the page and their cursors, the same way the
+// keyset pagination of the database does
+func paginate(rows []row, order db.Order, p db.Pagination) ([]int, []string, db.PageInfo, error) {
+	if order.Direction == "" {
+		order.Direction = db.Asc
+	}
+	limit, backwards, err := p.Limit()
+	if err != nil {
+		return nil, nil, db.PageInfo{}, err
+	}
+	bounds := []struct {
+		cursor  *string
+		greater bool
+	}{
+		{p.After, order.Direction == db.Asc},
+		{p.Before, order.Direction == db.Desc},
+	}
+	for _, bound := range bounds {
+		if bound.cursor == nil {
+			continue
+		}
+		value, id, err := db.DecodeCursor(*bound.cursor, order.Column)
+		if err != nil {
+			return nil, nil, db.PageInfo{}, err
+		}
+		var kept []row
+		for _, r := range rows {
+			c := compareRows(r, value, id)
+			if (bound.greater && c > 0) || (!bound.greater && c < 0) {
+				kept = append(kept, r)
+			}
+		}
+		rows = kept
+	}
+	ascending := (order.Direction == db.Asc) != backwards
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := compareRows(rows[i], rows[j].value, rows[j].id)
+		if ascending {
+			return c < 0
+		}
+		return c > 0
+	})
+	if len(rows) > limit+1 {
+		rows = rows[:limit+1]
+	}
+	indexes, info := p.Trim(len(rows), limit, backwards)
+	var page []int
+	var cursors []string
+	for _, i := range indexes {
+		page = append(page, rows[i].index)
+		cursors = append(cursors, db.EncodeCursor(rows[i].value, rows[i].id))
+	}
+	return page, cursors, info, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func copyCheck(chk db.Check) db.Check {
+	chk.Labels = db.NewCheckLabels(chk.ID, chk.GetLabels())
+	sort.Slice(chk.Labels, func(i, j int) bool {
+		return chk.Labels[i].Name < chk.Labels[j].Name
+	})
+	chk.Executions = nil
+	return chk
+}
+
+type memoryChecks struct {
+	*MemoryStorage
+}
+
+// find returns the check unless it belongs to another workspace or it is deleted
+func (r memoryChecks) find(ctx context.Context, id string, withDeleted bool) (db.Check, bool) {
+	chk, ok := r.checks[id]
+	if !ok || !visible(ctx, chk.WorkspaceID) || (chk.DeletedAt.Valid && !withDeleted) {
+		return db.Check{}, false
+	}
+	return copyCheck(chk), true
+}
+
+func (r memoryChecks) Get(ctx context.Context, id string) (*db.Check, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chk, ok := r.find(ctx, id, false)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &chk, nil
+}
+
+func (r memoryChecks) GetWithDeleted(ctx context.Context, id string) (*db.Check, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chk, ok := r.find(ctx, id, true)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &chk, nil
+}
+
+func (r memoryChecks) GetByIdentifier(ctx context.Context, identifier string) (*db.Check, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, chk := range r.checks {
+		if chk.Identifier != identifier {
+			continue
+		}
+		if chk, ok := r.find(ctx, id, false); ok {
+			return &chk, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryChecks) GetMany(ctx context.Context, ids []string) ([]db.Check, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var checks []db.Check
+	for _, id := range ids {
+		if chk, ok := r.find(ctx, id, false); ok {
+			checks = append(checks, chk)
+		}
+	}
+	return checks, nil
+}
+
+// all returns the checks of the workspace of the context
+func (r memoryChecks) all(ctx context.Context, deleted bool) []db.Check {
+	var checks []db.Check
+	for _, chk := range r.checks {
+		if visible(ctx, chk.WorkspaceID) && chk.DeletedAt.Valid == deleted {
+			checks = append(checks, copyCheck(chk))
+		}
+	}
+	return checks
+}
+
+func (r memoryChecks) List(ctx context.Context, filter db.CheckFilter, order db.Order, p db.Pagination) (*db.CheckPage, error) {
+	if order.Column.Name == "" {
+		order.Column = db.IdentifierColumn
+	}
+	if filter.Teams != nil && len(filter.Teams) == 0 {
+		return &db.CheckPage{}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var checks []db.Check
+	var rows []row
+	for _, chk := range r.all(ctx, false) {
+		if len(filter.Types) > 0 && !contains(filter.Types, string(chk.Type)) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !contains(filter.Statuses, string(chk.Status)) {
+			continue
+		}
+		if filter.Owner != "" && chk.Owner != filter.Owner {
+			continue
+		}
+		if filter.Teams != nil && !contains(filter.Teams, chk.Owner) {
+			continue
+		}
+		if !chk.GetLabels().Matches(filter.Labels) {
+			continue
+		}
+		rows = append(rows, row{value: chk.OrderValue(order.Column), id: chk.ID, index: len(checks)})
+		checks = append(checks, chk)
+	}
+	indexes, cursors, info, err := paginate(rows, order, p)
+	if err != nil {
+		return nil, err
+	}
+	page := &db.CheckPage{
+		Cursors:    cursors,
+		PageInfo:   info,
+		TotalCount: int64(len(checks)),
+	}
+	for _, i := range indexes {
+		page.Checks = append(page.Checks, checks[i])
+	}
+	return page, nil
+}
+
+func (r memoryChecks) ListDeleted(ctx context.Context, teams []string) ([]db.Check, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var checks []db.Check
+	for _, chk := range r.all(ctx, true) {
+		if teams == nil || contains(teams, chk.Owner) {
+			checks = append(checks, chk)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].DeletedAt.Time.After(checks[j].DeletedAt.Time)
+	})
+	return checks, nil
+}
+
+func (r memoryChecks) ListScheduled(ctx context.Context) ([]db.Check, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var checks []db.Check
+	for _, chk := range r.all(ctx, false) {
+		if !chk.Paused {
+			checks = append(checks, chk)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].ID < checks[j].ID
+	})
+	return checks, nil
+}
+
+// identifierTaken tells whether another check of the workspace, deleted or not, has the identifier
+func (r memoryChecks) identifierTaken(workspaceID string, identifier string, id string) bool {
+	for _, chk := range r.checks {
+		if chk.ID != id && chk.WorkspaceID == workspaceID && chk.Identifier == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryChecks) count(workspaceID string) int {
+	count := 0
+	for _, chk := range r.checks {
+		if chk.WorkspaceID == workspaceID && !chk.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count
+}
+
+func (r memoryChecks) Create(ctx context.Context, chk *db.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newID(&chk.ID)
+	assignWorkspace(ctx, &chk.WorkspaceID)
+	if _, ok := r.checks[chk.ID]; ok {
+		return errors.Errorf("Check %s already exists", chk.ID)
+	}
+	if r.identifierTaken(chk.WorkspaceID, chk.Identifier, chk.ID) {
+		return errors.Errorf("Check %s already exists", chk.Identifier)
+	}
+	err := r.checkQuota(chk.WorkspaceID, r.count(chk.WorkspaceID), "checks", func(w db.Workspace) int {
+		return w.MaxChecks
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if chk.CreatedAt.IsZero() {
+		chk.CreatedAt = now
+	}
+	chk.UpdatedAt = now
+	r.checks[chk.ID] = copyCheck(*chk)
+	return r.audit(ctx, chk.WorkspaceID, db.CreateOperation, db.CheckResource, chk.ID, nil, chk)
+}
+
+func (r memoryChecks) Save(ctx context.Context, operation db.AuditOperation, chk *db.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, chk.ID, false)
+	if !ok {
+		return ErrNotFound
+	}
+	chk.WorkspaceID = before.WorkspaceID
+	if r.identifierTaken(chk.WorkspaceID, chk.Identifier, chk.ID) {
+		return errors.Errorf("Check %s already exists", chk.Identifier)
+	}
+	chk.CreatedAt = before.CreatedAt
+	chk.UpdatedAt = time.Now()
+	r.checks[chk.ID] = copyCheck(*chk)
+	return r.audit(ctx, chk.WorkspaceID, operation, db.CheckResource, chk.ID, before, chk)
+}
+
+func (r memoryChecks) SaveResult(ctx context.Context, chk *db.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.find(ctx, chk.ID, false)
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Paused {
+		return nil
+	}
+	stored.Status = chk.Status
+	stored.Message = chk.Message
+	stored.ErrorMsg = chk.ErrorMsg
+	stored.LatestCheck = chk.LatestCheck
+	stored.Latency = chk.Latency
+	stored.CertExpiresAt = chk.CertExpiresAt
+	stored.UpdatedAt = time.Now()
+	r.checks[chk.ID] = stored
+	return nil
+}
+
+func (r memoryChecks) Delete(ctx context.Context, chk *db.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, chk.ID, false)
+	if !ok {
+		return ErrNotFound
+	}
+	chk.Identifier = fmt.Sprintf("%s-deleted-%s", chk.Identifier, uuid.New().String())
+	chk.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	stored := r.checks[chk.ID]
+	stored.Identifier = chk.Identifier
+	stored.DeletedAt = chk.DeletedAt
+	r.checks[chk.ID] = stored
+	return r.audit(ctx, before.WorkspaceID, db.DeleteOperation, db.CheckResource, chk.ID, before, nil)
+}
+
+func (r memoryChecks) Restore(ctx context.Context, chk *db.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, chk.ID, true)
+	if !ok {
+		return ErrNotFound
+	}
+	if !before.DeletedAt.Valid {
+		return errors.Errorf("Check %s is not deleted", chk.ID)
+	}
+	err := r.checkQuota(before.WorkspaceID, r.count(before.WorkspaceID), "checks", func(w db.Workspace) int {
+		return w.MaxChecks
+	})
+	if err != nil {
+		return err
+	}
+	identifier := before.OriginalIdentifier()
+	taken := false
+	for _, other := range r.checks {
+		if other.WorkspaceID == before.WorkspaceID && other.Identifier == identifier && !other.DeletedAt.Valid {
+			taken = true
+		}
+	}
+	if !taken {
+		chk.Identifier = identifier
+	}
+	chk.DeletedAt = gorm.DeletedAt{}
+	stored := r.checks[chk.ID]
+	stored.Identifier = chk.Identifier
+	stored.DeletedAt = chk.DeletedAt
+	r.checks[chk.ID] = stored
+	return r.audit(ctx, before.WorkspaceID, db.RestoreOperation, db.CheckResource, chk.ID, before, chk)
+}
+
+func (r memoryChecks) Purge(ctx context.Context, chk *db.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, chk.ID, true)
+	if !ok {
+		return ErrNotFound
+	}
+	if !before.DeletedAt.Valid {
+		return errors.Errorf("Check %s must be deleted before it is purged", chk.ID)
+	}
+	var executions []db.CheckExecution
+	for _, execution := range r.executions {
+		if execution.CheckID != chk.ID {
+			executions = append(executions, execution)
+		}
+	}
+	r.executions = executions
+	for id, component := range r.components {
+		if component.CheckID != chk.ID {
+			continue
+		}
+		delete(r.components, id)
+		for incidentID, componentIDs := range r.incidentComponents {
+			r.incidentComponents[incidentID] = without(componentIDs, id)
+		}
+	}
+	for windowID, checkIDs := range r.windowChecks {
+		r.windowChecks[windowID] = without(checkIDs, chk.ID)
+	}
+	delete(r.checks, chk.ID)
+	return r.audit(ctx, before.WorkspaceID, db.PurgeOperation, db.CheckResource, chk.ID, chk, nil)
+}
+
+func without(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// memoryExecutions keeps every execution, the checks are expected to be authorized by the caller
+type memoryExecutions struct {
+	*MemoryStorage
+}
+
+func (r memoryExecutions) Create(ctx context.Context, execution *db.CheckExecution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newID(&execution.ID)
+	now := time.Now()
+	if execution.CreatedAt.IsZero() {
+		execution.CreatedAt = now
+	}
+	execution.UpdatedAt = now
+	r.executions = append(r.executions, *execution)
+	return nil
+}
+
+func (r memoryExecutions) List(ctx context.Context, filter db.ExecutionFilter, order db.Order, p db.Pagination) (*db.ExecutionPage, error) {
+	if order.Column.Name == "" {
+		order.Column = db.CreatedAtColumn
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var executions []db.CheckExecution
+	var rows []row
+	for _, execution := range r.executions {
+		if execution.CheckID != filter.CheckID {
+			continue
+		}
+		if filter.From != nil && execution.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.Until != nil && execution.CreatedAt.After(*filter.Until) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !contains(filter.Statuses, string(execution.Status)) {
+			continue
+		}
+		rows = append(rows, row{value: execution.OrderValue(order.Column), id: execution.ID, index: len(executions)})
+		executions = append(executions, execution)
+	}
+	indexes, cursors, info, err := paginate(rows, order, p)
+	if err != nil {
+		return nil, err
+	}
+	page := &db.ExecutionPage{
+		Cursors:    cursors,
+		PageInfo:   info,
+		TotalCount: int64(len(executions)),
+	}
+	for _, i := range indexes {
+		page.Executions = append(page.Executions, executions[i])
+	}
+	return page, nil
+}
+
+// percentile returns the latency of the given rank of the sorted latencies, using the
+// nearest-rank method like the database
+func percentile(latencies []time.Duration, rank float64) time.Duration {
+	i := int(math.Ceil(rank*float64(len(latencies)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return latencies[i]
+}
+
+func (r memoryExecutions) Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error) {
+	if bucket < time.Second {
+		return nil, errors.Errorf("Bucket must be at least one second, got %s", bucket)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	latencies := map[int64][]time.Duration{}
+	buckets := map[int64]*db.MetricsBucket{}
+	for _, execution := range r.executions {
+		if execution.CheckID != checkID || execution.CreatedAt.Before(from) || !execution.CreatedAt.Before(until) {
+			continue
+		}
+		start := db.BucketStart(execution.CreatedAt, bucket).Unix()
+		b, ok := buckets[start]
+		if !ok {
+			b = &db.MetricsBucket{Time: time.Unix(start, 0)}
+			buckets[start] = b
+		}
+		b.Count++
+		if execution.Status == db.Up && !execution.Maintenance {
+			b.Up++
+		}
+		if execution.Status == db.Down && !execution.Maintenance {
+			b.Down++
+		}
+		latencies[start] = append(latencies[start], execution.Latency)
+	}
+	var metrics []db.MetricsBucket
+	for start, b := range buckets {
+		bucketLatencies := latencies[start]
+		sort.Slice(bucketLatencies, func(i, j int) bool {
+			return bucketLatencies[i] < bucketLatencies[j]
+		})
+		var total time.Duration
+		for _, latency := range bucketLatencies {
+			total += latency
+		}
+		b.MinLatency = bucketLatencies[0]
+		b.MaxLatency = bucketLatencies[len(bucketLatencies)-1]
+		b.AvgLatency = total / time.Duration(len(bucketLatencies))
+		b.P50 = percentile(bucketLatencies, 0.5)
+		b.P95 = percentile(bucketLatencies, 0.95)
+		b.P99 = percentile(bucketLatencies, 0.99)
+		metrics = append(metrics, *b)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Time.Before(metrics[j].Time)
+	})
+	return metrics, nil
+}
+
+func (r memoryExecutions) Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (int64, int64, error) {
+	bucket := until.Sub(from)
+	if bucket < time.Second {
+		bucket = time.Second
+	}
+	metrics, err := r.Metrics(ctx, checkID, from, until, bucket)
+	if err != nil {
+		return 0, 0, err
+	}
+	var up, down int64
+	for _, b := range metrics {
+		up += b.Up
+		down += b.Down
+	}
+	return up, down, nil
+}
+
+// Compact does nothing, the executions are kept for the lifetime of the storage
+func (r memoryExecutions) Compact(ctx context.Context, policy db.RetentionPolicy, now time.Time) error {
+	return nil
+}
+
+type memoryIncidents struct {
+	*MemoryStorage
+}
+
+// component returns the component along with its check, the status page must be visible
+func (s *MemoryStorage) component(ctx context.Context, id string) (db.StatusPageComponent, bool) {
+	component, ok := s.components[id]
+	if !ok {
+		return db.StatusPageComponent{}, false
+	}
+	statusPage, ok := s.statusPages[component.StatusPageID]
+	if !ok || !visible(ctx, statusPage.WorkspaceID) {
+		return db.StatusPageComponent{}, false
+	}
+	if chk, ok := s.checks[component.CheckID]; ok && !chk.DeletedAt.Valid {
+		component.Check = copyCheck(chk)
+	}
+	return component, true
+}
+
+func (r memoryIncidents) load(incident db.Incident) db.Incident {
+	incident.Components = nil
+	for _, id := range r.incidentComponents[incident.ID] {
+		if component, ok := r.component(context.Background(), id); ok {
+			incident.Components = append(incident.Components, component)
+		}
+	}
+	updates := r.incidentUpdates[incident.ID]
+	incident.Updates = make([]db.IncidentUpdate, len(updates))
+	copy(incident.Updates, updates)
+	sort.SliceStable(incident.Updates, func(i, j int) bool {
+		return incident.Updates[i].CreatedAt.After(incident.Updates[j].CreatedAt)
+	})
+	return incident
+}
+
+func (r memoryIncidents) find(ctx context.Context, id string) (db.Incident, bool) {
+	incident, ok := r.incidents[id]
+	if !ok || !visible(ctx, incident.WorkspaceID) {
+		return db.Incident{}, false
+	}
+	return r.load(incident), true
+}
+
+func (r memoryIncidents) Get(ctx context.Context, id string) (*db.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	incident, ok := r.find(ctx, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &incident, nil
+}
+
+func (r memoryIncidents) List(ctx context.Context, active *bool) ([]db.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var incidents []db.Incident
+	for id := range r.incidents {
+		incident, ok := r.find(ctx, id)
+		if !ok {
+			continue
+		}
+		if active != nil && *active == (incident.Status == db.Resolved) {
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].CreatedAt.After(incidents[j].CreatedAt)
+	})
+	return incidents, nil
+}
+
+// components returns the ids of the components of the workspace of the context
+func (r memoryIncidents) components(ctx context.Context, ids []string) ([]string, error) {
+	var components []string
+	found := map[string]bool{}
+	for _, id := range ids {
+		if _, ok := r.component(ctx, id); ok && !found[id] {
+			components = append(components, id)
+			found[id] = true
+		}
+	}
+	return components, missingComponent(ids, found)
+}
+
+func (r memoryIncidents) addUpdate(incidentID string, update db.IncidentUpdate) {
+	newID(&update.ID)
+	update.IncidentID = incidentID
+	if update.CreatedAt.IsZero() {
+		update.CreatedAt = time.Now()
+	}
+	r.incidentUpdates[incidentID] = append(r.incidentUpdates[incidentID], update)
+}
+
+func (r memoryIncidents) Create(ctx context.Context, incident *db.Incident, componentIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	components, err := r.components(ctx, componentIDs)
+	if err != nil {
+		return err
+	}
+	newID(&incident.ID)
+	assignWorkspace(ctx, &incident.WorkspaceID)
+	now := time.Now()
+	if incident.CreatedAt.IsZero() {
+		incident.CreatedAt = now
+	}
+	incident.UpdatedAt = now
+	stored := *incident
+	stored.Components = nil
+	stored.Updates = nil
+	r.incidents[incident.ID] = stored
+	r.incidentComponents[incident.ID] = components
+	for _, update := range incident.Updates {
+		r.addUpdate(incident.ID, update)
+	}
+	*incident = r.load(stored)
+	return r.audit(ctx, incident.WorkspaceID, db.CreateOperation, db.IncidentResource, incident.ID, nil, incident)
+}
+
+func (r memoryIncidents) PostUpdate(ctx context.Context, incident *db.Incident, update db.IncidentUpdate, componentIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, incident.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	components, err := r.components(ctx, componentIDs)
+	if err != nil {
+		return err
+	}
+	r.addUpdate(incident.ID, update)
+	stored := *incident
+	stored.WorkspaceID = before.WorkspaceID
+	stored.CreatedAt = before.CreatedAt
+	stored.UpdatedAt = time.Now()
+	stored.Components = nil
+	stored.Updates = nil
+	r.incidents[incident.ID] = stored
+	if componentIDs != nil {
+		r.incidentComponents[incident.ID] = components
+	}
+	after := r.load(stored)
+	return r.audit(ctx, before.WorkspaceID, db.UpdateOperation, db.IncidentResource, incident.ID, before, after)
+}
+
+func (r memoryIncidents) Delete(ctx context.Context, incident *db.Incident) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, incident.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.incidents, incident.ID)
+	return r.audit(ctx, before.WorkspaceID, db.DeleteOperation, db.IncidentResource, incident.ID, incident, nil)
+}
+
+type memoryChannels struct {
+	*MemoryStorage
+}
+
+func (r memoryChannels) find(ctx context.Context, id string) (db.NotificationChannel, bool) {
+	channel, ok := r.channels[id]
+	if !ok || !visible(ctx, channel.WorkspaceID) {
+		return db.NotificationChannel{}, false
+	}
+	return channel, true
+}
+
+func (r memoryChannels) Get(ctx context.Context, id string) (*db.NotificationChannel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	channel, ok := r.find(ctx, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &channel, nil
+}
+
+func (r memoryChannels) List(ctx context.Context) ([]db.NotificationChannel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var channels []db.NotificationChannel
+	for id := range r.channels {
+		if channel, ok := r.find(ctx, id); ok {
+			channels = append(channels, channel)
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+	return channels, nil
+}
+
+func (r memoryChannels) ForCheck(ctx context.Context, chk db.Check) ([]db.NotificationChannel, error) {
+	channels, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkLabels := chk.GetLabels()
+	var checkChannels []db.NotificationChannel
+	for _, channel := range channels {
+		if channel.WorkspaceID != workspaceOrDefault(chk.WorkspaceID) {
+			continue
+		}
+		labels, err := channel.GetLabels()
+		if err != nil {
+			return nil, err
+		}
+		if checkLabels.Matches(labels) {
+			checkChannels = append(checkChannels, channel)
+		}
+	}
+	return checkChannels, nil
+}
+
+func (r memoryChannels) nameTaken(channel *db.NotificationChannel) bool {
+	for _, other := range r.channels {
+		if other.ID != channel.ID && other.WorkspaceID == channel.WorkspaceID && other.Name == channel.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryChannels) Create(ctx context.Context, channel *db.NotificationChannel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newID(&channel.ID)
+	assignWorkspace(ctx, &channel.WorkspaceID)
+	if r.nameTaken(channel) {
+		return errors.Errorf("Notification channel %s already exists", channel.Name)
+	}
+	count := 0
+	for _, other := range r.channels {
+		if other.WorkspaceID == channel.WorkspaceID {
+			count++
+		}
+	}
+	err := r.checkQuota(channel.WorkspaceID, count, "notification channels", func(w db.Workspace) int {
+		return w.MaxNotificationChannels
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if channel.CreatedAt.IsZero() {
+		channel.CreatedAt = now
+	}
+	channel.UpdatedAt = now
+	r.channels[channel.ID] = *channel
+	return r.audit(ctx, channel.WorkspaceID, db.CreateOperation, db.NotificationChannelResource, channel.ID, nil, channel)
+}
+
+func (r memoryChannels) Save(ctx context.Context, channel *db.NotificationChannel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, channel.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	channel.WorkspaceID = before.WorkspaceID
+	if r.nameTaken(channel) {
+		return errors.Errorf("Notification channel %s already exists", channel.Name)
+	}
+	channel.CreatedAt = before.CreatedAt
+	channel.UpdatedAt = time.Now()
+	r.channels[channel.ID] = *channel
+	return r.audit(ctx, channel.WorkspaceID, db.UpdateOperation, db.NotificationChannelResource, channel.ID, before, channel)
+}
+
+func (r memoryChannels) Delete(ctx context.Context, channel *db.NotificationChannel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, channel.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.channels, channel.ID)
+	return r.audit(ctx, before.WorkspaceID, db.DeleteOperation, db.NotificationChannelResource, channel.ID, channel, nil)
+}
+
+type memoryStatusPages struct {
+	*MemoryStorage
+}
+
+func (r memoryStatusPages) find(ctx context.Context, id string) (db.StatusPage, bool) {
+	statusPage, ok := r.statusPages[id]
+	if !ok || !visible(ctx, statusPage.WorkspaceID) {
+		return db.StatusPage{}, false
+	}
+	statusPage.Components = nil
+	for componentID, component := range r.components {
+		if component.StatusPageID != id {
+			continue
+		}
+		component, _ = r.component(ctx, componentID)
+		statusPage.Components = append(statusPage.Components, component)
+	}
+	sort.Slice(statusPage.Components, func(i, j int) bool {
+		a, b := statusPage.Components[i], statusPage.Components[j]
+		if a.GroupName != b.GroupName {
+			return a.GroupName < b.GroupName
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Name < b.Name
+	})
+	return statusPage, true
+}
+
+func (r memoryStatusPages) Get(ctx context.Context, id string) (*db.StatusPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statusPage, ok := r.find(ctx, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &statusPage, nil
+}
+
+func (r memoryStatusPages) List(ctx context.Context) ([]db.StatusPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var statusPages []db.StatusPage
+	for id := range r.statusPages {
+		if statusPage, ok := r.find(ctx, id); ok {
+			statusPages = append(statusPages, statusPage)
+		}
+	}
+	sort.Slice(statusPages, func(i, j int) bool {
+		return statusPages[i].Slug < statusPages[j].Slug
+	})
+	return statusPages, nil
+}
+
+// slugTaken tells whether another status page of the workspace has the slug
+func (r memoryStatusPages) slugTaken(statusPage *db.StatusPage) bool {
+	for _, other := range r.statusPages {
+		if other.ID != statusPage.ID && other.WorkspaceID == statusPage.WorkspaceID && other.Slug == statusPage.Slug {
+			return true
+		}
+	}
+	return false
+}
+
+// domainTaken tells whether another status page of any workspace has the custom domain
+func (r memoryStatusPages) domainTaken(statusPage *db.StatusPage) bool {
+	if statusPage.Domain == "" {
+		return false
+	}
+	for _, other := range r.statusPages {
+		if other.ID != statusPage.ID && other.Domain == statusPage.Domain {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryStatusPages) Create(ctx context.Context, statusPage *db.StatusPage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newID(&statusPage.ID)
+	assignWorkspace(ctx, &statusPage.WorkspaceID)
+	if r.slugTaken(statusPage) {
+		return errors.Errorf("Status page %s already exists", statusPage.Slug)
+	}
+	if r.domainTaken(statusPage) {
+		return errDomainTaken(statusPage)
+	}
+	count := 0
+	for _, other := range r.statusPages {
+		if other.WorkspaceID == statusPage.WorkspaceID {
+			count++
+		}
+	}
+	err := r.checkQuota(statusPage.WorkspaceID, count, "status pages", func(w db.Workspace) int {
+		return w.MaxStatusPages
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if statusPage.CreatedAt.IsZero() {
+		statusPage.CreatedAt = now
+	}
+	statusPage.UpdatedAt = now
+	stored := *statusPage
+	stored.Components = nil
+	r.statusPages[statusPage.ID] = stored
+	return r.audit(ctx, statusPage.WorkspaceID, db.CreateOperation, db.StatusPageResource, statusPage.ID, nil, statusPage)
+}
+
+func (r memoryStatusPages) Save(ctx context.Context, statusPage *db.StatusPage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, statusPage.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	before.Components = nil
+	statusPage.WorkspaceID = before.WorkspaceID
+	if r.slugTaken(statusPage) {
+		return errors.Errorf("Status page %s already exists", statusPage.Slug)
+	}
+	if r.domainTaken(statusPage) {
+		return errDomainTaken(statusPage)
+	}
+	statusPage.CreatedAt = before.CreatedAt
+	statusPage.UpdatedAt = time.Now()
+	stored := *statusPage
+	stored.Components = nil
+	r.statusPages[statusPage.ID] = stored
+	return r.audit(ctx, statusPage.WorkspaceID, db.UpdateOperation, db.StatusPageResource, statusPage.ID, before, statusPage)
+}
+
+func (r memoryStatusPages) Delete(ctx context.Context, statusPage *db.StatusPage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.find(ctx, statusPage.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	before.Components = nil
+	statusPage.Slug = statusPage.Slug + "-deleted-" + uuid.New().String()
+	statusPage.Domain = ""
+	delete(r.statusPages, statusPage.ID)
+	return r.audit(ctx, before.WorkspaceID, db.DeleteOperation, db.StatusPageResource, statusPage.ID, before, nil)
+}
+
+func (r memoryStatusPages) GetComponent(ctx context.Context, id string) (*db.StatusPageComponent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	component, ok := r.component(ctx, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	component.Check = db.Check{}
+	return &component, nil
+}
+
+func (r memoryStatusPages) AddComponent(ctx context.Context, component *db.StatusPageComponent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newID(&component.ID)
+	now := time.Now()
+	if component.CreatedAt.IsZero() {
+		component.CreatedAt = now
+	}
+	component.UpdatedAt = now
+	stored := *component
+	stored.Check = db.Check{}
+	r.components[component.ID] = stored
+	return r.audit(ctx, "", db.CreateOperation, db.StatusPageComponentResource, component.ID, nil, component)
+}
+
+func (r memoryStatusPages) SaveComponent(ctx context.Context, component *db.StatusPageComponent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok := r.component(ctx, component.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	before.Check = db.Check{}
+	component.StatusPageID = before.StatusPageID
+	component.CreatedAt = before.CreatedAt
+	component.UpdatedAt = time.Now()
+	stored := *component
+	stored.Check = db.Check{}
+	r.components[component.ID] = stored
+	return r.audit(ctx, "", db.UpdateOperation, db.StatusPageComponentResource, component.ID, before, component)
+}
+
+func (r memoryStatusPages) RemoveComponent(ctx context.Context, component *db.StatusPageComponent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.component(ctx, component.ID); !ok {
+		return ErrNotFound
+	}
+	delete(r.components, component.ID)
+	return r.audit(ctx, "", db.DeleteOperation, db.StatusPageComponentResource, component.ID, component, nil)
+}
+
+type memoryMaintenanceWindows struct {
+	*MemoryStorage
+}
+
+// find returns the window along with the checks it lists and the ones matching its labels
+func (r memoryMaintenanceWindows) find(ctx context.Context, id string) (db.MaintenanceWindow, bool, error) {
+	window, ok := r.windows[id]
+	if !ok || !visible(ctx, window.WorkspaceID) {
+		return db.MaintenanceWindow{}, false, nil
+	}
+	window.Checks = nil
+	included := map[string]bool{}
+	for _, checkID := range r.windowChecks[id] {
+		chk, ok := r.checks[checkID]
+		if ok && !chk.DeletedAt.Valid {
+			window.Checks = append(window.Checks, copyCheck(chk))
+			included[checkID] = true
+		}
+	}
+	labels, err := window.GetLabels()
+	if err != nil {
+		return db.MaintenanceWindow{}, false, err
+	}
+	if len(labels) == 0 {
+		return window, true, nil
+	}
+	var labeled []db.Check
+	for _, chk := range r.checks {
+		if chk.WorkspaceID == window.WorkspaceID && !chk.DeletedAt.Valid && !included[chk.ID] && chk.GetLabels().Matches(labels) {
+			labeled = append(labeled, copyCheck(chk))
+		}
+	}
+	sort.Slice(labeled, func(i, j int) bool {
+		return labeled[i].ID < labeled[j].ID
+	})
+	window.Checks = append(window.Checks, labeled...)
+	return window, true, nil
+}
+
+func (r memoryMaintenanceWindows) Get(ctx context.Context, id string) (*db.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	window, ok, err := r.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &window, nil
+}
+
+func (r memoryMaintenanceWindows) list(ctx context.Context) ([]db.MaintenanceWindow, error) {
+	var windows []db.MaintenanceWindow
+	for id := range r.windows {
+		window, ok, err := r.find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			windows = append(windows, window)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].CreatedAt.Before(windows[j].CreatedAt)
+	})
+	return windows, nil
+}
+
+func (r memoryMaintenanceWindows) List(ctx context.Context) ([]db.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(ctx)
+}
+
+func (r memoryMaintenanceWindows) Active(ctx context.Context, now time.Time) (map[string]db.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	windows, err := r.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := map[string]db.MaintenanceWindow{}
+	for _, window := range windows {
+		isActive, err := window.ActiveAt(now)
+		if err != nil {
+			return nil, err
+		}
+		if !isActive {
+			continue
+		}
+		for _, chk := range window.Checks {
+			active[chk.ID] = window
+		}
+	}
+	return active, nil
+}
+
+func (r memoryMaintenanceWindows) store(window *db.MaintenanceWindow) {
+	stored := *window
+	stored.Checks = nil
+	r.windows[window.ID] = stored
+	var checkIDs []string
+	for _, chk := range window.Checks {
+		checkIDs = append(checkIDs, chk.ID)
+	}
+	r.windowChecks[window.ID] = checkIDs
+}
+
+func (r memoryMaintenanceWindows) Create(ctx context.Context, window *db.MaintenanceWindow) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newID(&window.ID)
+	assignWorkspace(ctx, &window.WorkspaceID)
+	now := time.Now()
+	if window.CreatedAt.IsZero() {
+		window.CreatedAt = now
+	}
+	window.UpdatedAt = now
+	r.store(window)
+	return r.audit(ctx, window.WorkspaceID, db.CreateOperation, db.MaintenanceWindowResource, window.ID, nil, window)
+}
+
+func (r memoryMaintenanceWindows) Save(ctx context.Context, window *db.MaintenanceWindow) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok, err := r.find(ctx, window.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	window.WorkspaceID = before.WorkspaceID
+	window.CreatedAt = before.CreatedAt
+	window.UpdatedAt = time.Now()
+	r.store(window)
+	return r.audit(ctx, window.WorkspaceID, db.UpdateOperation, db.MaintenanceWindowResource, window.ID, before, window)
+}
+
+func (r memoryMaintenanceWindows) Delete(ctx context.Context, window *db.MaintenanceWindow) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, ok, err := r.find(ctx, window.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.windows, window.ID)
+	delete(r.windowChecks, window.ID)
+	return r.audit(ctx, before.WorkspaceID, db.DeleteOperation, db.MaintenanceWindowResource, window.ID, window, nil)
+}
+
+type memoryWorkspaces struct {
+	*MemoryStorage
+}
+
+func (r memoryWorkspaces) Get(ctx context.Context, id string) (*db.Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	workspace, ok := r.workspaces[id]
+	if !ok {
+		return nil, errors.Errorf("Workspace %s not found", id)
+	}
+	return &workspace, nil
+}
+
+func (r memoryWorkspaces) Usage(ctx context.Context, id string) (*db.WorkspaceUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usage := &db.WorkspaceUsage{}
+	for _, chk := range r.checks {
+		if chk.WorkspaceID == id && !chk.DeletedAt.Valid {
+			usage.Checks++
+		}
+	}
+	for _, statusPage := range r.statusPages {
+		if statusPage.WorkspaceID == id {
+			usage.StatusPages++
+		}
+	}
+	for _, channel := range r.channels {
+		if channel.WorkspaceID == id {
+			usage.NotificationChannels++
+		}
+	}
+	return usage, nil
+}
+
+type memoryAuditLog struct {
+	*MemoryStorage
+}
+
+func (r memoryAuditLog) List(ctx context.Context, filter db.AuditFilter, p db.Pagination) (*db.AuditPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []db.AuditEntry
+	var rows []row
+	for _, entry := range r.auditEntries {
+		if !visible(ctx, entry.WorkspaceID) {
+			continue
+		}
+		if filter.ResourceType != "" && entry.ResourceType != filter.ResourceType {
+			continue
+		}
+		if filter.ResourceID != "" && entry.ResourceID != filter.ResourceID {
+			continue
+		}
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if len(filter.Operations) > 0 && !contains(filter.Operations, string(entry.Operation)) {
+			continue
+		}
+		if filter.From != nil && entry.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.Until != nil && entry.CreatedAt.After(*filter.Until) {
+			continue
+		}
+		rows = append(rows, row{value: entry.CreatedAt, id: entry.ID, index: len(entries)})
+		entries = append(entries, entry)
+	}
+	indexes, cursors, info, err := paginate(rows, db.Order{Column: db.CreatedAtColumn, Direction: db.Desc}, p)
+	if err != nil {
+		return nil, err
+	}
+	page := &db.AuditPage{
+		Cursors:    cursors,
+		PageInfo:   info,
+		TotalCount: int64(len(entries)),
+	}
+	for _, i := range indexes {
+		page.Entries = append(page.Entries, entries[i])
+	}
+	return page, nil
+}
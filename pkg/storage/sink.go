@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ExecutionSink stores the executions in a time-series backend, the uptime and the latency of
+// the checks are computed by the backend holding the executions
+type ExecutionSink interface {
+	Write(ctx context.Context, execution db.CheckExecution) error
+	Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error)
+	Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (up int64, down int64, err error)
+	// Delete removes the executions of the check, it is called when the check is purged
+	Delete(ctx context.Context, checkID string) error
+}
+
+// ExecutionLister is implemented by the sinks able to return the executions themselves, the
+// executions of the other sinks are listed from the database
+type ExecutionLister interface {
+	List(ctx context.Context, filter db.ExecutionFilter, order db.Order, p db.Pagination) (*db.ExecutionPage, error)
+}
+
+// WithExecutionSink returns the storage writing the executions to the sink, which answers the
+// uptime and latency queries. The executions are also stored in the database when
+// keepInDatabase is set, the database keeps compacting its own copy
+func WithExecutionSink(store Storage, sink ExecutionSink, keepInDatabase bool) Storage {
+	return sinkStorage{Storage: store, sink: sink, keepInDatabase: keepInDatabase}
+}
+
+type sinkStorage struct {
+	Storage
+	sink           ExecutionSink
+	keepInDatabase bool
+}
+
+// Transaction keeps writing the executions to the sink, which doesn't take part in the
+// transaction
+func (s sinkStorage) Transaction(ctx context.Context, fn func(store Storage) error) error {
+	return s.Storage.Transaction(ctx, func(store Storage) error {
+		return fn(sinkStorage{Storage: store, sink: s.sink, keepInDatabase: s.keepInDatabase})
+	})
+}
+
+func (s sinkStorage) Checks() CheckRepository {
+	return sinkChecks{CheckRepository: s.Storage.Checks(), sink: s.sink}
+}
+
+type sinkChecks struct {
+	CheckRepository
+	sink ExecutionSink
+}
+
+// Purge deletes the executions of the sink before the check, so that the next purge retries
+// when either of them fails
+func (r sinkChecks) Purge(ctx context.Context, chk *db.Check) error {
+	before, err := r.CheckRepository.GetWithDeleted(ctx, chk.ID)
+	if err != nil {
+		return err
+	}
+	if !before.DeletedAt.Valid {
+		return errors.Errorf("Check %s must be deleted before it is purged", chk.ID)
+	}
+	err = r.sink.Delete(ctx, chk.ID)
+	if err != nil {
+		return errors.Wrapf(err, "Failed deleting the executions of check %s", chk.ID)
+	}
+	return r.CheckRepository.Purge(ctx, chk)
+}
+
+func (s sinkStorage) Executions() ExecutionRepository {
+	return sinkExecutions{database: s.Storage.Executions(), sink: s.sink, keepInDatabase: s.keepInDatabase}
+}
+
+type sinkExecutions struct {
+	database       ExecutionRepository
+	sink           ExecutionSink
+	keepInDatabase bool
+}
+
+func (r sinkExecutions) Create(ctx context.Context, execution *db.CheckExecution) error {
+	newID(&execution.ID)
+	if execution.CreatedAt.IsZero() {
+		execution.CreatedAt = time.Now()
+	}
+	if r.keepInDatabase {
+		err := r.database.Create(ctx, execution)
+		if err != nil {
+			return err
+		}
+	}
+	return r.sink.Write(ctx, *execution)
+}
+
+func (r sinkExecutions) List(ctx context.Context, filter db.ExecutionFilter, order db.Order, p db.Pagination) (*db.ExecutionPage, error) {
+	if lister, ok := r.sink.(ExecutionLister); ok {
+		return lister.List(ctx, filter, order, p)
+	}
+	return r.database.List(ctx, filter, order, p)
+}
+
+func (r sinkExecutions) Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error) {
+	return r.sink.Metrics(ctx, checkID, from, until, bucket)
+}
+
+func (r sinkExecutions) Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (int64, int64, error) {
+	return r.sink.Uptime(ctx, checkID, from, until)
+}
+
+// Compact only applies to the executions of the database, the retention of the sink is
+// configured in its backend
+func (r sinkExecutions) Compact(ctx context.Context, policy db.RetentionPolicy, now time.Time) error {
+	return r.database.Compact(ctx, policy, now)
+}
@@ -1,6 +1,154 @@
 package storage
 
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ErrNotFound is returned when a row doesn't exist or belongs to another workspace
+var ErrNotFound = errors.New("record not found")
+
+// ErrDomainTaken is returned when the custom domain of a status page is used by another status
+// page, of any workspace since the domain alone selects the page served under it
+var ErrDomainTaken = errors.New("Domain already used by another status page")
+
+// Storage holds the configuration and the history of the checks. The statements only access
+// the workspace of the context and the changes are recorded in the audit log as made by the
+// actor of the context, see db.WithWorkspace and db.WithActor
 type Storage interface {
-	StoreFailure()
-	UpdateCheck()
+	Checks() CheckRepository
+	Executions() ExecutionRepository
+	Incidents() IncidentRepository
+	Channels() ChannelRepository
+	StatusPages() StatusPageRepository
+	MaintenanceWindows() MaintenanceWindowRepository
+	Workspaces() WorkspaceRepository
+	AuditLog() AuditRepository
+	// Transaction runs fn with a storage whose changes are only kept when fn succeeds
+	Transaction(ctx context.Context, fn func(store Storage) error) error
+}
+
+// CheckRepository returns the checks along with their labels
+type CheckRepository interface {
+	Get(ctx context.Context, id string) (*db.Check, error)
+	// GetWithDeleted returns the check even if it is deleted, as long as it is not purged
+	GetWithDeleted(ctx context.Context, id string) (*db.Check, error)
+	GetByIdentifier(ctx context.Context, identifier string) (*db.Check, error)
+	GetMany(ctx context.Context, ids []string) ([]db.Check, error)
+	List(ctx context.Context, filter db.CheckFilter, order db.Order, p db.Pagination) (*db.CheckPage, error)
+	// ListDeleted returns the deleted checks owned by the teams, every team when teams is nil
+	ListDeleted(ctx context.Context, teams []string) ([]db.Check, error)
+	// ListScheduled returns the checks that aren't paused
+	ListScheduled(ctx context.Context) ([]db.Check, error)
+	Create(ctx context.Context, chk *db.Check) error
+	// Save updates the check and replaces its labels, the change is recorded as the operation
+	Save(ctx context.Context, operation db.AuditOperation, chk *db.Check) error
+	// SaveResult stores the outcome of a probe, only the fields written by the probes are saved
+	// since the check may have changed during the round and the checks paused meanwhile are
+	// left as they are
+	SaveResult(ctx context.Context, chk *db.Check) error
+	Delete(ctx context.Context, chk *db.Check) error
+	Restore(ctx context.Context, chk *db.Check) error
+	Purge(ctx context.Context, chk *db.Check) error
+}
+
+type ExecutionRepository interface {
+	Create(ctx context.Context, execution *db.CheckExecution) error
+	List(ctx context.Context, filter db.ExecutionFilter, order db.Order, p db.Pagination) (*db.ExecutionPage, error)
+	Metrics(ctx context.Context, checkID string, from time.Time, until time.Time, bucket time.Duration) ([]db.MetricsBucket, error)
+	Uptime(ctx context.Context, checkID string, from time.Time, until time.Time) (up int64, down int64, err error)
+	// Compact rolls up and prunes the executions according to the retention policy
+	Compact(ctx context.Context, policy db.RetentionPolicy, now time.Time) error
+}
+
+// IncidentRepository returns the incidents along with their components and their updates,
+// latest first
+type IncidentRepository interface {
+	Get(ctx context.Context, id string) (*db.Incident, error)
+	// List returns every incident when active is nil, otherwise the open or the resolved ones
+	List(ctx context.Context, active *bool) ([]db.Incident, error)
+	// Create stores the incident affecting the components of the workspace with the given ids,
+	// it fails when a component isn't found
+	Create(ctx context.Context, incident *db.Incident, componentIDs []string) error
+	// PostUpdate appends the update and saves the status and the impact of the incident, its
+	// components are replaced unless componentIDs is nil
+	PostUpdate(ctx context.Context, incident *db.Incident, update db.IncidentUpdate, componentIDs []string) error
+	Delete(ctx context.Context, incident *db.Incident) error
+}
+
+type ChannelRepository interface {
+	Get(ctx context.Context, id string) (*db.NotificationChannel, error)
+	// List returns the channels sorted by name
+	List(ctx context.Context) ([]db.NotificationChannel, error)
+	// ForCheck returns the channels routing the notifications of the check
+	ForCheck(ctx context.Context, chk db.Check) ([]db.NotificationChannel, error)
+	Create(ctx context.Context, channel *db.NotificationChannel) error
+	Save(ctx context.Context, channel *db.NotificationChannel) error
+	Delete(ctx context.Context, channel *db.NotificationChannel) error
+}
+
+// StatusPageRepository returns the status pages along with their components
+type StatusPageRepository interface {
+	Get(ctx context.Context, id string) (*db.StatusPage, error)
+	// List returns the status pages sorted by slug
+	List(ctx context.Context) ([]db.StatusPage, error)
+	Create(ctx context.Context, statusPage *db.StatusPage) error
+	Save(ctx context.Context, statusPage *db.StatusPage) error
+	Delete(ctx context.Context, statusPage *db.StatusPage) error
+	// GetComponent returns the component of a status page of the workspace
+	GetComponent(ctx context.Context, id string) (*db.StatusPageComponent, error)
+	AddComponent(ctx context.Context, component *db.StatusPageComponent) error
+	// SaveComponent updates the name, the group, the position and the check of the component
+	SaveComponent(ctx context.Context, component *db.StatusPageComponent) error
+	RemoveComponent(ctx context.Context, component *db.StatusPageComponent) error
+}
+
+// MaintenanceWindowRepository returns the windows along with their checks, including the
+// ones matching their labels
+type MaintenanceWindowRepository interface {
+	Get(ctx context.Context, id string) (*db.MaintenanceWindow, error)
+	// List returns the windows sorted by creation
+	List(ctx context.Context) ([]db.MaintenanceWindow, error)
+	// Active returns the window in progress for every check under maintenance
+	Active(ctx context.Context, now time.Time) (map[string]db.MaintenanceWindow, error)
+	Create(ctx context.Context, window *db.MaintenanceWindow) error
+	// Save updates the window and replaces the checks it lists
+	Save(ctx context.Context, window *db.MaintenanceWindow) error
+	Delete(ctx context.Context, window *db.MaintenanceWindow) error
+}
+
+type WorkspaceRepository interface {
+	Get(ctx context.Context, id string) (*db.Workspace, error)
+	Usage(ctx context.Context, id string) (*db.WorkspaceUsage, error)
+}
+
+type AuditRepository interface {
+	// List returns a page of the audit log, latest changes first
+	List(ctx context.Context, filter db.AuditFilter, p db.Pagination) (*db.AuditPage, error)
+}
+
+// missingComponent fails with the first id that wasn't found, so that the incidents aren't
+// created without the components they were meant to affect
+func missingComponent(ids []string, found map[string]bool) error {
+	for _, id := range ids {
+		if !found[id] {
+			return errors.Wrapf(ErrNotFound, "Component %s", id)
+		}
+	}
+	return nil
+}
+
+// errDomainTaken rejects the status page whose domain is used by another one
+func errDomainTaken(statusPage *db.StatusPage) error {
+	return errors.Wrapf(ErrDomainTaken, "Status page %s", statusPage.Slug)
+}
+
+// newID assigns an id to the rows created without one
+func newID(id *string) {
+	if *id == "" {
+		*id = uuid.New().String()
+	}
 }
@@ -0,0 +1,598 @@
+package storage
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kfsoftware/statuspage/pkg/db"
+	"github.com/kfsoftware/statuspage/pkg/internal/dbtest"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+// testStorage is an implementation under test along with the way to create its workspaces
+type testStorage struct {
+	name          string
+	open          func(t *testing.T) Storage
+	saveWorkspace func(t *testing.T, store Storage, workspace db.Workspace)
+}
+
+var testStorages = []testStorage{
+	{
+		name: "memory",
+		open: func(t *testing.T) Storage {
+			return NewMemoryStorage()
+		},
+		saveWorkspace: func(t *testing.T, store Storage, workspace db.Workspace) {
+			store.(*MemoryStorage).SaveWorkspace(workspace)
+		},
+	},
+	{
+		name: "sqlite",
+		open: func(t *testing.T) Storage {
+			return NewGormStorage(dbtest.Open(t, db.SQLite, db.Migrate, db.WorkspacePlugin{}))
+		},
+		saveWorkspace: func(t *testing.T, store Storage, workspace db.Workspace) {
+			err := db.SaveWorkspace(store.(gormStorage).db, &workspace)
+			if err != nil {
+				t.Fatal(err)
+			}
+		},
+	},
+}
+
+// forEachStorage runs the test against every implementation, so that they behave the same
+func forEachStorage(t *testing.T, test func(t *testing.T, s testStorage, store Storage)) {
+	for _, s := range testStorages {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			test(t, s, s.open(t))
+		})
+	}
+}
+
+func newCheck(identifier string, labels db.Labels) *db.Check {
+	id := uuid.New().String()
+	return &db.Check{
+		ID:         id,
+		Identifier: identifier,
+		Type:       "http",
+		Frequency:  "@every 1m",
+		Status:     db.Scheduled,
+		Labels:     db.NewCheckLabels(id, labels),
+	}
+}
+
+func mustCreateCheck(t *testing.T, ctx context.Context, store Storage, identifier string, labels db.Labels) *db.Check {
+	t.Helper()
+	chk := newCheck(identifier, labels)
+	err := store.Checks().Create(ctx, chk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chk
+}
+
+func TestChecks(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		s.saveWorkspace(t, store, db.Workspace{ID: "acme", Name: "Acme", MaxChecks: 3})
+		ctx := db.WithActor(db.WithWorkspace(context.Background(), "acme"), db.Actor{Name: "alice", Method: "test"})
+		other := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+
+		web := mustCreateCheck(t, ctx, store, "web", db.Labels{"team": "web"})
+		api := mustCreateCheck(t, ctx, store, "api", db.Labels{"team": "api"})
+		mustCreateCheck(t, other, store, "web", nil)
+		if web.WorkspaceID != "acme" {
+			t.Errorf("Create() assigned workspace %q, want acme", web.WorkspaceID)
+		}
+		if err := store.Checks().Create(ctx, newCheck("web", nil)); err == nil {
+			t.Error("Create() with a taken identifier succeeded")
+		}
+		mustCreateCheck(t, ctx, store, "db", nil)
+		if err := store.Checks().Create(ctx, newCheck("cache", nil)); err == nil {
+			t.Error("Create() over the quota succeeded")
+		}
+
+		if _, err := store.Checks().Get(other, web.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() from another workspace = %v, want ErrNotFound", err)
+		}
+		found, err := store.Checks().GetByIdentifier(ctx, "api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.ID != api.ID || found.GetLabels()["team"] != "api" {
+			t.Errorf("GetByIdentifier() = %+v, want check %s with its labels", found, api.ID)
+		}
+
+		first := 2
+		page, err := store.Checks().List(ctx, db.CheckFilter{}, db.Order{}, db.Pagination{First: &first})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalCount != 3 || len(page.Checks) != 2 || page.Checks[0].Identifier != "api" || page.Checks[1].Identifier != "db" || !page.PageInfo.HasNextPage {
+			t.Fatalf("List() = %+v, want api and db out of 3 checks", page)
+		}
+		page, err = store.Checks().List(ctx, db.CheckFilter{}, db.Order{}, db.Pagination{First: &first, After: &page.Cursors[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Checks) != 1 || page.Checks[0].Identifier != "web" || page.PageInfo.HasNextPage || !page.PageInfo.HasPreviousPage {
+			t.Errorf("List() after db = %+v, want web", page)
+		}
+		page, err = store.Checks().List(ctx, db.CheckFilter{Labels: db.Labels{"team": "web"}}, db.Order{}, db.Pagination{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Checks) != 1 || page.Checks[0].ID != web.ID {
+			t.Errorf("List() by label = %+v, want web", page.Checks)
+		}
+
+		web.Description = "Website"
+		web.Labels = db.NewCheckLabels(web.ID, db.Labels{"team": "frontend"})
+		err = store.Checks().Save(ctx, db.UpdateOperation, web)
+		if err != nil {
+			t.Fatal(err)
+		}
+		web.Paused = true
+		web.Status = db.Paused
+		err = store.Checks().Save(ctx, db.PauseOperation, web)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := *web
+		result.Paused = false
+		result.Labels = nil
+		result.Status = db.Down
+		err = store.Checks().SaveResult(context.Background(), &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved, err := store.Checks().Get(ctx, web.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Description != "Website" || saved.Status != db.Paused || !saved.Paused || !saved.GetLabels().Equal(db.Labels{"team": "frontend"}) {
+			t.Errorf("Get() after saving = %+v, want the result of the paused check dropped", saved)
+		}
+		scheduled, err := store.Checks().ListScheduled(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(scheduled) != 2 {
+			t.Errorf("ListScheduled() returned %d checks, want 2", len(scheduled))
+		}
+
+		err = store.Checks().Delete(ctx, api)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Checks().Get(ctx, api.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of a deleted check = %v, want ErrNotFound", err)
+		}
+		mustCreateCheck(t, ctx, store, "api", nil)
+		deleted, err := store.Checks().ListDeleted(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deleted) != 1 || deleted[0].OriginalIdentifier() != "api" {
+			t.Fatalf("ListDeleted() = %+v, want api", deleted)
+		}
+		if err := store.Checks().Restore(ctx, &deleted[0]); err == nil {
+			t.Error("Restore() over the quota succeeded")
+		}
+		err = store.Checks().Purge(ctx, &deleted[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Checks().GetWithDeleted(ctx, api.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetWithDeleted() of a purged check = %v, want ErrNotFound", err)
+		}
+
+		usage, err := store.Workspaces().Usage(ctx, "acme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usage.Checks != 3 {
+			t.Errorf("Usage() = %d checks, want 3", usage.Checks)
+		}
+		entries, err := store.AuditLog().List(ctx, db.AuditFilter{ResourceID: web.ID}, db.Pagination{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.TotalCount != 3 || entries.Entries[0].Operation != db.PauseOperation || entries.Entries[0].Actor != "alice" {
+			t.Errorf("AuditLog().List() = %+v, want create, update and pause by alice", entries.Entries)
+		}
+		entries, err = store.AuditLog().List(other, db.AuditFilter{}, db.Pagination{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.TotalCount != 1 {
+			t.Errorf("AuditLog().List() of the default workspace returned %d entries, want 1", entries.TotalCount)
+		}
+	})
+}
+
+func TestSaveResultKeepsConcurrentChanges(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		ctx := context.Background()
+		web := mustCreateCheck(t, ctx, store, "web", db.Labels{"team": "web"})
+		api := mustCreateCheck(t, ctx, store, "api", nil)
+		scheduled, err := store.Checks().ListScheduled(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(scheduled) != 2 {
+			t.Fatalf("ListScheduled() returned %d checks, want 2", len(scheduled))
+		}
+
+		// the checks change while the round probes them
+		web.Identifier = "website"
+		web.Description = "Website"
+		web.Frequency = "@every 5m"
+		err = store.Checks().Save(ctx, db.UpdateOperation, web)
+		if err != nil {
+			t.Fatal(err)
+		}
+		api.Paused = true
+		api.Status = db.Paused
+		err = store.Checks().Save(ctx, db.PauseOperation, api)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		latestCheck := time.Now().Truncate(time.Second)
+		for i := range scheduled {
+			chk := &scheduled[i]
+			chk.Status = db.Up
+			chk.Message = "200 OK"
+			chk.LatestCheck = latestCheck
+			chk.Latency = 100 * time.Millisecond
+			err = store.Checks().SaveResult(ctx, chk)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		saved, err := store.Checks().Get(ctx, web.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Identifier != "website" || saved.Description != "Website" || saved.Frequency != "@every 5m" || !saved.GetLabels().Equal(db.Labels{"team": "web"}) {
+			t.Errorf("Get() = %+v, want the update kept", saved)
+		}
+		if saved.Status != db.Up || saved.Message != "200 OK" || saved.Latency != 100*time.Millisecond || !saved.LatestCheck.Equal(latestCheck) {
+			t.Errorf("Get() = %+v, want the result saved", saved)
+		}
+		saved, err = store.Checks().Get(ctx, api.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !saved.Paused || saved.Status != db.Paused || !saved.LatestCheck.IsZero() {
+			t.Errorf("Get() = %+v, want the check paused without the result", saved)
+		}
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		ctx := context.Background()
+		err := store.Transaction(ctx, func(tx Storage) error {
+			mustCreateCheck(t, ctx, tx, "web", nil)
+			return errors.New("failed")
+		})
+		if err == nil || err.Error() != "failed" {
+			t.Fatalf("Transaction() = %v, want the error of fn", err)
+		}
+		if _, err := store.Checks().GetByIdentifier(ctx, "web"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByIdentifier() after a rollback = %v, want ErrNotFound", err)
+		}
+		err = store.Transaction(ctx, func(tx Storage) error {
+			mustCreateCheck(t, ctx, tx, "web", nil)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Checks().GetByIdentifier(ctx, "web"); err != nil {
+			t.Errorf("GetByIdentifier() after a commit = %v", err)
+		}
+	})
+}
+
+func TestStatusPageDomains(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		s.saveWorkspace(t, store, db.Workspace{ID: "acme", Name: "Acme"})
+		acme := db.WithWorkspace(context.Background(), "acme")
+		other := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+
+		statusPage := &db.StatusPage{Slug: "status", Domain: "status.example.com"}
+		err := store.StatusPages().Create(other, statusPage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		claim := &db.StatusPage{Slug: "status", Domain: "status.example.com"}
+		if err := store.StatusPages().Create(acme, claim); !errors.Is(err, ErrDomainTaken) {
+			t.Errorf("Create() with the domain of another workspace = %v, want ErrDomainTaken", err)
+		}
+		claim.Domain = ""
+		err = store.StatusPages().Create(acme, claim)
+		if err != nil {
+			t.Fatalf("Create() without domain = %v", err)
+		}
+		if err := store.StatusPages().Create(acme, &db.StatusPage{Slug: "internal"}); err != nil {
+			t.Errorf("Create() of a second page without domain = %v", err)
+		}
+		claim.Domain = "status.example.com"
+		if err := store.StatusPages().Save(acme, claim); !errors.Is(err, ErrDomainTaken) {
+			t.Errorf("Save() with the domain of another workspace = %v, want ErrDomainTaken", err)
+		}
+		if err := store.StatusPages().Save(other, statusPage); err != nil {
+			t.Errorf("Save() keeping its own domain = %v", err)
+		}
+		err = store.StatusPages().Delete(other, statusPage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.StatusPages().Save(acme, claim); err != nil {
+			t.Errorf("Save() with the domain of a deleted page = %v", err)
+		}
+	})
+}
+
+func TestExecutions(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		ctx := context.Background()
+		chk := mustCreateCheck(t, ctx, store, "web", nil)
+		start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+		for i := 1; i <= 10; i++ {
+			err := store.Executions().Create(ctx, &db.CheckExecution{
+				CheckID:     chk.ID,
+				Status:      db.Up,
+				Latency:     time.Duration(i) * time.Millisecond,
+				Maintenance: i == 10,
+				CreatedAt:   start.Add(time.Duration(i) * time.Second),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		first := 3
+		order := db.Order{Column: db.LatencyColumn, Direction: db.Desc}
+		page, err := store.Executions().List(ctx, db.ExecutionFilter{CheckID: chk.ID}, order, db.Pagination{First: &first})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalCount != 10 || len(page.Executions) != 3 || page.Executions[0].Latency != 10*time.Millisecond {
+			t.Fatalf("List() by latency = %+v, want the 3 slowest out of 10", page)
+		}
+		page, err = store.Executions().List(ctx, db.ExecutionFilter{CheckID: chk.ID}, order, db.Pagination{First: &first, After: &page.Cursors[2]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Executions) != 3 || page.Executions[0].Latency != 7*time.Millisecond {
+			t.Errorf("List() after the third slowest = %+v, want 7ms first", page.Executions)
+		}
+
+		until := start.Add(time.Minute)
+		buckets, err := store.Executions().Metrics(ctx, chk.ID, start, until, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(buckets) != 1 {
+			t.Fatalf("Metrics() returned %d buckets, want 1", len(buckets))
+		}
+		b := buckets[0]
+		if b.Count != 10 || b.MinLatency != time.Millisecond || b.MaxLatency != 10*time.Millisecond || b.P50 != 5*time.Millisecond || b.P95 != 10*time.Millisecond {
+			t.Errorf("Metrics() = %+v", b)
+		}
+		up, down, err := store.Executions().Uptime(ctx, chk.ID, start, until)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if up != 9 || down != 0 {
+			t.Errorf("Uptime() = %d up, %d down, want 9 up without the maintenance execution", up, down)
+		}
+	})
+}
+
+func TestIncidents(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		s.saveWorkspace(t, store, db.Workspace{ID: "acme", Name: "Acme"})
+		ctx := db.WithWorkspace(context.Background(), "acme")
+		other := db.WithWorkspace(context.Background(), db.DefaultWorkspaceID)
+
+		chk := mustCreateCheck(t, ctx, store, "web", nil)
+		statusPage := &db.StatusPage{ID: uuid.New().String(), Slug: "acme", Title: "Acme"}
+		err := store.StatusPages().Create(ctx, statusPage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.StatusPages().Create(ctx, &db.StatusPage{ID: uuid.New().String(), Slug: "acme"}); err == nil {
+			t.Error("Create() with a taken slug succeeded")
+		}
+		if err := store.StatusPages().Create(other, &db.StatusPage{ID: uuid.New().String(), Slug: "acme"}); err != nil {
+			t.Errorf("Create() with the slug of another workspace = %v", err)
+		}
+		component := &db.StatusPageComponent{StatusPageID: statusPage.ID, Name: "Website", CheckID: chk.ID}
+		err = store.StatusPages().AddComponent(ctx, component)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.StatusPages().GetComponent(other, component.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetComponent() from another workspace = %v, want ErrNotFound", err)
+		}
+		found, err := store.StatusPages().Get(ctx, statusPage.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found.Components) != 1 || found.Components[0].Check.Identifier != "web" {
+			t.Errorf("Get() = %+v, want the component along with its check", found.Components)
+		}
+		moved := *component
+		moved.GroupName = "Public"
+		moved.Position = 2
+		if err := store.StatusPages().SaveComponent(other, &moved); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SaveComponent() from another workspace = %v, want ErrNotFound", err)
+		}
+		err = store.StatusPages().SaveComponent(ctx, &moved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved, err := store.StatusPages().GetComponent(ctx, component.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.GroupName != "Public" || saved.Position != 2 || saved.StatusPageID != statusPage.ID {
+			t.Errorf("GetComponent() after SaveComponent() = %+v", saved)
+		}
+
+		incident := &db.Incident{
+			ID:     uuid.New().String(),
+			Title:  "Outage",
+			Status: db.Investigating,
+			Impact: db.MajorImpact,
+			Updates: []db.IncidentUpdate{
+				{ID: uuid.New().String(), Status: db.Investigating, Body: "Looking into it", CreatedAt: time.Now().Add(-time.Minute)},
+			},
+		}
+		if err := store.Incidents().Create(ctx, incident, []string{component.ID, uuid.New().String()}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Create() with an unknown component = %v, want ErrNotFound", err)
+		}
+		if err := store.Incidents().Create(other, &db.Incident{Title: "Outage"}, []string{component.ID}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Create() with the component of another workspace = %v, want ErrNotFound", err)
+		}
+		err = store.Incidents().Create(ctx, incident, []string{component.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Incidents().Get(other, incident.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() from another workspace = %v, want ErrNotFound", err)
+		}
+		now := time.Now()
+		incident.Status = db.Resolved
+		incident.ResolvedAt = &now
+		err = store.Incidents().PostUpdate(ctx, incident, db.IncidentUpdate{
+			ID:        uuid.New().String(),
+			Status:    db.Resolved,
+			Body:      "Fixed",
+			CreatedAt: now,
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found2, err := store.Incidents().Get(ctx, incident.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found2.Status != db.Resolved || len(found2.Updates) != 2 || found2.Updates[0].Body != "Fixed" || len(found2.Components) != 1 {
+			t.Errorf("Get() after the update = %+v, want resolved with 2 updates and its component", found2)
+		}
+		active := true
+		incidents, err := store.Incidents().List(ctx, &active)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(incidents) != 0 {
+			t.Errorf("List() of the active incidents = %+v, want none", incidents)
+		}
+		if err := store.Incidents().PostUpdate(ctx, incident, db.IncidentUpdate{ID: uuid.New().String(), Status: db.Resolved}, []string{uuid.New().String()}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("PostUpdate() with an unknown component = %v, want ErrNotFound", err)
+		}
+		err = store.Incidents().PostUpdate(ctx, incident, db.IncidentUpdate{ID: uuid.New().String(), Status: db.Resolved}, []string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found2, err = store.Incidents().Get(ctx, incident.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found2.Components) != 0 {
+			t.Errorf("Get() after removing the components = %+v, want none", found2.Components)
+		}
+	})
+}
+
+func TestMaintenanceWindowsAndChannels(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s testStorage, store Storage) {
+		ctx := context.Background()
+		web := mustCreateCheck(t, ctx, store, "web", db.Labels{"team": "web"})
+		api := mustCreateCheck(t, ctx, store, "api", db.Labels{"team": "api"})
+		mustCreateCheck(t, ctx, store, "db", nil)
+
+		start := time.Now().Add(-time.Minute)
+		end := time.Now().Add(time.Hour)
+		window := &db.MaintenanceWindow{
+			ID:       uuid.New().String(),
+			Title:    "Upgrade",
+			Mode:     db.PauseMode,
+			StartsAt: &start,
+			EndsAt:   &end,
+			Checks:   []db.Check{*api},
+		}
+		err := window.SetLabels(db.Labels{"team": "web"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = store.MaintenanceWindows().Create(ctx, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		active, err := store.MaintenanceWindows().Active(ctx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(active) != 2 || active[web.ID].ID != window.ID || active[api.ID].ID != window.ID {
+			t.Errorf("Active() = %+v, want web and api", active)
+		}
+		window.Checks = nil
+		err = store.MaintenanceWindows().Save(ctx, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := store.MaintenanceWindows().Get(ctx, window.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found.Checks) != 1 || found.Checks[0].ID != web.ID {
+			t.Errorf("Get() after removing api = %+v, want web", found.Checks)
+		}
+
+		everything := &db.NotificationChannel{ID: uuid.New().String(), Name: "everything", Type: db.WebhookChannel, Url: "http://localhost"}
+		webOnly := &db.NotificationChannel{ID: uuid.New().String(), Name: "web", Type: db.SlackChannel, Url: "http://localhost"}
+		err = webOnly.SetLabels(db.Labels{"team": "web"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, channel := range []*db.NotificationChannel{webOnly, everything} {
+			err = store.Channels().Create(ctx, channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.Channels().Create(ctx, &db.NotificationChannel{ID: uuid.New().String(), Name: "web"}); err == nil {
+			t.Error("Create() with a taken name succeeded")
+		}
+		channels, err := store.Channels().List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(channels) != 2 || channels[0].Name != "everything" {
+			t.Errorf("List() = %+v, want everything and web", channels)
+		}
+		channels, err = store.Channels().ForCheck(ctx, *api)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(channels) != 1 || channels[0].ID != everything.ID {
+			t.Errorf("ForCheck(api) = %+v, want everything", channels)
+		}
+		err = store.Channels().Delete(ctx, everything)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Channels().Get(ctx, everything.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of a deleted channel = %v, want ErrNotFound", err)
+		}
+	})
+}
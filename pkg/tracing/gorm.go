@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin creates a span for every statement executed by gorm, the spans are children
+// of the span in the context of the statement, see gorm.DB.WithContext
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	err := callback.Create().Before("gorm:create").Register("tracing:before_create", startSpan("create"))
+	if err != nil {
+		return err
+	}
+	err = callback.Create().After("gorm:create").Register("tracing:after_create", endSpan("create"))
+	if err != nil {
+		return err
+	}
+	err = callback.Query().Before("gorm:query").Register("tracing:before_query", startSpan("select"))
+	if err != nil {
+		return err
+	}
+	err = callback.Query().After("gorm:query").Register("tracing:after_query", endSpan("select"))
+	if err != nil {
+		return err
+	}
+	err = callback.Update().Before("gorm:update").Register("tracing:before_update", startSpan("update"))
+	if err != nil {
+		return err
+	}
+	err = callback.Update().After("gorm:update").Register("tracing:after_update", endSpan("update"))
+	if err != nil {
+		return err
+	}
+	err = callback.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan("delete"))
+	if err != nil {
+		return err
+	}
+	err = callback.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan("delete"))
+	if err != nil {
+		return err
+	}
+	err = callback.Row().Before("gorm:row").Register("tracing:before_row", startSpan("row"))
+	if err != nil {
+		return err
+	}
+	err = callback.Row().After("gorm:row").Register("tracing:after_row", endSpan("row"))
+	if err != nil {
+		return err
+	}
+	err = callback.Raw().Before("gorm:raw").Register("tracing:before_raw", startSpan("raw"))
+	if err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan("raw"))
+}
+
+func startSpan(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// statements outside of a trace would only add noise
+			return
+		}
+		_, span := Tracer().Start(
+			ctx,
+			"db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(dbSystem(db.Dialector.Name())),
+				semconv.DBOperationKey.String(operation),
+			),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endSpan(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span, ok := value.(trace.Span)
+		if !ok {
+			return
+		}
+		defer span.End()
+		// the table is only known once the statement has been built
+		if db.Statement.Table != "" {
+			span.SetName("db." + operation + " " + db.Statement.Table)
+			span.SetAttributes(semconv.DBSQLTableKey.String(db.Statement.Table))
+		}
+		span.SetAttributes(
+			semconv.DBStatementKey.String(db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+		)
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+	}
+}
+
+func dbSystem(dialector string) string {
+	switch dialector {
+	case "postgres":
+		return "postgresql"
+	default:
+		return dialector
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GraphQLTracer is a gqlgen extension creating a span for every operation and for every
+// field with a resolver
+type GraphQLTracer struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = GraphQLTracer{}
+
+func (GraphQLTracer) ExtensionName() string {
+	return "OpenTelemetry"
+}
+
+func (GraphQLTracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (GraphQLTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	operationContext := graphql.GetOperationContext(ctx)
+	operationType := "operation"
+	if operationContext.Operation != nil {
+		operationType = string(operationContext.Operation.Operation)
+	}
+	name := operationType
+	if operationContext.OperationName != "" {
+		name += " " + operationContext.OperationName
+	}
+	ctx, span := Tracer().Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.type", operationType),
+			attribute.String("graphql.operation.name", operationContext.OperationName),
+		),
+	)
+	defer span.End()
+	response := next(ctx)
+	if response != nil && len(response.Errors) > 0 {
+		span.SetStatus(codes.Error, response.Errors.Error())
+	}
+	return response
+}
+
+func (GraphQLTracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fieldContext := graphql.GetFieldContext(ctx)
+	if fieldContext == nil || !fieldContext.IsResolver {
+		return next(ctx)
+	}
+	ctx, span := Tracer().Start(
+		ctx,
+		fieldContext.Object+"."+fieldContext.Field.Name,
+		trace.WithAttributes(attribute.String("graphql.field.path", fieldContext.Path().String())),
+	)
+	defer span.End()
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"os"
+)
+
+const instrumentationName = "github.com/kfsoftware/statuspage"
+
+type ExporterType string
+
+const (
+	// OTLPExporter sends the spans to an OTLP collector over http
+	OTLPExporter ExporterType = "otlp"
+	// StdoutExporter prints the spans as JSON
+	StdoutExporter ExporterType = "stdout"
+	// FileExporter appends the spans as JSON to a file
+	FileExporter ExporterType = "file"
+)
+
+// ShutdownFunc flushes the pending spans and releases the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the tracer provider set in the `tracing` configuration, tracing is disabled
+// when no exporter is set
+func Setup() (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	exporterType := ExporterType(viper.GetString("tracing.exporter"))
+	if exporterType == "" {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	exporter, closeExporter, err := newExporter(exporterType)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := viper.GetString("tracing.serviceName")
+	if serviceName == "" {
+		serviceName = "statuspage"
+	}
+	sampleRatio := 1.0
+	if viper.IsSet("tracing.sampleRatio") {
+		sampleRatio = viper.GetFloat64("tracing.sampleRatio")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if err != nil {
+			return err
+		}
+		return closeExporter()
+	}, nil
+}
+
+func newExporter(exporterType ExporterType) (sdktrace.SpanExporter, func() error, error) {
+	noop := func() error { return nil }
+	switch exporterType {
+	case OTLPExporter:
+		var options []otlptracehttp.Option
+		endpoint := viper.GetString("tracing.endpoint")
+		if endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if viper.GetBool("tracing.insecure") {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		headers := viper.GetStringMapString("tracing.headers")
+		if len(headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(headers))
+		}
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return exporter, noop, nil
+	case StdoutExporter:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, err
+		}
+		return exporter, noop, nil
+	case FileExporter:
+		fileName := viper.GetString("tracing.file")
+		if fileName == "" {
+			return nil, nil, errors.New("The file exporter requires `tracing.file`")
+		}
+		file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file.Close, nil
+	default:
+		return nil, nil, errors.Errorf("Invalid tracing exporter %s", exporterType)
+	}
+}
+
+// Tracer returns the tracer of the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Extract returns the request with the trace context propagated by the caller
+func Extract(r *http.Request) *http.Request {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return r.WithContext(ctx)
+}
+
+// Probe executes the probe inside of a span, http probes propagate the span to their target
+func Probe(ctx context.Context, probe check.Check, attributes ...attribute.KeyValue) check.Result {
+	ctx, span := Tracer().Start(
+		ctx,
+		"probe "+string(probe.GetType()),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("check.type", string(probe.GetType()))),
+		trace.WithAttributes(attributes...),
+	)
+	defer span.End()
+	result := probe.Check(ctx)
+	span.SetAttributes(attribute.String("check.message", result.Message))
+	if result.Statistics != nil {
+		span.SetAttributes(attribute.Int64("check.latency_ms", result.Statistics.GetTimeTaken().Milliseconds()))
+	}
+	if httpStatistics, ok := result.Statistics.(check.HttpStatistics); ok && httpStatistics.StatusCode != 0 {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(httpStatistics.StatusCode))
+	}
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, result.Error.Error())
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kfsoftware/statuspage/pkg/check"
+	"github.com/spf13/viper"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+	}
+	Status struct {
+		Code string
+	}
+}
+
+func setupFileExporter(t *testing.T) (ShutdownFunc, string) {
+	fileName := filepath.Join(t.TempDir(), "traces.json")
+	viper.Set("tracing.exporter", string(FileExporter))
+	viper.Set("tracing.file", fileName)
+	t.Cleanup(viper.Reset)
+	shutdown, err := Setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shutdown, fileName
+}
+
+func readSpans(t *testing.T, fileName string) []exportedSpan {
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var spans []exportedSpan
+	decoder := json.NewDecoder(file)
+	for {
+		var span exportedSpan
+		err := decoder.Decode(&span)
+		if err == io.EOF {
+			return spans
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		spans = append(spans, span)
+	}
+}
+
+func TestProbePropagatesTraceContext(t *testing.T) {
+	shutdown, fileName := setupFileExporter(t)
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	expectedStatusCode := http.StatusOK
+	result := Probe(context.Background(), check.NewHttpCheck(server.URL, &expectedStatusCode))
+	if result.Error == nil {
+		t.Fatal("expected the probe to fail")
+	}
+	err := shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := readSpans(t, fileName)
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "probe http" {
+		t.Errorf("unexpected span name %s", span.Name)
+	}
+	if span.Status.Code != "Error" {
+		t.Errorf("expected the span to be failed, got %s", span.Status.Code)
+	}
+	// traceparent: version-traceid-spanid-flags
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 {
+		t.Fatalf("invalid traceparent header %q", traceparent)
+	}
+	if parts[1] != span.SpanContext.TraceID {
+		t.Errorf("target received trace %s, expected %s", parts[1], span.SpanContext.TraceID)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	shutdown, fileName := setupFileExporter(t)
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	_, span := Tracer().Start(Extract(req).Context(), "query")
+	span.End()
+	err := shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := readSpans(t, fileName)
+	if len(spans) != 1 || spans[0].SpanContext.TraceID != traceID {
+		t.Errorf("expected the span to continue trace %s, got %+v", traceID, spans)
+	}
+}
+
+func TestSetupRequiresFile(t *testing.T) {
+	viper.Set("tracing.exporter", string(FileExporter))
+	t.Cleanup(viper.Reset)
+	_, err := Setup()
+	if err == nil {
+		t.Fatal("expected an error without `tracing.file`")
+	}
+}